@@ -16,10 +16,11 @@ import (
 )
 
 type ExchangeClient struct {
-	baseURL string
-	apiKey  string
-	client  *http.Client
-	tracer  trace.Tracer
+	baseURL    string
+	historyURL string
+	apiKey     string
+	client     *http.Client
+	tracer     trace.Tracer
 }
 
 type ExchangeResponse struct {
@@ -38,10 +39,11 @@ type SymbolData struct {
 	SourceExchange        string `json:"source_exchange"`
 }
 
-func NewExchangeClient(baseURL, apiKey string) *ExchangeClient {
+func NewExchangeClient(baseURL, historyURL, apiKey string) *ExchangeClient {
 	return &ExchangeClient{
-		baseURL: baseURL,
-		apiKey:  apiKey,
+		baseURL:    baseURL,
+		historyURL: historyURL,
+		apiKey:     apiKey,
 		client: &http.Client{
 			Timeout: 10 * time.Second,
 		},
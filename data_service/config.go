@@ -10,6 +10,7 @@ type Config struct {
 	Port                 string
 	APIKey               string
 	ExchangeAPIURL       string
+	HistoryAPIURL        string
 	OTELExporterEndpoint string
 }
 
@@ -49,10 +50,16 @@ func LoadConfig() (*Config, error) {
 		exchange_api_url = "https://api.freecryptoapi.com/v1/getData"
 	}
 
+	history_api_url := os.Getenv("HISTORY_API_URL")
+	if history_api_url == "" {
+		history_api_url = "https://api.freecryptoapi.com/v1/getHistory"
+	}
+
 	return &Config{
 		Port:                 port,
 		APIKey:               api_key,
 		ExchangeAPIURL:       exchange_api_url,
+		HistoryAPIURL:        history_api_url,
 		OTELExporterEndpoint: otel_endpoint,
 	}, nil
 }
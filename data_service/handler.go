@@ -89,6 +89,84 @@ func (h *Handler) GetPrice(w http.ResponseWriter, r *http.Request) {
 	h.metrics.RecordRequest(ctx, r.URL.Path, time.Since(start).Seconds(), false)
 }
 
+func (h *Handler) GetHistory(w http.ResponseWriter, r *http.Request) {
+	start := time.Now()
+
+	ctx, span := h.tracer.Start(r.Context(), "get_history_handler",
+		trace.WithAttributes(
+			attribute.String("http.method", r.Method),
+			attribute.String("http.url", r.URL.String()),
+			attribute.String("http.route", "/history"),
+		),
+	)
+	defer span.End()
+
+	symbol := r.URL.Query().Get("symbol")
+	if symbol == "" {
+		symbol = "BTC"
+	}
+	span.SetAttributes(attribute.String("request.symbol", symbol))
+
+	// По умолчанию отдаём историю за последние 30 дней
+	to := time.Now().UTC()
+	from := to.AddDate(0, 0, -30)
+	var err error
+	if v := r.URL.Query().Get("from"); v != "" {
+		if from, err = time.Parse("2006-01-02", v); err != nil {
+			span.SetStatus(codes.Error, "invalid from")
+			http.Error(w, "Invalid from date, expected YYYY-MM-DD", http.StatusBadRequest)
+			h.metrics.RecordRequest(ctx, r.URL.Path, time.Since(start).Seconds(), true)
+			return
+		}
+	}
+	if v := r.URL.Query().Get("to"); v != "" {
+		if to, err = time.Parse("2006-01-02", v); err != nil {
+			span.SetStatus(codes.Error, "invalid to")
+			http.Error(w, "Invalid to date, expected YYYY-MM-DD", http.StatusBadRequest)
+			h.metrics.RecordRequest(ctx, r.URL.Path, time.Since(start).Seconds(), true)
+			return
+		}
+	}
+
+	interval := r.URL.Query().Get("interval")
+	if interval == "" {
+		interval = DefaultInterval
+	}
+	if !intervals[interval] {
+		span.SetStatus(codes.Error, "invalid interval")
+		http.Error(w, "Invalid interval, expected 15m, 1h, 4h or 1d", http.StatusBadRequest)
+		h.metrics.RecordRequest(ctx, r.URL.Path, time.Since(start).Seconds(), true)
+		return
+	}
+	span.SetAttributes(attribute.String("request.interval", interval))
+
+	history, err := h.exchangeClient.GetHistory(ctx, symbol, interval, from, to)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, fmt.Sprintf("Failed to fetch history: %v", err))
+		log.Printf("Error fetching history for symbol %s: %v", symbol, err)
+		http.Error(w, fmt.Sprintf("Failed to fetch history: %v", err), http.StatusInternalServerError)
+
+		h.metrics.RecordRequest(ctx, r.URL.Path, time.Since(start).Seconds(), true)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(history); err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "Failed to encode response")
+		log.Printf("Error encoding response: %v", err)
+
+		h.metrics.RecordRequest(ctx, r.URL.Path, time.Since(start).Seconds(), true)
+		return
+	}
+
+	span.SetAttributes(attribute.Int("response.candles_count", len(history.Candles)))
+	span.SetStatus(codes.Ok, "success")
+
+	h.metrics.RecordRequest(ctx, r.URL.Path, time.Since(start).Seconds(), false)
+}
+
 func (h *Handler) HealthCheck(w http.ResponseWriter, r *http.Request) {
 	// start := time.Now()
 
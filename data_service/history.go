@@ -0,0 +1,190 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Candle is a single OHLCV bar returned by GET /history.
+type Candle struct {
+	Time   time.Time `json:"time"`
+	Open   float64   `json:"open"`
+	High   float64   `json:"high"`
+	Low    float64   `json:"low"`
+	Close  float64   `json:"close"`
+	Volume float64   `json:"volume"`
+}
+
+type HistoryResponse struct {
+	Status   string   `json:"status"`
+	Symbol   string   `json:"symbol"`
+	Interval string   `json:"interval"`
+	Candles  []Candle `json:"candles"`
+}
+
+// DefaultInterval is the candle size of GET /history when none is requested.
+const DefaultInterval = "1d"
+
+// intervals are the candle sizes accepted by GET /history.
+var intervals = map[string]bool{"15m": true, "1h": true, "4h": true, "1d": true}
+
+// flexFloat accepts both JSON numbers and numeric strings, the exchange API
+// returns prices as strings in some endpoints and as numbers in others.
+type flexFloat float64
+
+func (f *flexFloat) UnmarshalJSON(b []byte) error {
+	s := strings.Trim(string(b), `"`)
+	if s == "" || s == "null" {
+		*f = 0
+		return nil
+	}
+	v, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return err
+	}
+	*f = flexFloat(v)
+	return nil
+}
+
+type exchangeHistoryResponse struct {
+	Status string `json:"status"`
+	Result []struct {
+		Date   string    `json:"date"`
+		Time   string    `json:"time"`
+		Open   flexFloat `json:"open"`
+		High   flexFloat `json:"high"`
+		Low    flexFloat `json:"low"`
+		Close  flexFloat `json:"close"`
+		Volume flexFloat `json:"volume"`
+	} `json:"result"`
+}
+
+// GetHistory fetches candles of the given interval. Daily candles are the
+// exchange default, so the interval is only sent for intraday requests.
+func (c *ExchangeClient) GetHistory(ctx context.Context, symbol, interval string, from, to time.Time) (*HistoryResponse, error) {
+	ctx, span := c.tracer.Start(ctx, "exchange_history_call",
+		trace.WithAttributes(
+			attribute.String("symbol", symbol),
+			attribute.String("history.interval", interval),
+			attribute.String("api.url", c.historyURL),
+			attribute.String("history.from", from.Format("2006-01-02")),
+			attribute.String("history.to", to.Format("2006-01-02")),
+		),
+	)
+	defer span.End()
+
+	query := url.Values{}
+	query.Set("symbol", symbol)
+	query.Set("start_date", from.Format("2006-01-02"))
+	query.Set("end_date", to.Format("2006-01-02"))
+	if interval != DefaultInterval {
+		query.Set("interval", interval)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "GET", c.historyURL+"?"+query.Encode(), nil)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "failed to create request")
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	req.Header.Set("accept", "*/*")
+	req.Header.Set("Authorization", "Bearer "+c.apiKey)
+	otel.GetTextMapPropagator().Inject(ctx, propagation.HeaderCarrier(req.Header))
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "failed to execute request")
+		return nil, fmt.Errorf("failed to execute request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	span.SetAttributes(attribute.Int("http.status_code", resp.StatusCode))
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "failed to read response body")
+		return nil, fmt.Errorf("failed to read response body: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		err := fmt.Errorf("API returned status %d: %s", resp.StatusCode, string(body))
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "API returned error status")
+		return nil, err
+	}
+
+	var exchange_resp exchangeHistoryResponse
+	if err := json.Unmarshal(body, &exchange_resp); err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "failed to parse response")
+		return nil, fmt.Errorf("failed to parse response: %w", err)
+	}
+
+	if exchange_resp.Status != "success" {
+		err := fmt.Errorf("API returned status: %s", exchange_resp.Status)
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "API returned non-success status")
+		return nil, err
+	}
+
+	history := &HistoryResponse{Status: "success", Symbol: symbol, Interval: interval}
+	for _, row := range exchange_resp.Result {
+		ts := row.Time
+		if ts == "" {
+			ts = row.Date
+		}
+		t, ok := parseHistoryTime(ts)
+		if !ok {
+			continue
+		}
+		history.Candles = append(history.Candles, Candle{
+			Time:   t,
+			Open:   float64(row.Open),
+			High:   float64(row.High),
+			Low:    float64(row.Low),
+			Close:  float64(row.Close),
+			Volume: float64(row.Volume),
+		})
+	}
+	sort.Slice(history.Candles, func(i, j int) bool {
+		return history.Candles[i].Time.Before(history.Candles[j].Time)
+	})
+
+	span.SetAttributes(attribute.Int("history.candles", len(history.Candles)))
+	span.SetStatus(codes.Ok, "success")
+	return history, nil
+}
+
+func parseHistoryTime(value string) (time.Time, bool) {
+	formats := []string{
+		time.RFC3339,
+		"2006-01-02 15:04:05",
+		"2006-01-02",
+	}
+	for _, format := range formats {
+		if t, err := time.Parse(format, value); err == nil {
+			return t.UTC(), true
+		}
+	}
+	if unix, err := strconv.ParseInt(value, 10, 64); err == nil {
+		return time.Unix(unix, 0).UTC(), true
+	}
+	return time.Time{}, false
+}
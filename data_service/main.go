@@ -107,7 +107,7 @@ func main() {
 	}
 
 	// Initialize exchange client
-	exchange_client := NewExchangeClient(cfg.ExchangeAPIURL, cfg.APIKey)
+	exchange_client := NewExchangeClient(cfg.ExchangeAPIURL, cfg.HistoryAPIURL, cfg.APIKey)
 
 	// Initialize handler
 	handler := NewHandler(exchange_client, metrics)
@@ -128,6 +128,7 @@ func main() {
 	// Routes
 	r.Get("/health", handler.HealthCheck)
 	r.Get("/price", handler.GetPrice)
+	r.Get("/history", handler.GetHistory)
 
 	// Start server
 	srv := &http.Server{
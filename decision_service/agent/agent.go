@@ -0,0 +1,263 @@
+// Package agent — торговый агент, который сам запрашивает нужные данные
+// через вызовы функций вместо одного заранее собранного промпта.
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/skomaroh1845/crypto_telemetry/decision_service/ai"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// ChatModel — LLM с поддержкой вызова функций, например ai.ToolChatClient.
+type ChatModel interface {
+	Chat(ctx context.Context, messages []ai.ChatMessage, tools []ai.ToolSpec) (ai.ChatMessage, error)
+	Provider() string
+	Model() string
+}
+
+// ErrStepBudget — агент исчерпал лимит шагов и так и не дал решения.
+var ErrStepBudget = errors.New("agent step budget exhausted")
+
+// maxToolResult ограничивает ответ инструмента в истории, чтобы длинная
+// история свечей не съела контекст модели.
+const maxToolResult = 8000
+
+const systemPrompt = `You are a crypto trading analyst. Use the provided tools to look up the data you need before deciding; do not guess prices.
+When you are done, reply with a JSON object only:
+{"decision": "buy" | "sell" | "hold", "confidence": number between 0 and 1, "reason": short explanation, "horizon": e.g. "24h"}`
+
+// Step — один вызов инструмента в ходе рассуждения агента.
+type Step struct {
+	Step       int             `json:"step"`
+	Tool       string          `json:"tool"`
+	Arguments  json.RawMessage `json:"arguments"`
+	Result     json.RawMessage `json:"result,omitempty"`
+	Error      string          `json:"error,omitempty"`
+	DurationMs float64         `json:"duration_ms"`
+}
+
+// Transcript собирает шаги агента для ответа пользователю. Его кладут в
+// контекст перед вызовом GetDecision.
+type Transcript struct {
+	mu    sync.Mutex
+	steps []Step
+}
+
+func (t *Transcript) add(step Step) {
+	t.mu.Lock()
+	t.steps = append(t.steps, step)
+	t.mu.Unlock()
+}
+
+// Steps возвращает копию шагов.
+func (t *Transcript) Steps() []Step {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return append([]Step(nil), t.steps...)
+}
+
+type transcriptKey struct{}
+
+// WithTranscript возвращает контекст, в который агент запишет свои шаги.
+func WithTranscript(ctx context.Context) (context.Context, *Transcript) {
+	t := &Transcript{}
+	return context.WithValue(ctx, transcriptKey{}, t), t
+}
+
+func transcriptFrom(ctx context.Context) *Transcript {
+	if t, ok := ctx.Value(transcriptKey{}).(*Transcript); ok {
+		return t
+	}
+	return &Transcript{}
+}
+
+// Agent реализует ai.AIClient: из рыночных данных берётся только символ,
+// остальное модель запрашивает сама.
+type Agent struct {
+	model    ChatModel
+	tools    map[string]Tool
+	specs    []ai.ToolSpec
+	maxSteps int
+}
+
+func New(model ChatModel, tools []Tool, maxSteps int) *Agent {
+	if maxSteps <= 0 {
+		maxSteps = 1
+	}
+	a := &Agent{model: model, tools: map[string]Tool{}, maxSteps: maxSteps}
+	for _, t := range tools {
+		a.tools[t.Name] = t
+		a.specs = append(a.specs, t.spec())
+	}
+	return a
+}
+
+// Strategy — имя стратегии агента в аудите и ответах.
+func (a *Agent) Strategy() string {
+	return "agent:" + a.model.Provider()
+}
+
+func (a *Agent) GetDecision(ctx context.Context, data ai.MarketData) (ai.DecisionResponse, error) {
+	ctx, span := otel.Tracer("trading-agent").Start(ctx, "invoke_agent trading-agent",
+		trace.WithAttributes(
+			attribute.String("gen_ai.operation.name", "invoke_agent"),
+			attribute.String("gen_ai.agent.name", "trading-agent"),
+			attribute.String("gen_ai.system", a.model.Provider()),
+			attribute.String("gen_ai.request.model", a.model.Model()),
+			attribute.String("symbol", data.Symbol),
+			attribute.Int("agent.max_steps", a.maxSteps),
+		),
+	)
+	defer span.End()
+
+	transcript := transcriptFrom(ctx)
+	messages := []ai.ChatMessage{
+		{Role: "system", Content: systemPrompt},
+		{Role: "user", Content: fmt.Sprintf("Should I buy, sell or hold %s right now?", strings.ToUpper(data.Symbol))},
+	}
+
+	steps := 0
+	for {
+		// после исчерпания шагов модель обязана ответить без инструментов
+		tools := a.specs
+		if steps >= a.maxSteps {
+			tools = nil
+			messages = append(messages, ai.ChatMessage{
+				Role:    "user",
+				Content: "Tool budget is exhausted. Give your final JSON decision now.",
+			})
+		}
+
+		reply, err := a.model.Chat(ctx, messages, tools)
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, "Agent chat failed")
+			return ai.DecisionResponse{}, err
+		}
+		messages = append(messages, reply)
+
+		if len(reply.ToolCalls) == 0 {
+			return a.finish(span, reply.Content, steps)
+		}
+		if tools == nil {
+			err := ErrStepBudget
+			span.RecordError(err)
+			span.SetStatus(codes.Error, "Agent step budget exhausted")
+			return ai.DecisionResponse{}, err
+		}
+
+		for _, call := range reply.ToolCalls {
+			// на каждый вызов нужен ответ, даже если бюджет уже исчерпан
+			result := errorResult(ErrStepBudget)
+			if steps < a.maxSteps {
+				steps++
+				result = a.runTool(ctx, steps, call, transcript)
+			}
+			messages = append(messages, ai.ChatMessage{
+				Role:       "tool",
+				ToolCallID: call.ID,
+				Content:    result,
+			})
+		}
+	}
+}
+
+func (a *Agent) finish(span trace.Span, content string, steps int) (ai.DecisionResponse, error) {
+	span.SetAttributes(attribute.Int("agent.steps", steps))
+
+	decision, err := ai.ParseDecisionOutput(content)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "Agent decision parse failed")
+		return ai.DecisionResponse{}, fmt.Errorf("failed to parse agent decision: %w", err)
+	}
+
+	span.SetAttributes(attribute.String("agent.decision", decision.Decision))
+	span.SetStatus(codes.Ok, "Agent decision completed")
+	return ai.DecisionResponse{
+		Decision:   decision.Decision,
+		Confidence: decision.Confidence,
+		Reason:     decision.Reason,
+		Horizon:    decision.Horizon,
+		Strategy:   a.Strategy(),
+		Provider:   a.model.Provider(),
+		Model:      a.model.Model(),
+		RawOutput:  content,
+	}, nil
+}
+
+// runTool выполняет вызов в дочернем span и возвращает результат для модели.
+// Ошибка инструмента не прерывает агента: модель видит её и может
+// попробовать иначе.
+func (a *Agent) runTool(ctx context.Context, n int, call ai.ToolCall, transcript *Transcript) string {
+	ctx, span := otel.Tracer("trading-agent").Start(ctx, "execute_tool "+call.Function.Name,
+		trace.WithAttributes(
+			attribute.String("gen_ai.operation.name", "execute_tool"),
+			attribute.String("gen_ai.tool.name", call.Function.Name),
+			attribute.String("gen_ai.tool.call.id", call.ID),
+			attribute.Int("agent.step", n),
+		),
+	)
+	defer span.End()
+
+	step := Step{Step: n, Tool: call.Function.Name, Arguments: json.RawMessage(call.Function.Arguments)}
+	if !json.Valid(step.Arguments) {
+		step.Arguments, _ = json.Marshal(call.Function.Arguments)
+	}
+
+	started := time.Now()
+	value, err := a.call(ctx, call)
+	step.DurationMs = float64(time.Since(started).Microseconds()) / 1000
+
+	var content string
+	if err == nil {
+		var data []byte
+		if data, err = json.Marshal(value); err == nil {
+			content = string(data)
+			if len(content) > maxToolResult {
+				// обрезка по границе символа, чтобы не отдать модели битый UTF-8
+				n := maxToolResult
+				for n > 0 && !utf8.RuneStart(content[n]) {
+					n--
+				}
+				content = content[:n] + "...(truncated)"
+			}
+			step.Result = data
+		}
+	}
+	if err != nil {
+		step.Error = err.Error()
+		content = errorResult(err)
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "Tool call failed")
+	} else {
+		span.SetStatus(codes.Ok, "Tool call completed")
+	}
+
+	transcript.add(step)
+	return content
+}
+
+func (a *Agent) call(ctx context.Context, call ai.ToolCall) (any, error) {
+	tool, ok := a.tools[call.Function.Name]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", errUnknownTool, call.Function.Name)
+	}
+	return tool.Run(ctx, json.RawMessage(call.Function.Arguments))
+}
+
+func errorResult(err error) string {
+	data, _ := json.Marshal(map[string]string{"error": err.Error()})
+	return string(data)
+}
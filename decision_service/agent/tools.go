@@ -0,0 +1,187 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/skomaroh1845/crypto_telemetry/decision_service/ai"
+)
+
+// Tool — функция, которую модель может вызвать. Run получает аргументы в
+// виде JSON объекта и возвращает значение, которое уходит модели как JSON.
+type Tool struct {
+	Name        string
+	Description string
+	Parameters  json.RawMessage
+	Run         func(ctx context.Context, args json.RawMessage) (any, error)
+}
+
+func (t Tool) spec() ai.ToolSpec {
+	return ai.ToolSpec{
+		Type: "function",
+		Function: ai.FunctionSpec{
+			Name:        t.Name,
+			Description: t.Description,
+			Parameters:  t.Parameters,
+		},
+	}
+}
+
+// DataSource — доступ к рыночным данным, на котором построены инструменты.
+type DataSource interface {
+	Price(ctx context.Context, symbol string) (ai.MarketData, error)
+	Candles(ctx context.Context, symbol string, days int) ([]ai.Candle, error)
+}
+
+const (
+	defaultDays = 30
+	maxDays     = 120
+	minCompare  = 2
+	maxCompare  = 5
+)
+
+// MarketTools — инструменты поверх data_service: цена, свечи, индикаторы и
+// сравнение нескольких символов.
+func MarketTools(data DataSource) []Tool {
+	return []Tool{
+		{
+			Name:        "get_price",
+			Description: "Current price, 24h high/low and daily change of a crypto symbol.",
+			Parameters:  json.RawMessage(`{"type":"object","properties":{"symbol":{"type":"string","description":"Ticker, e.g. BTC"}},"required":["symbol"]}`),
+			Run: func(ctx context.Context, args json.RawMessage) (any, error) {
+				var in struct {
+					Symbol string `json:"symbol"`
+				}
+				if err := decodeArgs(args, &in); err != nil {
+					return nil, err
+				}
+				if in.Symbol == "" {
+					return nil, errNoSymbol
+				}
+				return data.Price(ctx, in.Symbol)
+			},
+		},
+		{
+			Name:        "get_candles",
+			Description: "Daily OHLCV candles of a crypto symbol for the last N days, oldest first.",
+			Parameters:  json.RawMessage(`{"type":"object","properties":{"symbol":{"type":"string"},"days":{"type":"integer","minimum":1,"maximum":120,"default":30}},"required":["symbol"]}`),
+			Run: func(ctx context.Context, args json.RawMessage) (any, error) {
+				var in struct {
+					Symbol string `json:"symbol"`
+					Days   int    `json:"days"`
+				}
+				if err := decodeArgs(args, &in); err != nil {
+					return nil, err
+				}
+				if in.Symbol == "" {
+					return nil, errNoSymbol
+				}
+				return data.Candles(ctx, in.Symbol, clampDays(in.Days))
+			},
+		},
+		{
+			Name:        "get_indicators",
+			Description: "Technical indicators (SMA, EMA, RSI, volatility, ATR, 7-day change) of a crypto symbol computed over the last N days.",
+			Parameters:  json.RawMessage(`{"type":"object","properties":{"symbol":{"type":"string"},"days":{"type":"integer","minimum":1,"maximum":120,"default":30}},"required":["symbol"]}`),
+			Run: func(ctx context.Context, args json.RawMessage) (any, error) {
+				var in struct {
+					Symbol string `json:"symbol"`
+					Days   int    `json:"days"`
+				}
+				if err := decodeArgs(args, &in); err != nil {
+					return nil, err
+				}
+				market, err := withHistory(ctx, data, in.Symbol, clampDays(in.Days))
+				if err != nil {
+					return nil, err
+				}
+				return market.Indicators, nil
+			},
+		},
+		{
+			Name:        "compare_symbols",
+			Description: "Side by side price, daily change and indicators of 2 to 5 crypto symbols.",
+			Parameters:  json.RawMessage(`{"type":"object","properties":{"symbols":{"type":"array","items":{"type":"string"},"minItems":2,"maxItems":5}},"required":["symbols"]}`),
+			Run: func(ctx context.Context, args json.RawMessage) (any, error) {
+				var in struct {
+					Symbols []string `json:"symbols"`
+				}
+				if err := decodeArgs(args, &in); err != nil {
+					return nil, err
+				}
+				if len(in.Symbols) < minCompare || len(in.Symbols) > maxCompare {
+					return nil, fmt.Errorf("symbols must contain %d to %d items", minCompare, maxCompare)
+				}
+
+				type row struct {
+					Symbol         string             `json:"symbol"`
+					Price          float64            `json:"price,omitempty"`
+					DailyChangePct float64            `json:"daily_change_pct,omitempty"`
+					Indicators     map[string]float64 `json:"indicators,omitempty"`
+					Error          string             `json:"error,omitempty"`
+				}
+				rows := make([]row, 0, len(in.Symbols))
+				for _, symbol := range in.Symbols {
+					market, err := withHistory(ctx, data, symbol, defaultDays)
+					if err != nil {
+						rows = append(rows, row{Symbol: strings.ToUpper(symbol), Error: err.Error()})
+						continue
+					}
+					rows = append(rows, row{
+						Symbol:         strings.ToUpper(symbol),
+						Price:          market.Price,
+						DailyChangePct: market.DailyChangePct,
+						Indicators:     market.Indicators,
+					})
+				}
+				return rows, nil
+			},
+		},
+	}
+}
+
+func withHistory(ctx context.Context, data DataSource, symbol string, days int) (ai.MarketData, error) {
+	if symbol == "" {
+		return ai.MarketData{}, errNoSymbol
+	}
+	market, err := data.Price(ctx, symbol)
+	if err != nil {
+		return ai.MarketData{}, err
+	}
+	candles, err := data.Candles(ctx, symbol, days)
+	if err != nil {
+		return ai.MarketData{}, err
+	}
+	market.Candles = candles
+	return ai.WithIndicators(market), nil
+}
+
+func decodeArgs(args json.RawMessage, v any) error {
+	if len(args) == 0 {
+		args = json.RawMessage("{}")
+	}
+	if err := json.Unmarshal(args, v); err != nil {
+		return fmt.Errorf("invalid arguments: %w", err)
+	}
+	return nil
+}
+
+func clampDays(days int) int {
+	switch {
+	case days <= 0:
+		return defaultDays
+	case days > maxDays:
+		return maxDays
+	default:
+		return days
+	}
+}
+
+var (
+	// errUnknownTool возвращается модели, если она вызвала несуществующую функцию
+	errUnknownTool = errors.New("unknown tool")
+	errNoSymbol    = errors.New("symbol is required")
+)
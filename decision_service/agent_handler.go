@@ -0,0 +1,117 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/skomaroh1845/crypto_telemetry/decision_service/agent"
+	"github.com/skomaroh1845/crypto_telemetry/decision_service/ai"
+	"github.com/skomaroh1845/crypto_telemetry/decision_service/risk"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// AgentResponse — решение агента и список вызванных им инструментов.
+type AgentResponse struct {
+	DecisionResponse
+	Transcript []agent.Step `json:"transcript"`
+}
+
+// dataServiceSource отдаёт инструментам агента данные из data_service.
+type dataServiceSource struct{}
+
+func (dataServiceSource) Price(ctx context.Context, symbol string) (ai.MarketData, error) {
+	return getMarketData(ctx, symbol)
+}
+
+func (dataServiceSource) Candles(ctx context.Context, symbol string, days int) ([]ai.Candle, error) {
+	return getRecentCandles(ctx, symbol, days)
+}
+
+// agentDecisionHandler принимает решение торговым агентом: модель сама
+// запрашивает цены, свечи и индикаторы, пока не решит или не исчерпает
+// лимит шагов. Риск-менеджмент и аудит те же, что у /decision.
+func (h *Handler) agentDecisionHandler(w http.ResponseWriter, r *http.Request) {
+	ctx, span := tracer.Start(r.Context(), "agent-decision-process",
+		trace.WithAttributes(attribute.String("handler", "agent-decision")),
+	)
+	defer span.End()
+
+	if h.agent == nil {
+		span.SetStatus(codes.Error, "Agent is not configured")
+		http.Error(w, "Trading agent is not configured", http.StatusNotFound)
+		return
+	}
+
+	// агент делает несколько обращений к LLM и не укладывается в общий
+	// таймаут записи сервера
+	if h.agentTimeout > 0 {
+		_ = http.NewResponseController(w).SetWriteDeadline(time.Now().Add(h.agentTimeout))
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, h.agentTimeout)
+		defer cancel()
+	}
+
+	symbol := r.URL.Query().Get("symbol")
+	span.SetAttributes(attribute.String("symbol", symbol))
+
+	chatID, err := parseChatID(r)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "Invalid chat_id")
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	profile := h.riskProfile
+	if value := r.URL.Query().Get("risk"); value != "" {
+		if profile, err = risk.ParseProfile(value); err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, "Invalid risk profile")
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+	span.SetAttributes(attribute.String("risk.profile", string(profile)))
+
+	// рынок нужен риск-менеджменту и аудиту, сама модель смотрит его через инструменты
+	market, err := getMarketData(ctx, symbol)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "Market data fetch failed")
+		http.Error(w, "Failed to fetch market data: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if candles, err := getRecentCandles(ctx, symbol, h.historyDays); err == nil {
+		market.Candles = candles
+	}
+	market = ai.WithIndicators(market)
+	market = h.withTimeframes(ctx, symbol, market)
+	market = h.withSentiment(ctx, symbol, market)
+
+	ctx, transcript := agent.WithTranscript(ctx)
+	resp, status, err := h.decide(ctx, span, decisionInput{
+		market:  market,
+		chatID:  chatID,
+		profile: profile,
+		client:  h.agent,
+	})
+	steps := transcript.Steps()
+	span.SetAttributes(attribute.Int("agent.steps", len(steps)))
+	if err != nil {
+		http.Error(w, "Failed to get agent decision: "+err.Error(), status)
+		return
+	}
+	if chatID != 0 {
+		resp.PaperTrade = h.executePaperTrade(ctx, chatID, symbol, resp.Decision, market)
+	}
+
+	span.SetStatus(codes.Ok, "Agent decision completed")
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(AgentResponse{DecisionResponse: resp, Transcript: steps})
+}
@@ -2,19 +2,120 @@ package ai
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"strings"
 	"time"
+
+	"github.com/skomaroh1845/crypto_telemetry/decision_service/regime"
+	"github.com/skomaroh1845/crypto_telemetry/decision_service/sentiment"
+	"github.com/skomaroh1845/crypto_telemetry/decision_service/timeframe"
 )
 
+type Candle struct {
+	Time   time.Time `json:"time"`
+	Open   float64   `json:"open"`
+	High   float64   `json:"high"`
+	Low    float64   `json:"low"`
+	Close  float64   `json:"close"`
+	Volume float64   `json:"volume"`
+}
+
 type MarketData struct {
-	Price     float64   `json:"price"`
-	Volume    float64   `json:"volume"`
-	Timestamp time.Time `json:"timestamp"`
+	Symbol         string             `json:"symbol,omitempty"`
+	Price          float64            `json:"price"`
+	Volume         float64            `json:"volume"`
+	Timestamp      time.Time          `json:"timestamp"`
+	High24h        float64            `json:"high_24h,omitempty"`
+	Low24h         float64            `json:"low_24h,omitempty"`
+	DailyChangePct float64            `json:"daily_change_pct,omitempty"`
+	Candles        []Candle           `json:"candles,omitempty"` // предыдущие свечи, от старых к новым
+	Indicators     map[string]float64 `json:"indicators,omitempty"`
+	Regime         *regime.Regime     `json:"regime,omitempty"` // режим рынка по свечам
+	// Timeframes — признаки по таймфреймам от младшего к старшему на момент Timestamp
+	Timeframes []timeframe.Frame `json:"timeframes,omitempty"`
+	// Sentiment — настроение новостей о символе за скользящее окно
+	Sentiment *sentiment.Summary `json:"sentiment,omitempty"`
 }
 
 type DecisionResponse struct {
-	Decision string `json:"decision"`
+	Decision      string  `json:"decision"`
+	Confidence    float64 `json:"confidence,omitempty"` // 0..1, только у LLM со структурированным ответом
+	Reason        string  `json:"reason,omitempty"`
+	Horizon       string  `json:"horizon,omitempty"`
+	Strategy      string  `json:"strategy,omitempty"`
+	Provider      string  `json:"provider,omitempty"` // звено цепочки fallback, которое ответило
+	Model         string  `json:"model,omitempty"`
+	PromptVersion string  `json:"prompt_version,omitempty"`
+	Rule          string  `json:"rule,omitempty"` // сработавшее правило стратегии rules
+	RawOutput     string  `json:"-"`              // ответ модели как есть, пишется только в аудит
 }
 
+// ErrInvalidDecision — модель ответила, но ответ не удалось разобрать в buy/sell/hold.
+var ErrInvalidDecision = errors.New("invalid decision received")
+
 type AIClient interface {
 	GetDecision(ctx context.Context, data MarketData) (DecisionResponse, error)
 }
+
+// NewClient создаёт стратегию по имени: daniilfrolov, groq, deepseek, ml,
+// rules или regime.
+func NewClient(name string) (AIClient, error) {
+	return NewClientWithOptions(name, ClientOptions{})
+}
+
+// ClientOptions — настройки стратегии поверх переменных окружения, например
+// из реестра стратегий.
+type ClientOptions struct {
+	// Model — модель LLM для groq и deepseek, пусто — модель по умолчанию
+	Model string
+	// Params — значения параметров правил стратегии rules поверх RULES_PARAMS_FILE
+	Params map[string]float64
+}
+
+// NewClientWithOptions создаёт стратегию по имени с заданными настройками.
+// Настройки, которые стратегия не поддерживает, считаются ошибкой, чтобы
+// конфигурация не молча игнорировалась.
+func NewClientWithOptions(name string, opts ClientOptions) (AIClient, error) {
+	name = strings.ToLower(name)
+	if opts.Model != "" && name != "groq" && name != "deepseek" {
+		return nil, fmt.Errorf("strategy %q does not take a model", name)
+	}
+	if len(opts.Params) > 0 && name != "rules" {
+		return nil, fmt.Errorf("strategy %q does not take parameters", name)
+	}
+	if opts.Model != "" {
+		if err := CheckPriced(opts.Model); err != nil {
+			return nil, err
+		}
+	}
+
+	switch name {
+	case "", "daniilfrolov":
+		return NewDaniilFrolovAI(), nil
+	case "groq":
+		return NewGroqClient().WithModel(opts.Model), nil
+	case "deepseek":
+		return NewDeepSeekClient().WithModel(opts.Model), nil
+	case "ml":
+		client, err := NewMLClient()
+		if err != nil {
+			return nil, err
+		}
+		return client, nil
+	case "rules":
+		client, err := NewRulesClientWithParams(opts.Params)
+		if err != nil {
+			return nil, err
+		}
+		return client, nil
+	case "regime":
+		client, err := NewRegimeClient()
+		if err != nil {
+			return nil, err
+		}
+		return client, nil
+	default:
+		return nil, fmt.Errorf("unknown strategy: %s", name)
+	}
+}
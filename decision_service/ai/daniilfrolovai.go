@@ -3,6 +3,7 @@ package ai
 import (
 	"context"
 	"math/rand"
+	"sync"
 	"time"
 
 	"go.opentelemetry.io/otel"
@@ -11,6 +12,7 @@ import (
 )
 
 type DaniilFrolovAI struct {
+	mu     sync.Mutex // rand.Rand не потокобезопасен, а клиент общий для всех запросов
 	random *rand.Rand
 }
 
@@ -39,7 +41,7 @@ func (d *DaniilFrolovAI) GetDecision(ctx context.Context, data MarketData) (Deci
 	span.SetAttributes(attribute.String("decision", decision))
 	span.SetStatus(codes.Ok, "Decision generated successfully")
 
-	return DecisionResponse{Decision: decision}, nil
+	return DecisionResponse{Decision: decision, Strategy: "daniilfrolov"}, nil
 }
 
 func (d *DaniilFrolovAI) calculateDecision(ctx context.Context, data MarketData) string {
@@ -47,6 +49,9 @@ func (d *DaniilFrolovAI) calculateDecision(ctx context.Context, data MarketData)
 	_, span := tracer.Start(ctx, "DaniilFrolovAI.calculateDecision")
 	defer span.End()
 
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
 	var decision string
 	var rule string
 
@@ -2,18 +2,19 @@ package ai
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
 	"os"
-	"strings"
 	"time"
 )
 
 type DeepSeekClient struct {
 	apiKey     string
 	baseURL    string
+	model      string
 	httpClient *http.Client
 }
 
@@ -21,6 +22,8 @@ type DeepSeekRequest struct {
 	Model    string            `json:"model"`
 	Messages []DeepSeekMessage `json:"messages"`
 	Stream   bool              `json:"stream"`
+	// ResponseFormat включает JSON mode, ответ разбирается по схеме из промпта
+	ResponseFormat *ResponseFormat `json:"response_format,omitempty"`
 }
 
 type DeepSeekMessage struct {
@@ -29,11 +32,23 @@ type DeepSeekMessage struct {
 }
 
 type DeepSeekResponse struct {
+	ID      string           `json:"id"`
+	Model   string           `json:"model"`
 	Choices []DeepSeekChoice `json:"choices"`
+	Usage   Usage            `json:"usage"`
 }
 
 type DeepSeekChoice struct {
-	Message DeepSeekMessage `json:"message"`
+	Message      DeepSeekMessage `json:"message"`
+	FinishReason string          `json:"finish_reason"`
+}
+
+func (r *DeepSeekResponse) chatResult() chatResult {
+	res := chatResult{ResponseID: r.ID, ResponseModel: r.Model, Usage: r.Usage}
+	if len(r.Choices) > 0 {
+		res.FinishReason = r.Choices[0].FinishReason
+	}
+	return res
 }
 
 func NewDeepSeekClient() *DeepSeekClient {
@@ -50,32 +65,57 @@ func NewDeepSeekClient() *DeepSeekClient {
 	return &DeepSeekClient{
 		apiKey:  apiKey,
 		baseURL: baseURL,
+		model:   "deepseek-chat",
 		httpClient: &http.Client{
 			Timeout: 30 * time.Second,
 		},
 	}
 }
 
-func (c *DeepSeekClient) GetDecision(data MarketData) (DecisionResponse, error) {
-	prompt := c.createTradingPrompt(data)
+// WithModel заменяет модель по умолчанию, пустая строка её не меняет.
+func (c *DeepSeekClient) WithModel(model string) *DeepSeekClient {
+	if model != "" {
+		c.model = model
+	}
+	return c
+}
+
+func (c *DeepSeekClient) GetDecision(ctx context.Context, data MarketData) (DecisionResponse, error) {
+	prompt, err := promptFor(ctx)
+	if err != nil {
+		return DecisionResponse{}, fmt.Errorf("failed to load prompt template: %w", err)
+	}
+	systemPrompt, userPrompt, err := prompt.Render(data)
+	if err != nil {
+		return DecisionResponse{}, err
+	}
 
 	deepSeekReq := DeepSeekRequest{
-		Model: "deepseek-chat",
+		Model: c.model,
 		Messages: []DeepSeekMessage{
 			{
 				Role:    "system",
-				Content: "You are a professional trading analyst. Analyze the given market data and provide ONLY a single word decision: 'buy', 'sell', or 'hold'. Do not provide any explanations or additional text.",
+				Content: systemPrompt,
 			},
 			{
 				Role:    "user",
-				Content: prompt,
+				Content: userPrompt,
 			},
 		},
-		Stream: false,
+		Stream:         false,
+		ResponseFormat: responseFormatFor(systemPrompt, userPrompt),
 	}
 
 	fmt.Println("Hello there!")
-	response, err := c.makeRequest(deepSeekReq)
+	var response *DeepSeekResponse
+	err = tracedChat(ctx, "deepseek", deepSeekReq.Model, hostOf(c.baseURL), func(ctx context.Context) (chatResult, error) {
+		resp, err := c.makeRequest(ctx, deepSeekReq)
+		if err != nil {
+			return chatResult{}, err
+		}
+		response = resp
+		return resp.chatResult(), nil
+	})
 	if err != nil {
 		return DecisionResponse{}, fmt.Errorf("failed to call DeepSeek API: %w", err)
 	}
@@ -85,28 +125,25 @@ func (c *DeepSeekClient) GetDecision(data MarketData) (DecisionResponse, error)
 		return DecisionResponse{}, fmt.Errorf("failed to parse AI decision: %w", err)
 	}
 
-	return DecisionResponse{Decision: decision}, nil
+	return DecisionResponse{
+		Decision:      decision.Decision,
+		Confidence:    decision.Confidence,
+		Reason:        decision.Reason,
+		Horizon:       decision.Horizon,
+		Strategy:      "deepseek",
+		Model:         deepSeekReq.Model,
+		PromptVersion: prompt.ID(),
+		RawOutput:     response.Choices[0].Message.Content,
+	}, nil
 }
 
-func (c *DeepSeekClient) createTradingPrompt(data MarketData) string {
-	return fmt.Sprintf(`
-Analyze this market data and provide a trading decision:
-
-Current Price: $%.2f
-Volume: %.2f
-Timestamp: %s
-
-Based on this data, should I buy, sell, or hold? Respond with only one word: buy, sell, or hold.
-    `, data.Price, data.Volume, data.Timestamp.Format(time.RFC3339))
-}
-
-func (c *DeepSeekClient) makeRequest(req DeepSeekRequest) (*DeepSeekResponse, error) {
+func (c *DeepSeekClient) makeRequest(ctx context.Context, req DeepSeekRequest) (*DeepSeekResponse, error) {
 	requestBody, err := json.Marshal(req)
 	if err != nil {
 		return nil, fmt.Errorf("failed to marshal request: %w", err)
 	}
 
-	httpReq, err := http.NewRequest("POST", c.baseURL+"/chat/completions", bytes.NewBuffer(requestBody))
+	httpReq, err := http.NewRequestWithContext(ctx, "POST", c.baseURL+"/chat/completions", bytes.NewBuffer(requestBody))
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
@@ -133,27 +170,9 @@ func (c *DeepSeekClient) makeRequest(req DeepSeekRequest) (*DeepSeekResponse, er
 	return &deepSeekResp, nil
 }
 
-func (c *DeepSeekClient) parseDecision(response *DeepSeekResponse) (string, error) {
+func (c *DeepSeekClient) parseDecision(response *DeepSeekResponse) (StructuredDecision, error) {
 	if len(response.Choices) == 0 {
-		return "", fmt.Errorf("no choices in response")
-	}
-
-	decision := response.Choices[0].Message.Content
-	decision = c.normalizeDecision(decision)
-
-	switch decision {
-	case "buy", "sell", "hold":
-		return decision, nil
-	default:
-		return "", fmt.Errorf("invalid decision received: %s", decision)
+		return StructuredDecision{}, fmt.Errorf("no choices in response")
 	}
-}
-
-func (c *DeepSeekClient) normalizeDecision(decision string) string {
-	decision = strings.TrimSpace(decision)
-	decision = strings.ToLower(decision)
-
-	decision = strings.TrimRight(decision, ".,!?")
-
-	return decision
+	return ParseDecisionOutput(response.Choices[0].Message.Content)
 }
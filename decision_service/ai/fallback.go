@@ -0,0 +1,186 @@
+package ai
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// ProviderSpec — звено цепочки: имя стратегии для NewClient и таймаут вызова.
+type ProviderSpec struct {
+	Name    string
+	Timeout time.Duration
+}
+
+// ParseChain разбирает строку вида "groq:5s,deepseek:15s,daniilfrolov".
+// Звенья без таймаута получают defaultTimeout.
+func ParseChain(spec string, defaultTimeout time.Duration) ([]ProviderSpec, error) {
+	var specs []ProviderSpec
+	for _, part := range strings.Split(spec, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		name, timeout, hasTimeout := strings.Cut(part, ":")
+		p := ProviderSpec{Name: strings.ToLower(strings.TrimSpace(name)), Timeout: defaultTimeout}
+		if hasTimeout {
+			d, err := time.ParseDuration(strings.TrimSpace(timeout))
+			if err != nil {
+				return nil, fmt.Errorf("invalid timeout for %s: %w", name, err)
+			}
+			p.Timeout = d
+		}
+		specs = append(specs, p)
+	}
+	if len(specs) == 0 {
+		return nil, fmt.Errorf("fallback chain is empty")
+	}
+	return specs, nil
+}
+
+// FallbackHook вызывается, когда провайдер пропущен или не ответил и запрос
+// уходит к следующему звену. reason: error, timeout, invalid_answer, budget,
+// circuit_open.
+type FallbackHook func(ctx context.Context, provider, reason string)
+
+type chainLink struct {
+	name    string
+	client  AIClient
+	timeout time.Duration
+	breaker *CircuitBreaker
+}
+
+// FallbackChain опрашивает провайдеров по порядку, пока один не вернёт
+// корректное решение. У каждого провайдера свой circuit breaker.
+type FallbackChain struct {
+	links      []*chainLink
+	onFallback FallbackHook
+}
+
+func NewFallbackChain(specs []ProviderSpec, breakerThreshold int, breakerCooldown time.Duration, onFallback FallbackHook) (*FallbackChain, error) {
+	chain := &FallbackChain{onFallback: onFallback}
+	for _, spec := range specs {
+		client, err := NewClient(spec.Name)
+		if err != nil {
+			return nil, err
+		}
+		chain.links = append(chain.links, &chainLink{
+			name:    spec.Name,
+			client:  client,
+			timeout: spec.Timeout,
+			breaker: NewCircuitBreaker(breakerThreshold, breakerCooldown),
+		})
+	}
+	if len(chain.links) == 0 {
+		return nil, fmt.Errorf("fallback chain is empty")
+	}
+	return chain, nil
+}
+
+func (c *FallbackChain) GetDecision(ctx context.Context, data MarketData) (DecisionResponse, error) {
+	tracer := otel.Tracer("ai-service")
+	ctx, span := tracer.Start(ctx, "FallbackChain.GetDecision")
+	defer span.End()
+
+	var errs []error
+	for i, link := range c.links {
+		if !link.breaker.Allow() {
+			errs = append(errs, fmt.Errorf("%s: circuit open", link.name))
+			c.fallback(ctx, span, link.name, "circuit_open")
+			continue
+		}
+
+		resp, err := c.call(ctx, link, data)
+		if err == nil {
+			link.breaker.Success()
+			resp.Provider = link.name
+			if resp.Strategy == "" {
+				resp.Strategy = link.name
+			}
+			span.SetAttributes(
+				attribute.String("ai.provider", link.name),
+				attribute.Int("ai.fallbacks", i),
+			)
+			span.SetStatus(codes.Ok, "Decision generated successfully")
+			return resp, nil
+		}
+
+		// запрос отменён или истёк его общий срок: провайдер не виноват, и
+		// следующие звенья упали бы сразу, так что цепочка останавливается
+		if ctx.Err() != nil {
+			link.breaker.Release()
+			errs = append(errs, fmt.Errorf("%s: %w", link.name, err))
+			err := fmt.Errorf("decision cancelled: %w", errors.Join(ctx.Err(), errors.Join(errs...)))
+			span.RecordError(err)
+			span.SetStatus(codes.Error, "Decision cancelled")
+			return DecisionResponse{}, err
+		}
+
+		// исчерпанный бюджет — не сбой провайдера: вызов не состоялся, так
+		// что breaker только снимает пробный запрос
+		if errors.Is(err, ErrBudgetExceeded) || errors.Is(err, ErrUnpricedModel) {
+			link.breaker.Release()
+		} else {
+			link.breaker.Failure()
+		}
+		errs = append(errs, fmt.Errorf("%s: %w", link.name, err))
+		c.fallback(ctx, span, link.name, fallbackReason(err))
+	}
+
+	err := fmt.Errorf("all providers failed: %w", errors.Join(errs...))
+	span.RecordError(err)
+	span.SetStatus(codes.Error, "All providers failed")
+	return DecisionResponse{}, err
+}
+
+func (c *FallbackChain) call(ctx context.Context, link *chainLink, data MarketData) (DecisionResponse, error) {
+	if link.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, link.timeout)
+		defer cancel()
+	}
+
+	resp, err := link.client.GetDecision(ctx, data)
+	if err != nil {
+		if ctx.Err() == context.DeadlineExceeded {
+			return resp, fmt.Errorf("%w after %s: %v", context.DeadlineExceeded, link.timeout, err)
+		}
+		return resp, err
+	}
+	switch resp.Decision {
+	case "buy", "sell", "hold":
+		return resp, nil
+	default:
+		return resp, fmt.Errorf("%w: %q", ErrInvalidDecision, resp.Decision)
+	}
+}
+
+func (c *FallbackChain) fallback(ctx context.Context, span trace.Span, provider, reason string) {
+	span.AddEvent("fallback", trace.WithAttributes(
+		attribute.String("ai.provider", provider),
+		attribute.String("fallback.reason", reason),
+	))
+	if c.onFallback != nil {
+		c.onFallback(ctx, provider, reason)
+	}
+}
+
+func fallbackReason(err error) string {
+	switch {
+	case errors.Is(err, context.DeadlineExceeded):
+		return "timeout"
+	case errors.Is(err, ErrInvalidDecision):
+		return "invalid_answer"
+	case errors.Is(err, ErrBudgetExceeded), errors.Is(err, ErrUnpricedModel):
+		return "budget"
+	default:
+		return "error"
+	}
+}
@@ -0,0 +1,353 @@
+package ai
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// ErrBudgetExceeded — дневной лимит расходов на LLM исчерпан, вызов не выполнялся.
+var ErrBudgetExceeded = errors.New("daily LLM budget exceeded")
+
+// ErrUnpricedModel — задан дневной лимит, а цены модели нет в LLM_PRICES:
+// её вызовы считались бы бесплатными и обходили лимит.
+var ErrUnpricedModel = errors.New("model has no price while LLM budget is set")
+
+// Usage — поле usage ответа OpenAI-совместимого API.
+type Usage struct {
+	PromptTokens     int `json:"prompt_tokens"`
+	CompletionTokens int `json:"completion_tokens"`
+	TotalTokens      int `json:"total_tokens"`
+}
+
+// Price — стоимость в долларах за миллион токенов.
+type Price struct {
+	Input  float64
+	Output float64
+}
+
+// PriceTable — цены по имени модели.
+type PriceTable map[string]Price
+
+// DefaultPrices — публичные цены моделей, которыми пользуются клиенты.
+func DefaultPrices() PriceTable {
+	return PriceTable{
+		"llama3-8b-8192": {Input: 0.05, Output: 0.08},
+		"deepseek-chat":  {Input: 0.27, Output: 1.10},
+
+		"llama3-groq-8b-8192-tool-use-preview": {Input: 0.19, Output: 0.19},
+	}
+}
+
+// ParsePrices разбирает строку "model=input/output,..." с ценами за миллион
+// токенов и накладывает её поверх base.
+func ParsePrices(spec string, base PriceTable) (PriceTable, error) {
+	prices := PriceTable{}
+	for model, price := range base {
+		prices[model] = price
+	}
+	for _, part := range strings.Split(spec, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		model, value, ok := strings.Cut(part, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid price %q: expected model=input/output", part)
+		}
+		in, out, ok := strings.Cut(value, "/")
+		if !ok {
+			return nil, fmt.Errorf("invalid price %q: expected model=input/output", part)
+		}
+		input, err := strconv.ParseFloat(strings.TrimSpace(in), 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid input price for %s: %w", model, err)
+		}
+		output, err := strconv.ParseFloat(strings.TrimSpace(out), 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid output price for %s: %w", model, err)
+		}
+		prices[strings.TrimSpace(model)] = Price{Input: input, Output: output}
+	}
+	return prices, nil
+}
+
+// Cost оценивает стоимость вызова. Для модели без цены возвращает 0.
+func (t PriceTable) Cost(model string, usage Usage) float64 {
+	price, ok := t[model]
+	if !ok {
+		return 0
+	}
+	return (float64(usage.PromptTokens)*price.Input + float64(usage.CompletionTokens)*price.Output) / 1e6
+}
+
+// Budget ограничивает расходы на LLM за сутки (UTC). Потраченная сумма
+// сохраняется в файл, чтобы рестарт сервиса не обнулял лимит.
+type Budget struct {
+	mu    sync.Mutex
+	limit float64
+	path  string
+	state budgetState
+}
+
+type budgetState struct {
+	Day   string  `json:"day"`
+	Spent float64 `json:"spent_usd"`
+}
+
+// NewBudget создаёт лимит в долларах на сутки, 0 — без ограничения.
+func NewBudget(dailyLimit float64, path string) (*Budget, error) {
+	b := &Budget{limit: dailyLimit, path: path}
+	if path == "" {
+		return b, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return b, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read budget state: %w", err)
+	}
+	if err := json.Unmarshal(data, &b.state); err != nil {
+		return nil, fmt.Errorf("failed to parse budget state: %w", err)
+	}
+	return b, nil
+}
+
+// Limited сообщает, задан ли дневной лимит.
+func (b *Budget) Limited() bool {
+	return b != nil && b.limit > 0
+}
+
+// Allow возвращает ErrBudgetExceeded, если лимит на сегодня исчерпан.
+func (b *Budget) Allow() error {
+	if b == nil || b.limit <= 0 {
+		return nil
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.rollover(time.Now())
+	if b.state.Spent >= b.limit {
+		return fmt.Errorf("%w: spent $%.4f of $%.2f", ErrBudgetExceeded, b.state.Spent, b.limit)
+	}
+	return nil
+}
+
+// Add учитывает стоимость выполненного вызова.
+func (b *Budget) Add(cost float64) error {
+	if b == nil || cost <= 0 {
+		return nil
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.rollover(time.Now())
+	b.state.Spent += cost
+	return b.save()
+}
+
+// Spent возвращает расходы за сегодня.
+func (b *Budget) Spent() float64 {
+	if b == nil {
+		return 0
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.rollover(time.Now())
+	return b.state.Spent
+}
+
+func (b *Budget) rollover(now time.Time) {
+	day := now.UTC().Format("2006-01-02")
+	if b.state.Day != day {
+		b.state = budgetState{Day: day}
+	}
+}
+
+func (b *Budget) save() error {
+	if b.path == "" {
+		return nil
+	}
+	data, err := json.Marshal(b.state)
+	if err != nil {
+		return fmt.Errorf("failed to marshal budget state: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(b.path), 0o755); err != nil {
+		return fmt.Errorf("failed to create budget dir: %w", err)
+	}
+	tmp := b.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return fmt.Errorf("failed to write budget state: %w", err)
+	}
+	if err := os.Rename(tmp, b.path); err != nil {
+		return fmt.Errorf("failed to save budget state: %w", err)
+	}
+	return nil
+}
+
+// LLMCall — итог одного вызова модели для метрик.
+type LLMCall struct {
+	Provider     string
+	Model        string
+	Usage        Usage
+	Cost         float64
+	FinishReason string
+	Duration     time.Duration
+	Err          error
+}
+
+// UsageHook вызывается после каждого вызова LLM, в том числе неудачного.
+type UsageHook func(ctx context.Context, call LLMCall)
+
+// GenAIConfig — цены, бюджет и хук метрик, общие для всех LLM-клиентов.
+type GenAIConfig struct {
+	Prices  PriceTable
+	Budget  *Budget
+	OnUsage UsageHook
+}
+
+var (
+	genAIMu     sync.RWMutex
+	genAIConfig = GenAIConfig{Prices: DefaultPrices()}
+)
+
+// SetGenAIConfig задаётся один раз при старте сервиса.
+func SetGenAIConfig(cfg GenAIConfig) {
+	if cfg.Prices == nil {
+		cfg.Prices = DefaultPrices()
+	}
+
+	genAIMu.Lock()
+	defer genAIMu.Unlock()
+	genAIConfig = cfg
+}
+
+// CheckPriced возвращает ErrUnpricedModel, если задан дневной лимит, а цены
+// модели нет: такие модели отклоняются при настройке, а не при первом вызове.
+func CheckPriced(model string) error {
+	return currentGenAIConfig().checkPriced(model)
+}
+
+func (c GenAIConfig) checkPriced(model string) error {
+	if !c.Budget.Limited() {
+		return nil
+	}
+	if _, ok := c.Prices[model]; !ok {
+		return fmt.Errorf("%w: %s, add it to LLM_PRICES", ErrUnpricedModel, model)
+	}
+	return nil
+}
+
+func currentGenAIConfig() GenAIConfig {
+	genAIMu.RLock()
+	defer genAIMu.RUnlock()
+	return genAIConfig
+}
+
+// chatResult — то, что клиент вытащил из ответа chat/completions.
+type chatResult struct {
+	ResponseID    string
+	ResponseModel string
+	FinishReason  string
+	Usage         Usage
+}
+
+// tracedChat оборачивает вызов chat/completions: проверяет бюджет, создаёт
+// span по семантическим конвенциям OpenTelemetry GenAI, считает стоимость
+// и передаёт итог в хук метрик.
+func tracedChat(ctx context.Context, provider, model, serverAddress string, call func(ctx context.Context) (chatResult, error)) error {
+	cfg := currentGenAIConfig()
+
+	ctx, span := otel.Tracer("ai-service").Start(ctx, "chat "+model,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			attribute.String("gen_ai.operation.name", "chat"),
+			attribute.String("gen_ai.system", provider),
+			attribute.String("gen_ai.request.model", model),
+			attribute.String("server.address", serverAddress),
+		),
+	)
+	defer span.End()
+
+	if err := cfg.Budget.Allow(); err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "LLM budget exceeded")
+		return err
+	}
+	if err := cfg.checkPriced(model); err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "LLM model has no price")
+		return err
+	}
+
+	started := time.Now()
+	res, err := call(ctx)
+	duration := time.Since(started)
+
+	cost := cfg.Prices.Cost(model, res.Usage)
+	if budgetErr := cfg.Budget.Add(cost); budgetErr != nil {
+		span.RecordError(budgetErr)
+	}
+
+	span.SetAttributes(
+		attribute.Int("gen_ai.usage.input_tokens", res.Usage.PromptTokens),
+		attribute.Int("gen_ai.usage.output_tokens", res.Usage.CompletionTokens),
+		attribute.Float64("gen_ai.usage.cost_usd", cost),
+	)
+	if res.ResponseID != "" {
+		span.SetAttributes(attribute.String("gen_ai.response.id", res.ResponseID))
+	}
+	if res.ResponseModel != "" {
+		span.SetAttributes(attribute.String("gen_ai.response.model", res.ResponseModel))
+	}
+	if res.FinishReason != "" {
+		span.SetAttributes(attribute.StringSlice("gen_ai.response.finish_reasons", []string{res.FinishReason}))
+	}
+
+	if cfg.OnUsage != nil {
+		cfg.OnUsage(ctx, LLMCall{
+			Provider:     provider,
+			Model:        model,
+			Usage:        res.Usage,
+			Cost:         cost,
+			FinishReason: res.FinishReason,
+			Duration:     duration,
+			Err:          err,
+		})
+	}
+
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "LLM call failed")
+		return err
+	}
+	span.SetStatus(codes.Ok, "LLM call completed")
+	return nil
+}
+
+func hostOf(baseURL string) string {
+	u, err := url.Parse(baseURL)
+	if err != nil {
+		return ""
+	}
+	return u.Hostname()
+}
@@ -3,18 +3,19 @@ package ai
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
 	"os"
-	"strings"
 	"time"
 )
 
 type GroqClient struct {
 	apiKey     string
 	baseURL    string
+	model      string
 	httpClient *http.Client
 }
 
@@ -22,6 +23,8 @@ type GroqRequest struct {
 	Model    string        `json:"model"`
 	Messages []GroqMessage `json:"messages"`
 	Stream   bool          `json:"stream"`
+	// ResponseFormat включает JSON mode, ответ разбирается по схеме из промпта
+	ResponseFormat *ResponseFormat `json:"response_format,omitempty"`
 }
 
 type GroqMessage struct {
@@ -30,11 +33,23 @@ type GroqMessage struct {
 }
 
 type GroqResponse struct {
+	ID      string       `json:"id"`
+	Model   string       `json:"model"`
 	Choices []GroqChoice `json:"choices"`
+	Usage   Usage        `json:"usage"`
 }
 
 type GroqChoice struct {
-	Message GroqMessage `json:"message"`
+	Message      GroqMessage `json:"message"`
+	FinishReason string      `json:"finish_reason"`
+}
+
+func (r *GroqResponse) chatResult() chatResult {
+	res := chatResult{ResponseID: r.ID, ResponseModel: r.Model, Usage: r.Usage}
+	if len(r.Choices) > 0 {
+		res.FinishReason = r.Choices[0].FinishReason
+	}
+	return res
 }
 
 func NewGroqClient() *GroqClient {
@@ -47,31 +62,56 @@ func NewGroqClient() *GroqClient {
 	return &GroqClient{
 		apiKey:  apiKey,
 		baseURL: "https://api.groq.com/openai/v1",
+		model:   "llama3-8b-8192", // Free model, very fast
 		httpClient: &http.Client{
 			Timeout: 30 * time.Second,
 		},
 	}
 }
 
-func (c *GroqClient) GetDecision(data MarketData) (DecisionResponse, error) {
-	prompt := c.createTradingPrompt(data)
+// WithModel заменяет модель по умолчанию, пустая строка её не меняет.
+func (c *GroqClient) WithModel(model string) *GroqClient {
+	if model != "" {
+		c.model = model
+	}
+	return c
+}
+
+func (c *GroqClient) GetDecision(ctx context.Context, data MarketData) (DecisionResponse, error) {
+	prompt, err := promptFor(ctx)
+	if err != nil {
+		return DecisionResponse{}, fmt.Errorf("failed to load prompt template: %w", err)
+	}
+	systemPrompt, userPrompt, err := prompt.Render(data)
+	if err != nil {
+		return DecisionResponse{}, err
+	}
 
 	groqReq := GroqRequest{
-		Model: "llama3-8b-8192", // Free model, very fast
+		Model: c.model,
 		Messages: []GroqMessage{
 			{
 				Role:    "system",
-				Content: "You are a professional trading analyst. Analyze the given market data and provide ONLY a single word decision: 'buy', 'sell', or 'hold'. Do not provide any explanations or additional text.",
+				Content: systemPrompt,
 			},
 			{
 				Role:    "user",
-				Content: prompt,
+				Content: userPrompt,
 			},
 		},
-		Stream: false,
+		Stream:         false,
+		ResponseFormat: responseFormatFor(systemPrompt, userPrompt),
 	}
 
-	response, err := c.makeRequest(groqReq)
+	var response *GroqResponse
+	err = tracedChat(ctx, "groq", groqReq.Model, hostOf(c.baseURL), func(ctx context.Context) (chatResult, error) {
+		resp, err := c.makeRequest(ctx, groqReq)
+		if err != nil {
+			return chatResult{}, err
+		}
+		response = resp
+		return resp.chatResult(), nil
+	})
 	if err != nil {
 		return DecisionResponse{}, fmt.Errorf("failed to call Groq API: %w", err)
 	}
@@ -81,28 +121,25 @@ func (c *GroqClient) GetDecision(data MarketData) (DecisionResponse, error) {
 		return DecisionResponse{}, fmt.Errorf("failed to parse AI decision: %w", err)
 	}
 
-	return DecisionResponse{Decision: decision}, nil
+	return DecisionResponse{
+		Decision:      decision.Decision,
+		Confidence:    decision.Confidence,
+		Reason:        decision.Reason,
+		Horizon:       decision.Horizon,
+		Strategy:      "groq",
+		Model:         groqReq.Model,
+		PromptVersion: prompt.ID(),
+		RawOutput:     response.Choices[0].Message.Content,
+	}, nil
 }
 
-func (c *GroqClient) createTradingPrompt(data MarketData) string {
-	return fmt.Sprintf(`
-Analyze this market data and provide a trading decision:
-
-Current Price: $%.2f
-Volume: %.2f
-Timestamp: %s
-
-Based on this data, should I buy, sell, or hold? Respond with only one word: buy, sell, or hold.
-    `, data.Price, data.Volume, data.Timestamp.Format(time.RFC3339))
-}
-
-func (c *GroqClient) makeRequest(req GroqRequest) (*GroqResponse, error) {
+func (c *GroqClient) makeRequest(ctx context.Context, req GroqRequest) (*GroqResponse, error) {
 	requestBody, err := json.Marshal(req)
 	if err != nil {
 		return nil, fmt.Errorf("failed to marshal request: %w", err)
 	}
 
-	httpReq, err := http.NewRequest("POST", c.baseURL+"/chat/completions", bytes.NewBuffer(requestBody))
+	httpReq, err := http.NewRequestWithContext(ctx, "POST", c.baseURL+"/chat/completions", bytes.NewBuffer(requestBody))
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
@@ -131,25 +168,9 @@ func (c *GroqClient) makeRequest(req GroqRequest) (*GroqResponse, error) {
 	return &groqResp, nil
 }
 
-func (c *GroqClient) parseDecision(response *GroqResponse) (string, error) {
+func (c *GroqClient) parseDecision(response *GroqResponse) (StructuredDecision, error) {
 	if len(response.Choices) == 0 {
-		return "", fmt.Errorf("no choices in response")
+		return StructuredDecision{}, fmt.Errorf("no choices in response")
 	}
-
-	decision := response.Choices[0].Message.Content
-	decision = c.normalizeDecision(decision)
-
-	switch decision {
-	case "buy", "sell", "hold":
-		return decision, nil
-	default:
-		return "", fmt.Errorf("invalid decision received: %s", decision)
-	}
-}
-
-func (c *GroqClient) normalizeDecision(decision string) string {
-	decision = strings.TrimSpace(decision)
-	decision = strings.ToLower(decision)
-	decision = strings.TrimRight(decision, ".,!?")
-	return decision
+	return ParseDecisionOutput(response.Choices[0].Message.Content)
 }
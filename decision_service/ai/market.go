@@ -0,0 +1,106 @@
+package ai
+
+import (
+	"time"
+
+	"github.com/skomaroh1845/crypto_telemetry/decision_service/indicators"
+	"github.com/skomaroh1845/crypto_telemetry/decision_service/regime"
+	"github.com/skomaroh1845/crypto_telemetry/decision_service/timeframe"
+)
+
+// WithIndicators считает индикаторы и режим рынка по закрытиям свечей и
+// текущей цене. Индикаторы, для которых не хватает истории, не попадают в
+// карту, а уже заданные в data значения и режим не перезаписываются.
+func WithIndicators(data MarketData) MarketData {
+	if len(data.Candles) == 0 {
+		return data
+	}
+
+	closes := make([]float64, 0, len(data.Candles)+1)
+	highs := make([]float64, 0, len(data.Candles)+1)
+	lows := make([]float64, 0, len(data.Candles)+1)
+	for _, c := range data.Candles {
+		closes = append(closes, c.Close)
+		highs = append(highs, c.High)
+		lows = append(lows, c.Low)
+	}
+	if data.Price > 0 {
+		closes = append(closes, data.Price)
+		highs = append(highs, max(data.Price, data.High24h))
+		low := data.Price
+		if data.Low24h > 0 {
+			low = min(low, data.Low24h)
+		}
+		lows = append(lows, low)
+	}
+
+	out := make(map[string]float64, len(data.Indicators)+8)
+	for k, v := range data.Indicators {
+		out[k] = v
+	}
+	set := func(name string, value float64, ok bool) {
+		if _, given := out[name]; ok && !given {
+			out[name] = value
+		}
+	}
+	v, ok := indicators.SMA(closes, 20)
+	set("sma_20", v, ok)
+	v, ok = indicators.SMA(closes, 50)
+	set("sma_50", v, ok)
+	v, ok = indicators.EMA(closes, 12)
+	set("ema_12", v, ok)
+	v, ok = indicators.EMA(closes, 26)
+	set("ema_26", v, ok)
+	v, ok = indicators.RSI(closes, 14)
+	set("rsi_14", v, ok)
+	v, ok = indicators.Volatility(closes, 20)
+	set("volatility_20", v, ok)
+	v, ok = indicators.ATR(highs, lows, closes, 14)
+	set("atr_14", v, ok)
+	v, ok = indicators.Change(closes, 7)
+	set("change_7_pct", v, ok)
+
+	data.Indicators = out
+	if data.Regime == nil {
+		data.Regime, _ = regime.Classify(closes, regime.DefaultConfig())
+	}
+	return data
+}
+
+// WithTimeframes добавляет признаки таймфреймов. Свечи выравниваются по
+// data.Timestamp: берутся только закрытые к этому моменту, а текущая цена
+// становится последней точкой, так что все таймфреймы смотрят на один момент.
+// Таймфреймы без достаточной истории пропускаются.
+func WithTimeframes(data MarketData, candles map[timeframe.Timeframe][]Candle) MarketData {
+	asOf := data.Timestamp
+	if asOf.IsZero() {
+		asOf = time.Now().UTC()
+	}
+	frames := make([]timeframe.Frame, 0, len(candles))
+	for _, tf := range timeframe.All {
+		series, ok := candles[tf]
+		if !ok {
+			continue
+		}
+		closes := make([]float64, 0, timeframe.Lookback+1)
+		var last time.Time
+		for _, c := range series {
+			if c.Time.Add(tf.Duration()).After(asOf) {
+				break
+			}
+			closes = append(closes, c.Close)
+			last = c.Time
+		}
+		if len(closes) > timeframe.Lookback {
+			closes = closes[len(closes)-timeframe.Lookback:]
+		}
+		if data.Price > 0 {
+			closes = append(closes, data.Price)
+		}
+		if f, ok := timeframe.Analyze(tf, last, closes); ok {
+			frames = append(frames, f)
+		}
+	}
+	data.Timeframes = frames
+	return data
+}
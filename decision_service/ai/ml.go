@@ -0,0 +1,106 @@
+package ai
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/skomaroh1845/crypto_telemetry/decision_service/ml"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+)
+
+// ErrNotEnoughHistory — в данных слишком мало свечей для признаков модели.
+var ErrNotEnoughHistory = errors.New("not enough candle history for model features")
+
+// MLClient принимает решение обученной моделью из cmd/train. Уверенность —
+// откалиброванная вероятность выбранного направления.
+type MLClient struct {
+	model *ml.Model
+}
+
+// NewMLClient загружает модель из файла ML_MODEL_FILE.
+func NewMLClient() (*MLClient, error) {
+	path := os.Getenv("ML_MODEL_FILE")
+	if path == "" {
+		return nil, errors.New("ML_MODEL_FILE is not set")
+	}
+	model, err := ml.Load(path)
+	if err != nil {
+		return nil, err
+	}
+	return &MLClient{model: model}, nil
+}
+
+func (c *MLClient) GetDecision(ctx context.Context, data MarketData) (DecisionResponse, error) {
+	_, span := otel.Tracer("ai-service").Start(ctx, "MLClient.GetDecision")
+	defer span.End()
+
+	span.SetAttributes(
+		attribute.String("ml.model_version", c.model.Version),
+		attribute.String("ml.kind", c.model.Kind),
+		attribute.Int("market.candles", len(data.Candles)),
+	)
+	if data.Symbol != "" && !strings.EqualFold(data.Symbol, c.model.Symbol) {
+		span.SetAttributes(attribute.String("ml.model_symbol", c.model.Symbol))
+	}
+
+	features, ok := ml.Features(seriesOf(data))
+	if !ok {
+		err := fmt.Errorf("%w: need %d candles, got %d", ErrNotEnoughHistory, ml.MinHistory-1, len(data.Candles))
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "Not enough history")
+		return DecisionResponse{}, err
+	}
+
+	up := c.model.Predict(features)
+	down := 1 - up
+	decision, confidence := "hold", max(up, down)
+	switch {
+	case up >= c.model.BuyThreshold:
+		decision, confidence = "buy", up
+	case down >= c.model.BuyThreshold:
+		decision, confidence = "sell", down
+	}
+
+	span.SetAttributes(
+		attribute.Float64("ml.p_up", up),
+		attribute.String("decision", decision),
+	)
+	span.SetStatus(codes.Ok, "Decision generated successfully")
+
+	return DecisionResponse{
+		Decision:   decision,
+		Confidence: confidence,
+		Reason:     fmt.Sprintf("P(up)=%.2f, P(down)=%.2f over %d candle(s)", up, down, c.model.Horizon),
+		Horizon:    fmt.Sprintf("%dd", c.model.Horizon),
+		Strategy:   "ml",
+		Provider:   "ml",
+		Model:      c.model.Version,
+	}, nil
+}
+
+// seriesOf собирает ряды для признаков так же, как WithIndicators: свечи и
+// текущая цена последней точкой. Объёмы — только закрытых свечей.
+func seriesOf(data MarketData) ml.Series {
+	var s ml.Series
+	for _, c := range data.Candles {
+		s.Close = append(s.Close, c.Close)
+		s.High = append(s.High, c.High)
+		s.Low = append(s.Low, c.Low)
+		s.Volume = append(s.Volume, c.Volume)
+	}
+	if data.Price > 0 {
+		s.Close = append(s.Close, data.Price)
+		s.High = append(s.High, max(data.Price, data.High24h))
+		low := data.Price
+		if data.Low24h > 0 {
+			low = min(low, data.Low24h)
+		}
+		s.Low = append(s.Low, low)
+	}
+	return s
+}
@@ -0,0 +1,281 @@
+package ai
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+// ResponseFormat — параметр response_format OpenAI-совместимых API.
+// {"type": "json_object"} включает JSON mode.
+type ResponseFormat struct {
+	Type string `json:"type"`
+}
+
+// responseFormatFor включает JSON mode, только если промпт просит JSON:
+// провайдеры отклоняют json_object, когда слова "json" нет в сообщениях,
+// а старые шаблоны с ответом одним словом должны продолжать работать.
+func responseFormatFor(system, user string) *ResponseFormat {
+	if strings.Contains(strings.ToLower(system+user), "json") {
+		return &ResponseFormat{Type: "json_object"}
+	}
+	return nil
+}
+
+// StructuredDecision — ответ модели по схеме из промпта:
+//
+//	{"decision": "buy|sell|hold", "confidence": 0..1, "reason": "...", "horizon": "1h|4h|1d|1w"}
+type StructuredDecision struct {
+	Decision   string  `json:"decision"`
+	Confidence float64 `json:"confidence"`
+	Reason     string  `json:"reason"`
+	Horizon    string  `json:"horizon"`
+	// Structured — ответ разобран как JSON, а не извлечён из свободного текста
+	Structured bool `json:"-"`
+}
+
+// ParseDecisionOutput разбирает ответ модели. Сначала ищется JSON-объект по
+// схеме, в том числе внутри ```json блока или окружённый текстом. Если JSON
+// нет или он не проходит валидацию, решение извлекается из свободного текста.
+func ParseDecisionOutput(text string) (StructuredDecision, error) {
+	if obj, ok := findJSONObject(text); ok {
+		if d, err := parseStructured(obj); err == nil {
+			return d, nil
+		}
+	}
+
+	decision, ok := extractDecision(text)
+	if !ok {
+		return StructuredDecision{}, fmt.Errorf("%w: %q", ErrInvalidDecision, truncate(text, 200))
+	}
+	return StructuredDecision{Decision: decision}, nil
+}
+
+// rawStructured принимает confidence и числом, и строкой ("0.8", "80%").
+type rawStructured struct {
+	Decision   string          `json:"decision"`
+	Action     string          `json:"action"`
+	Confidence json.RawMessage `json:"confidence"`
+	Reason     string          `json:"reason"`
+	Reasoning  string          `json:"reasoning"`
+	Horizon    string          `json:"horizon"`
+}
+
+func parseStructured(obj string) (StructuredDecision, error) {
+	var raw rawStructured
+	if err := json.Unmarshal([]byte(obj), &raw); err != nil {
+		return StructuredDecision{}, err
+	}
+
+	value := raw.Decision
+	if value == "" {
+		value = raw.Action
+	}
+	decision, ok := normalizeDecision(value)
+	if !ok {
+		return StructuredDecision{}, fmt.Errorf("%w: %q", ErrInvalidDecision, value)
+	}
+
+	confidence, err := parseConfidence(raw.Confidence)
+	if err != nil {
+		return StructuredDecision{}, err
+	}
+
+	reason := raw.Reason
+	if reason == "" {
+		reason = raw.Reasoning
+	}
+
+	return StructuredDecision{
+		Decision:   decision,
+		Confidence: confidence,
+		Reason:     strings.TrimSpace(reason),
+		Horizon:    strings.ToLower(strings.TrimSpace(raw.Horizon)),
+		Structured: true,
+	}, nil
+}
+
+// parseConfidence приводит уверенность к диапазону 0..1. Значения вида 80
+// или "80%" считаются процентами.
+func parseConfidence(raw json.RawMessage) (float64, error) {
+	if len(raw) == 0 || string(raw) == "null" {
+		return 0, nil
+	}
+
+	var value float64
+	if err := json.Unmarshal(raw, &value); err != nil {
+		var s string
+		if err := json.Unmarshal(raw, &s); err != nil {
+			return 0, fmt.Errorf("invalid confidence: %s", raw)
+		}
+		s = strings.TrimSpace(s)
+		percent := strings.HasSuffix(s, "%")
+		if _, err := fmt.Sscanf(strings.TrimSuffix(s, "%"), "%g", &value); err != nil {
+			return 0, fmt.Errorf("invalid confidence: %q", s)
+		}
+		if percent {
+			value /= 100
+		}
+	}
+
+	if value > 1 && value <= 100 {
+		value /= 100
+	}
+	if value < 0 || value > 1 {
+		return 0, fmt.Errorf("confidence out of range: %v", value)
+	}
+	return value, nil
+}
+
+// findJSONObject возвращает первый сбалансированный {...} в тексте, учитывая
+// строки и экранирование, так что фигурные скобки внутри reason не мешают.
+func findJSONObject(text string) (string, bool) {
+	for start := strings.IndexByte(text, '{'); start >= 0; {
+		depth, inString, escaped := 0, false, false
+		for i := start; i < len(text); i++ {
+			c := text[i]
+			switch {
+			case escaped:
+				escaped = false
+			case inString && c == '\\':
+				escaped = true
+			case c == '"':
+				inString = !inString
+			case inString:
+			case c == '{':
+				depth++
+			case c == '}':
+				depth--
+				if depth == 0 {
+					obj := text[start : i+1]
+					if json.Valid([]byte(obj)) {
+						return obj, true
+					}
+					i = len(text)
+				}
+			}
+		}
+
+		next := strings.IndexByte(text[start+1:], '{')
+		if next < 0 {
+			break
+		}
+		start += next + 1
+	}
+	return "", false
+}
+
+var (
+	// явная метка: "Decision: BUY", "**Recommendation**: hold", "Action - sell"
+	labeledDecision = regexp.MustCompile(`(?i)\b(?:decision|recommendation|action|signal|answer|verdict)\b[\s*_"'` + "`" + `]*[:=\-–][\s*_"'` + "`" + `]*([a-z]+)`)
+	// "I recommend buying", "I'd recommend: Buy", "I would hold", "suggest to sell";
+	// модальный глагол перед рекомендацией ("would recommend") входит в совпадение,
+	// иначе "would" забрал бы "recommend" как решение
+	verbDecision = regexp.MustCompile(`(?i)\b(?:(?:would|should)\s+)?(?:recommend(?:ation)?|suggest|advise|would|should|go with|opt for)\b[\s:,*_"'` + "`" + `]*(?:to\s+|a\s+)?([a-z]+)`)
+	wordPattern  = regexp.MustCompile(`(?i)[a-z']+`)
+	negations    = map[string]bool{"not": true, "don't": true, "dont": true, "never": true, "no": true, "avoid": true, "against": true, "shouldn't": true, "wouldn't": true}
+)
+
+// extractDecision ищет решение в свободном тексте: сначала по явной метке,
+// затем по глаголу рекомендации, затем по первому слову и наконец как
+// единственное упомянутое без отрицания слово buy/sell/hold. Если упомянуто
+// несколько разных решений, ответ считается неоднозначным. Отрицание перед
+// решением ("I would not recommend buying") отменяет его; если кроме
+// отрицаний ничего нет, ответ читается как hold — не входить и не выходить.
+func extractDecision(text string) (string, bool) {
+	negated := false
+	for _, re := range []*regexp.Regexp{labeledDecision, verbDecision} {
+		for _, m := range re.FindAllStringSubmatchIndex(text, -1) {
+			d, ok := normalizeDecision(text[m[2]:m[3]])
+			if !ok {
+				continue
+			}
+			if negatedAt(text, m[0], m[2]) {
+				negated = true
+				continue
+			}
+			return d, true
+		}
+	}
+
+	words := wordPattern.FindAllString(strings.ToLower(text), -1)
+	// ответ, начинающийся с решения ("BUY\n\nThe trend..."), не требует разбора дальше
+	if len(words) > 0 && !negated {
+		switch words[0] {
+		case "buy", "sell", "hold":
+			return words[0], true
+		}
+	}
+
+	found := ""
+	for _, loc := range wordPattern.FindAllStringIndex(text, -1) {
+		// синонимы и формы вроде "short term" или "selling pressure" без метки
+		// слишком часто значат другое, поэтому здесь только сами buy/sell/hold
+		w := strings.ToLower(text[loc[0]:loc[1]])
+		if w != "buy" && w != "sell" && w != "hold" {
+			continue
+		}
+		if negatedAt(text, loc[0], loc[0]) {
+			negated = true
+			continue
+		}
+		if found != "" && found != w {
+			return "", false
+		}
+		found = w
+	}
+	if found == "" && negated {
+		return "hold", true
+	}
+	return found, found != ""
+}
+
+// negatedAt сообщает, есть ли отрицание в нескольких словах перед совпадением
+// start или внутри него до решения at. Слова ищутся только в той же части
+// предложения, так что в "Don't buy now, sell" sell не отрицается.
+func negatedAt(text string, start, at int) bool {
+	from := max(0, start-40)
+	prefix := text[from:at]
+	if i := strings.LastIndexAny(prefix, ".,!?;\n"); i >= 0 {
+		prefix = prefix[i+1:]
+	}
+	words := wordPattern.FindAllString(strings.ToLower(prefix), -1)
+	if len(words) > 4 {
+		words = words[len(words)-4:]
+	}
+	for _, w := range words {
+		if negations[w] || strings.HasSuffix(w, "n't") {
+			return true
+		}
+	}
+	return false
+}
+
+// normalizeDecision сводит слово к buy/sell/hold, понимая регистр,
+// пунктуацию и формы вроде "buying" или "BUY!".
+func normalizeDecision(value string) (string, bool) {
+	value = strings.ToLower(strings.Trim(value, " \t\r\n.,!?:;*_\"'`()[]"))
+	switch value {
+	case "buy", "buying", "long":
+		return "buy", true
+	case "sell", "selling", "short":
+		return "sell", true
+	case "hold", "holding", "wait", "neutral":
+		return "hold", true
+	default:
+		return "", false
+	}
+}
+
+// truncate обрезает строку до n байт, не разрывая символ UTF-8.
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
+	}
+	return s[:n] + "..."
+}
@@ -0,0 +1,117 @@
+package ai
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestParseDecisionOutput(t *testing.T) {
+	tests := []struct {
+		name       string
+		text       string
+		want       string
+		confidence float64
+		structured bool
+		wantErr    bool
+	}{
+		{name: "bare word", text: "BUY", want: "buy"},
+		{name: "word with punctuation", text: "Hold.", want: "hold"},
+		{name: "leading word with explanation", text: "SELL\n\nThe trend has reversed and volume is drying up.", want: "sell"},
+		{
+			name:       "json",
+			text:       `{"decision": "buy", "confidence": 0.72, "reason": "breakout", "horizon": "1d"}`,
+			want:       "buy",
+			confidence: 0.72,
+			structured: true,
+		},
+		{
+			name:       "markdown json block",
+			text:       "Here is my analysis:\n```json\n{\"decision\": \"SELL\", \"confidence\": \"80%\", \"reason\": \"RSI {overbought}\"}\n```\nTrade carefully.",
+			want:       "sell",
+			confidence: 0.8,
+			structured: true,
+		},
+		{
+			name:       "action and reasoning keys",
+			text:       `{"action": "holding", "confidence": 65, "reasoning": "range"}`,
+			want:       "hold",
+			confidence: 0.65,
+			structured: true,
+		},
+		{name: "invalid json falls back to text", text: `{"decision": "moon"} Decision: hold`, want: "hold"},
+		{name: "bold label", text: "**Recommendation**: Buy\n\nMomentum is strong.", want: "buy"},
+		{name: "markdown heading label", text: "### Analysis\nPrice is flat.\n\n**Decision:** `HOLD`", want: "hold"},
+		{name: "dash label", text: "Action - sell, the support broke.", want: "sell"},
+		{name: "recommend verb", text: "Given the oversold RSI, I recommend buying a small position.", want: "buy"},
+		{name: "would verb", text: "With the current volatility I would hold.", want: "hold"},
+		{name: "suggest to", text: "I suggest to sell before the weekend.", want: "sell"},
+		{name: "would recommend buying", text: "I would recommend buying BTC.", want: "buy"},
+		{name: "would recommend holding", text: "I would recommend holding for now.", want: "hold"},
+		{name: "should suggest", text: "You should consider it, I'd suggest selling.", want: "sell"},
+		{name: "negated recommendation", text: "I would not recommend buying right now.", want: "hold"},
+		{name: "dont recommend", text: "I don't recommend selling at these levels.", want: "hold"},
+		{name: "dont think you should", text: "I don't think you should buy here.", want: "hold"},
+		{name: "negated then positive", text: "Do not buy. Sell the position instead.", want: "sell"},
+		{name: "negation before comma", text: "Don't buy now, sell.", want: "sell"},
+		{name: "never sell", text: "Never sell into panic; hold through the dip.", want: "hold"},
+		{name: "negated word only", text: "Avoid buy orders until the trend confirms.", want: "hold"},
+		{name: "ambiguous mentions", text: "You could buy the dip or sell the rally, depends on your view.", wantErr: true},
+		{name: "hedged between options", text: "Maybe buy, maybe hold, the market is uncertain.", wantErr: true},
+		{name: "no decision", text: "The market is unpredictable today.", wantErr: true},
+		{name: "empty", text: "", wantErr: true},
+		{name: "selling pressure is not a decision", text: "There is selling pressure but nothing conclusive.", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseDecisionOutput(tt.text)
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalidDecision) {
+					t.Fatalf("ParseDecisionOutput(%q) = %+v, %v; want ErrInvalidDecision", tt.text, got, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseDecisionOutput(%q) error: %v", tt.text, err)
+			}
+			if got.Decision != tt.want {
+				t.Errorf("decision = %q, want %q", got.Decision, tt.want)
+			}
+			if got.Confidence != tt.confidence {
+				t.Errorf("confidence = %v, want %v", got.Confidence, tt.confidence)
+			}
+			if got.Structured != tt.structured {
+				t.Errorf("structured = %v, want %v", got.Structured, tt.structured)
+			}
+		})
+	}
+}
+
+func TestParseConfidenceOutOfRange(t *testing.T) {
+	// JSON с уверенностью вне диапазона отбрасывается, решение берётся из текста
+	got, err := ParseDecisionOutput(`{"decision": "buy", "confidence": 250}`)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got.Structured || got.Decision != "buy" {
+		t.Errorf("got %+v, want unstructured buy", got)
+	}
+}
+
+func TestTruncateKeepsRunes(t *testing.T) {
+	s := strings.Repeat("ж", 150) // по два байта на символ
+	for _, n := range []int{1, 199, 200, 201} {
+		got := truncate(s, n)
+		if !utf8.ValidString(got) {
+			t.Errorf("truncate(_, %d) split a rune: %q", n, got)
+		}
+		if len(got) > n+len("...") {
+			t.Errorf("truncate(_, %d) returned %d bytes", n, len(got))
+		}
+	}
+	if got := truncate("short", 10); got != "short" {
+		t.Errorf("truncate short string = %q", got)
+	}
+}
@@ -0,0 +1,169 @@
+package ai
+
+import (
+	"context"
+	"crypto/sha256"
+	"embed"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+)
+
+//go:embed prompts/*.tmpl
+var builtinPrompts embed.FS
+
+// PromptTemplate — шаблон text/template с блоками "system" и "user".
+// Необязательный блок "version" задаёт версию явно, иначе версией служит
+// хэш содержимого файла, так что любая правка шаблона меняет версию.
+type PromptTemplate struct {
+	Name    string
+	Version string
+	tmpl    *template.Template
+}
+
+var promptFuncs = template.FuncMap{
+	"last": func(candles []Candle, n int) []Candle {
+		if len(candles) <= n {
+			return candles
+		}
+		return candles[len(candles)-n:]
+	},
+	"rfc3339": func(t time.Time) string { return t.Format(time.RFC3339) },
+}
+
+func ParsePrompt(name string, text []byte) (*PromptTemplate, error) {
+	tmpl, err := template.New(name).Funcs(promptFuncs).Option("missingkey=zero").Parse(string(text))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse prompt %s: %w", name, err)
+	}
+	for _, block := range []string{"system", "user"} {
+		if tmpl.Lookup(block) == nil {
+			return nil, fmt.Errorf("prompt %s has no %q block", name, block)
+		}
+	}
+
+	sum := sha256.Sum256(text)
+	version := hex.EncodeToString(sum[:4])
+	if tmpl.Lookup("version") != nil {
+		var b strings.Builder
+		if err := tmpl.ExecuteTemplate(&b, "version", nil); err != nil {
+			return nil, fmt.Errorf("failed to render version of prompt %s: %w", name, err)
+		}
+		if declared := strings.TrimSpace(b.String()); declared != "" {
+			version = declared + "-" + version
+		}
+	}
+
+	return &PromptTemplate{Name: name, Version: version, tmpl: tmpl}, nil
+}
+
+// ID — имя и версия шаблона, например "default@v1-3fa9c21b".
+func (p *PromptTemplate) ID() string {
+	return p.Name + "@" + p.Version
+}
+
+func (p *PromptTemplate) Render(data MarketData) (system, user string, err error) {
+	var sb, ub strings.Builder
+	if err := p.tmpl.ExecuteTemplate(&sb, "system", data); err != nil {
+		return "", "", fmt.Errorf("failed to render system prompt %s: %w", p.ID(), err)
+	}
+	if err := p.tmpl.ExecuteTemplate(&ub, "user", data); err != nil {
+		return "", "", fmt.Errorf("failed to render user prompt %s: %w", p.ID(), err)
+	}
+	return strings.TrimSpace(sb.String()), strings.TrimSpace(ub.String()), nil
+}
+
+// LoadPrompts загружает встроенные шаблоны и поверх них *.tmpl из dir.
+// Отсутствующий dir не считается ошибкой.
+func LoadPrompts(dir string) (map[string]*PromptTemplate, error) {
+	prompts := map[string]*PromptTemplate{}
+
+	entries, err := builtinPrompts.ReadDir("prompts")
+	if err != nil {
+		return nil, err
+	}
+	for _, e := range entries {
+		text, err := builtinPrompts.ReadFile("prompts/" + e.Name())
+		if err != nil {
+			return nil, err
+		}
+		p, err := ParsePrompt(strings.TrimSuffix(e.Name(), ".tmpl"), text)
+		if err != nil {
+			return nil, err
+		}
+		prompts[p.Name] = p
+	}
+
+	if dir == "" {
+		return prompts, nil
+	}
+	files, err := filepath.Glob(filepath.Join(dir, "*.tmpl"))
+	if err != nil {
+		return nil, err
+	}
+	for _, file := range files {
+		text, err := os.ReadFile(file)
+		if err != nil {
+			if errors.Is(err, os.ErrNotExist) {
+				continue
+			}
+			return nil, err
+		}
+		p, err := ParsePrompt(strings.TrimSuffix(filepath.Base(file), ".tmpl"), text)
+		if err != nil {
+			return nil, err
+		}
+		prompts[p.Name] = p
+	}
+	return prompts, nil
+}
+
+var (
+	promptMu      sync.RWMutex
+	defaultPrompt *PromptTemplate
+)
+
+// SetDefaultPrompt задаёт шаблон, которым LLM-клиенты пользуются, если в
+// контексте запроса не указан другой.
+func SetDefaultPrompt(p *PromptTemplate) {
+	promptMu.Lock()
+	defer promptMu.Unlock()
+	defaultPrompt = p
+}
+
+type promptKey struct{}
+
+// ContextWithPrompt переопределяет шаблон для одного запроса.
+func ContextWithPrompt(ctx context.Context, p *PromptTemplate) context.Context {
+	return context.WithValue(ctx, promptKey{}, p)
+}
+
+func promptFor(ctx context.Context) (*PromptTemplate, error) {
+	if p, ok := ctx.Value(promptKey{}).(*PromptTemplate); ok && p != nil {
+		return p, nil
+	}
+
+	promptMu.RLock()
+	p := defaultPrompt
+	promptMu.RUnlock()
+	if p != nil {
+		return p, nil
+	}
+
+	text, err := builtinPrompts.ReadFile("prompts/default.tmpl")
+	if err != nil {
+		return nil, err
+	}
+	p, err = ParsePrompt("default", text)
+	if err != nil {
+		return nil, err
+	}
+	SetDefaultPrompt(p)
+	return p, nil
+}
@@ -0,0 +1,195 @@
+package ai
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/skomaroh1845/crypto_telemetry/decision_service/regime"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+)
+
+// RegimeClient выбирает стратегии по режиму рынка, например тренд отдаёт
+// правилам, а боковик — ML-модели. Соответствие задаётся REGIME_STRATEGIES
+// вида "trending=rules:0.7+ml:0.3,ranging=ml,high_volatility=groq,default=daniilfrolov":
+// у режима одна стратегия или взвешенный ансамбль, вес по умолчанию 1.
+// default отвечает, когда режим неизвестен или для него нет стратегии.
+type RegimeClient struct {
+	strategies map[string]regimeRoute
+}
+
+// regimeRoute — стратегии одного режима с весами голосов.
+type regimeRoute []regimeMember
+
+type regimeMember struct {
+	name   string
+	weight float64
+	client AIClient
+}
+
+func (r regimeRoute) String() string {
+	names := make([]string, len(r))
+	for i, m := range r {
+		names[i] = m.name
+	}
+	return strings.Join(names, "+")
+}
+
+func NewRegimeClient() (*RegimeClient, error) {
+	spec := os.Getenv("REGIME_STRATEGIES")
+	if spec == "" {
+		return nil, errors.New("REGIME_STRATEGIES is not set")
+	}
+	c := &RegimeClient{strategies: map[string]regimeRoute{}}
+	for _, part := range strings.Split(spec, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		name, strategies, ok := strings.Cut(part, "=")
+		name = strings.TrimSpace(name)
+		if !ok || strings.TrimSpace(strategies) == "" {
+			return nil, fmt.Errorf("invalid REGIME_STRATEGIES entry %q: expected regime=strategy[:weight][+strategy[:weight]]", part)
+		}
+		if name != "default" && !slices.Contains(regime.Names, name) {
+			return nil, fmt.Errorf("unknown regime %q, expected one of %s or default", name, strings.Join(regime.Names, ", "))
+		}
+		route, err := parseRegimeRoute(strategies)
+		if err != nil {
+			return nil, fmt.Errorf("regime %s: %w", name, err)
+		}
+		c.strategies[name] = route
+	}
+	if _, ok := c.strategies["default"]; !ok {
+		c.strategies["default"] = regimeRoute{{name: "daniilfrolov", weight: 1, client: NewDaniilFrolovAI()}}
+	}
+	return c, nil
+}
+
+// parseRegimeRoute разбирает "rules:0.7+ml:0.3".
+func parseRegimeRoute(spec string) (regimeRoute, error) {
+	var route regimeRoute
+	for _, part := range strings.Split(spec, "+") {
+		strategy, weightText, weighted := strings.Cut(strings.TrimSpace(part), ":")
+		strategy = strings.ToLower(strings.TrimSpace(strategy))
+		if strategy == "" {
+			return nil, fmt.Errorf("empty strategy in %q", spec)
+		}
+		if strategy == "regime" {
+			return nil, errors.New("regime strategy cannot route to itself")
+		}
+		weight := 1.0
+		if weighted {
+			w, err := strconv.ParseFloat(strings.TrimSpace(weightText), 64)
+			if err != nil || w <= 0 {
+				return nil, fmt.Errorf("invalid weight %q for %s: expected a positive number", weightText, strategy)
+			}
+			weight = w
+		}
+		client, err := NewClient(strategy)
+		if err != nil {
+			return nil, err
+		}
+		route = append(route, regimeMember{name: strategy, weight: weight, client: client})
+	}
+	return route, nil
+}
+
+func (c *RegimeClient) GetDecision(ctx context.Context, data MarketData) (DecisionResponse, error) {
+	ctx, span := otel.Tracer("ai-service").Start(ctx, "RegimeClient.GetDecision")
+	defer span.End()
+
+	current := "unknown"
+	route := c.strategies["default"]
+	if data.Regime != nil {
+		current = data.Regime.Regime
+		if r, ok := c.strategies[current]; ok {
+			route = r
+		}
+	}
+	span.SetAttributes(
+		attribute.String("market.regime", current),
+		attribute.String("regime.strategy", route.String()),
+	)
+
+	var resp DecisionResponse
+	var err error
+	if len(route) == 1 {
+		resp, err = route[0].client.GetDecision(ctx, data)
+		if err == nil && resp.Strategy == "" {
+			resp.Strategy = route[0].name
+		}
+	} else {
+		resp, err = route.vote(ctx, data)
+	}
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "Regime strategy failed")
+		return DecisionResponse{}, err
+	}
+	resp.Strategy = "regime:" + resp.Strategy
+	span.SetAttributes(attribute.Float64("regime.confidence", resp.Confidence))
+	span.SetStatus(codes.Ok, "Decision generated successfully")
+	return resp, nil
+}
+
+// vote опрашивает стратегии ансамбля параллельно и складывает веса голосов
+// за каждое решение. Побеждает решение с наибольшим весом, при равенстве —
+// hold; уверенность — доля веса за него среди ответивших стратегий.
+// Упавшие стратегии не голосуют, ошибка — только если упали все.
+func (r regimeRoute) vote(ctx context.Context, data MarketData) (DecisionResponse, error) {
+	responses := make([]DecisionResponse, len(r))
+	errs := make([]error, len(r))
+	var wg sync.WaitGroup
+	for i, m := range r {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			responses[i], errs[i] = m.client.GetDecision(ctx, data)
+		}()
+	}
+	wg.Wait()
+
+	scores := map[string]float64{}
+	var total float64
+	var votes []string
+	for i, m := range r {
+		if errs[i] != nil {
+			errs[i] = fmt.Errorf("%s: %w", m.name, errs[i])
+			continue
+		}
+		decision := responses[i].Decision
+		scores[decision] += m.weight
+		total += m.weight
+		votes = append(votes, fmt.Sprintf("%s %s (%.2g)", m.name, decision, m.weight))
+	}
+	if total == 0 {
+		return DecisionResponse{}, fmt.Errorf("all regime strategies failed: %w", errors.Join(errs...))
+	}
+
+	best, tie := "hold", false
+	for _, decision := range []string{"buy", "sell", "hold"} {
+		switch {
+		case scores[decision] > scores[best]:
+			best, tie = decision, false
+		case decision != best && scores[decision] == scores[best]:
+			tie = true
+		}
+	}
+	if tie {
+		best = "hold"
+	}
+	return DecisionResponse{
+		Decision:   best,
+		Confidence: scores[best] / total,
+		Reason:     "weighted vote: " + strings.Join(votes, ", "),
+		Strategy:   r.String(),
+	}, nil
+}
@@ -0,0 +1,97 @@
+package ai
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/skomaroh1845/crypto_telemetry/decision_service/rules"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+)
+
+// RulesClient принимает решение правилами из файла RULES_FILE с
+// параметрами из RULES_PARAMS_FILE. Файлы перечитываются на лету, так что
+// пороги меняются без пересборки.
+type RulesClient struct {
+	rules func() *rules.RuleSet
+}
+
+func NewRulesClient() (*RulesClient, error) {
+	return NewRulesClientWithParams(nil)
+}
+
+// NewRulesClientWithParams — правила из RULES_FILE, где params переопределяют
+// значения из файла параметров.
+func NewRulesClientWithParams(params map[string]float64) (*RulesClient, error) {
+	path := os.Getenv("RULES_FILE")
+	if path == "" {
+		return nil, errors.New("RULES_FILE is not set")
+	}
+	watcher, err := rules.OpenWithParams(path, os.Getenv("RULES_PARAMS_FILE"), params, rules.DefaultReloadInterval)
+	if err != nil {
+		return nil, err
+	}
+	return &RulesClient{rules: watcher.Rules}, nil
+}
+
+// NewStaticRulesClient принимает решения неизменным набором правил, например
+// в бэктесте или оптимизаторе.
+func NewStaticRulesClient(set *rules.RuleSet) *RulesClient {
+	return &RulesClient{rules: func() *rules.RuleSet { return set }}
+}
+
+func (c *RulesClient) GetDecision(ctx context.Context, data MarketData) (DecisionResponse, error) {
+	_, span := otel.Tracer("ai-service").Start(ctx, "RulesClient.GetDecision")
+	defer span.End()
+
+	set := c.rules()
+	res := EvaluateRules(set, data)
+
+	span.SetAttributes(
+		attribute.String("rules.version", set.Version),
+		attribute.String("decision", res.Decision),
+	)
+	reason, rule := "no rule matched", ""
+	if res.Rule != nil {
+		span.SetAttributes(attribute.Int("rules.line", res.Rule.Line))
+		rule = res.Rule.Expr
+		if rule == "" {
+			rule = "default"
+		}
+		reason = fmt.Sprintf("line %d: %s", res.Rule.Line, rule)
+	}
+	span.SetStatus(codes.Ok, "Decision generated successfully")
+
+	return DecisionResponse{
+		Decision:   res.Decision,
+		Confidence: res.Confidence,
+		Reason:     reason,
+		Strategy:   "rules",
+		Provider:   "rules",
+		Model:      set.Name + "@" + set.Version,
+		Rule:       rule,
+	}, nil
+}
+
+// EvaluateRules прогоняет правила по рыночным данным, ряды собираются так же,
+// как для индикаторов.
+func EvaluateRules(set *rules.RuleSet, data MarketData) rules.Result {
+	series := seriesOf(data)
+	snapshot := &rules.Snapshot{
+		Price:          data.Price,
+		Volume:         data.Volume,
+		High24h:        data.High24h,
+		Low24h:         data.Low24h,
+		DailyChangePct: data.DailyChangePct,
+		Close:          series.Close,
+		High:           series.High,
+		Low:            series.Low,
+	}
+	if r := data.Regime; r != nil {
+		snapshot.Regime, snapshot.TrendStrength, snapshot.VolatilityRatio = r.Regime, r.TrendStrength, r.VolatilityRatio
+	}
+	return set.Evaluate(snapshot)
+}
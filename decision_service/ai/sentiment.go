@@ -0,0 +1,56 @@
+package ai
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/skomaroh1845/crypto_telemetry/decision_service/sentiment"
+)
+
+// SentimentScorer оценивает тон новости моделью. Если модель не ответила или
+// ответ не число, новость оценивает запасной оценщик, обычно словарный.
+type SentimentScorer struct {
+	model    *ToolChatClient
+	fallback sentiment.Scorer
+	timeout  time.Duration
+}
+
+func NewSentimentScorer(model *ToolChatClient, fallback sentiment.Scorer, timeout time.Duration) *SentimentScorer {
+	return &SentimentScorer{model: model, fallback: fallback, timeout: timeout}
+}
+
+func (s *SentimentScorer) Score(ctx context.Context, symbol string, item sentiment.Item) (float64, error) {
+	score, err := s.ask(ctx, symbol, item)
+	if err == nil || s.fallback == nil {
+		return score, err
+	}
+	return s.fallback.Score(ctx, symbol, item)
+}
+
+func (s *SentimentScorer) ask(ctx context.Context, symbol string, item sentiment.Item) (float64, error) {
+	if s.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, s.timeout)
+		defer cancel()
+	}
+	msg, err := s.model.Chat(ctx, []ChatMessage{
+		{Role: "system", Content: "You rate how a crypto news item affects the price of " + symbol +
+			" over the next days. Reply with a single number from -1 (very negative) to 1 (very positive), 0 if neutral or unrelated, and nothing else."},
+		{Role: "user", Content: item.Text()},
+	}, nil)
+	if err != nil {
+		return 0, err
+	}
+	fields := strings.Fields(msg.Content)
+	if len(fields) == 0 {
+		return 0, fmt.Errorf("empty sentiment score")
+	}
+	score, err := strconv.ParseFloat(strings.Trim(fields[0], ".,;\"'`"), 64)
+	if err != nil || score < -1 || score > 1 {
+		return 0, fmt.Errorf("unusable sentiment score %q", msg.Content)
+	}
+	return score, nil
+}
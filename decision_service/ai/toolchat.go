@@ -0,0 +1,175 @@
+package ai
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+// ChatMessage — сообщение OpenAI-совместимого чата с вызовами функций.
+type ChatMessage struct {
+	Role       string     `json:"role"`
+	Content    string     `json:"content"`
+	ToolCalls  []ToolCall `json:"tool_calls,omitempty"`
+	ToolCallID string     `json:"tool_call_id,omitempty"`
+}
+
+type ToolCall struct {
+	ID       string       `json:"id"`
+	Type     string       `json:"type"`
+	Function FunctionCall `json:"function"`
+}
+
+type FunctionCall struct {
+	Name string `json:"name"`
+	// Arguments — JSON объект аргументов строкой, как его вернула модель
+	Arguments string `json:"arguments"`
+}
+
+// ToolSpec описывает функцию, которую модель может вызвать.
+type ToolSpec struct {
+	Type     string       `json:"type"`
+	Function FunctionSpec `json:"function"`
+}
+
+type FunctionSpec struct {
+	Name        string          `json:"name"`
+	Description string          `json:"description"`
+	Parameters  json.RawMessage `json:"parameters"` // JSON Schema аргументов
+}
+
+type toolChatRequest struct {
+	Model    string        `json:"model"`
+	Messages []ChatMessage `json:"messages"`
+	Tools    []ToolSpec    `json:"tools,omitempty"`
+	Stream   bool          `json:"stream"`
+}
+
+type toolChatResponse struct {
+	ID      string `json:"id"`
+	Model   string `json:"model"`
+	Choices []struct {
+		Message      ChatMessage `json:"message"`
+		FinishReason string      `json:"finish_reason"`
+	} `json:"choices"`
+	Usage Usage `json:"usage"`
+}
+
+func (r *toolChatResponse) chatResult() chatResult {
+	res := chatResult{ResponseID: r.ID, ResponseModel: r.Model, Usage: r.Usage}
+	if len(r.Choices) > 0 {
+		res.FinishReason = r.Choices[0].FinishReason
+	}
+	return res
+}
+
+// ToolChatClient — чат с вызовом функций у groq или deepseek. Ключи и адреса
+// берутся из тех же переменных окружения, что и у обычных клиентов.
+type ToolChatClient struct {
+	provider   string
+	model      string
+	apiKey     string
+	baseURL    string
+	httpClient *http.Client
+}
+
+// NewToolChatClient создаёт клиента провайдера; пустая model — модель
+// провайдера по умолчанию.
+func NewToolChatClient(provider, model string) (*ToolChatClient, error) {
+	c := &ToolChatClient{
+		provider:   strings.ToLower(provider),
+		model:      model,
+		httpClient: &http.Client{Timeout: 30 * time.Second},
+	}
+	switch c.provider {
+	case "groq":
+		c.apiKey = os.Getenv("GROQ_API_KEY")
+		c.baseURL = "https://api.groq.com/openai/v1"
+		if c.model == "" {
+			c.model = "llama3-groq-8b-8192-tool-use-preview"
+		}
+	case "deepseek":
+		c.apiKey = os.Getenv("DEEPSEEK_API_KEY")
+		c.baseURL = os.Getenv("DEEPSEEK_BASE_URL")
+		if c.baseURL == "" {
+			c.baseURL = "https://api.deepseek.com/v1"
+		}
+		if c.model == "" {
+			c.model = "deepseek-chat"
+		}
+	default:
+		return nil, fmt.Errorf("provider %q does not support tool calling", provider)
+	}
+	if err := CheckPriced(c.model); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+func (c *ToolChatClient) Provider() string { return c.provider }
+
+func (c *ToolChatClient) Model() string { return c.model }
+
+// Chat отправляет историю и список функций и возвращает ответ модели: либо
+// текст, либо запросы вызовов функций. Вызов трассируется и учитывается в
+// бюджете как обычный chat.
+func (c *ToolChatClient) Chat(ctx context.Context, messages []ChatMessage, tools []ToolSpec) (ChatMessage, error) {
+	req := toolChatRequest{Model: c.model, Messages: messages, Tools: tools}
+
+	var response *toolChatResponse
+	err := tracedChat(ctx, c.provider, c.model, hostOf(c.baseURL), func(ctx context.Context) (chatResult, error) {
+		resp, err := c.makeRequest(ctx, req)
+		if err != nil {
+			return chatResult{}, err
+		}
+		response = resp
+		return resp.chatResult(), nil
+	})
+	if err != nil {
+		return ChatMessage{}, fmt.Errorf("failed to call %s API: %w", c.provider, err)
+	}
+	if len(response.Choices) == 0 {
+		return ChatMessage{}, fmt.Errorf("no choices in response")
+	}
+	return response.Choices[0].Message, nil
+}
+
+func (c *ToolChatClient) makeRequest(ctx context.Context, req toolChatRequest) (*toolChatResponse, error) {
+	requestBody, err := json.Marshal(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, "POST", c.baseURL+"/chat/completions", bytes.NewBuffer(requestBody))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	httpReq.Header.Set("Content-Type", "application/json")
+	if c.apiKey != "" {
+		httpReq.Header.Set("Authorization", "Bearer "+c.apiKey)
+	}
+
+	resp, err := c.httpClient.Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("API request failed with status %d: %s", resp.StatusCode, string(body))
+	}
+
+	var chatResp toolChatResponse
+	if err := json.NewDecoder(resp.Body).Decode(&chatResp); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+	return &chatResp, nil
+}
@@ -0,0 +1,52 @@
+package audit
+
+import (
+	"encoding/csv"
+	"io"
+	"strconv"
+	"time"
+)
+
+var csvHeader = []string{
+	"id", "time", "symbol", "chat_id", "source", "strategy", "experiment", "variant", "provider", "model", "prompt_version", "decision",
+	"confidence", "horizon", "reason",
+	"price", "volume", "latency_ms", "trace_id", "error", "raw_output",
+}
+
+// WriteCSV выгружает записи в CSV, рыночные данные раскладываются на price и volume.
+func WriteCSV(w io.Writer, records []Record) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(csvHeader); err != nil {
+		return err
+	}
+	for _, rec := range records {
+		row := []string{
+			rec.ID,
+			rec.Time.Format(time.RFC3339Nano),
+			rec.Symbol,
+			strconv.FormatInt(rec.ChatID, 10),
+			rec.Source,
+			rec.Strategy,
+			rec.Experiment,
+			rec.Variant,
+			rec.Provider,
+			rec.Model,
+			rec.PromptVersion,
+			rec.Decision,
+			strconv.FormatFloat(rec.Confidence, 'f', -1, 64),
+			rec.Horizon,
+			rec.Reason,
+			strconv.FormatFloat(rec.Market.Price, 'f', -1, 64),
+			strconv.FormatFloat(rec.Market.Volume, 'f', -1, 64),
+			strconv.FormatFloat(rec.LatencyMs, 'f', 1, 64),
+			rec.TraceID,
+			rec.Error,
+			rec.RawOutput,
+		}
+		if err := cw.Write(row); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
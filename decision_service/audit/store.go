@@ -0,0 +1,334 @@
+package audit
+
+import (
+	"bufio"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/skomaroh1845/crypto_telemetry/decision_service/ai"
+	"github.com/skomaroh1845/crypto_telemetry/decision_service/risk"
+	"github.com/skomaroh1845/crypto_telemetry/decision_service/timeframe"
+)
+
+// Record описывает одно решение со всеми входными данными, чтобы по жалобе
+// пользователя можно было восстановить, почему оно было принято.
+type Record struct {
+	ID       string    `json:"id"`
+	Time     time.Time `json:"time"`
+	Symbol   string    `json:"symbol"`
+	ChatID   int64     `json:"chat_id,omitempty"`
+	Strategy string    `json:"strategy"`
+	// StrategyVersion — версия из реестра стратегий, пусто — стратегия из окружения
+	StrategyVersion string `json:"strategy_version,omitempty"`
+	Provider        string `json:"provider,omitempty"`
+	// Source — откуда взяты рыночные данные, пусто — из data_service
+	Source string `json:"source,omitempty"`
+	// Experiment и Variant — A/B эксперимент, в который попал запрос
+	Experiment string `json:"experiment,omitempty"`
+	Variant    string `json:"variant,omitempty"`
+	Model      string `json:"model,omitempty"`
+	// PromptVersion — имя@версия шаблона промпта, для сравнения вариантов
+	PromptVersion string        `json:"prompt_version,omitempty"`
+	Decision      string        `json:"decision"`
+	Confidence    float64       `json:"confidence,omitempty"`
+	Reason        string        `json:"reason,omitempty"`
+	Horizon       string        `json:"horizon,omitempty"`
+	Market        ai.MarketData `json:"market"`
+	// Risk — рекомендация риск-менеджмента; Decision уже учитывает отказ от покупки
+	Risk *risk.Assessment `json:"risk,omitempty"`
+	// Timeframes — проверка решения по таймфреймам, если она включена
+	Timeframes *timeframe.Agreement `json:"timeframes,omitempty"`
+	RawOutput  string               `json:"raw_output,omitempty"`
+	LatencyMs  float64              `json:"latency_ms"`
+	// Cached — ответ стратегии повторён из кэша, а не получен заново
+	Cached  bool   `json:"cached,omitempty"`
+	TraceID string `json:"trace_id,omitempty"`
+	Error   string `json:"error,omitempty"`
+}
+
+// SourcePush — рыночные данные переданы вызывающим в теле запроса и могут
+// не совпадать с реальным рынком.
+const SourcePush = "push"
+
+// SourceSchedule — решение принято планировщиком сигналов, а не по запросу.
+const SourceSchedule = "schedule"
+
+type Filter struct {
+	Symbol   string
+	Strategy string
+	ChatID   int64
+	Since    time.Time
+	Until    time.Time
+	Offset   int
+	Limit    int
+}
+
+type Page struct {
+	Total      int      `json:"total"`
+	Offset     int      `json:"offset"`
+	Limit      int      `json:"limit"`
+	NextOffset int      `json:"next_offset,omitempty"`
+	Records    []Record `json:"records"`
+}
+
+// Store — журнал решений в append-only JSONL файле. Записи за период
+// хранения держатся в памяти по возрастанию времени и дописываются в файл по
+// одной строке. Свечи остаются только в файле: в памяти хватает цены,
+// индикаторов и таймфреймов, а история за месяц на каждую запись быстро
+// съедает память.
+type Store struct {
+	mu      sync.RWMutex
+	path    string
+	file    *os.File
+	records []Record
+	// retention — сколько хранятся записи, 0 — без ограничения
+	retention time.Duration
+}
+
+func Open(path string, retention time.Duration) (*Store, error) {
+	s := &Store{path: path, retention: retention}
+	if path == "" {
+		return s, nil
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create audit dir: %w", err)
+	}
+
+	if err := s.load(path); err != nil {
+		return nil, err
+	}
+	if _, err := s.compact(time.Now()); err != nil {
+		return nil, err
+	}
+
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open audit log: %w", err)
+	}
+	s.file = file
+	return s, nil
+}
+
+func (s *Store) load(path string) error {
+	f, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read audit log: %w", err)
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 4*1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		if len(strings.TrimSpace(scanner.Text())) == 0 {
+			continue
+		}
+		var rec Record
+		if err := json.Unmarshal(scanner.Bytes(), &rec); err != nil {
+			// обрезанная последняя строка после падения не должна мешать старту
+			continue
+		}
+		s.insert(rec)
+	}
+	return scanner.Err()
+}
+
+// Append сохраняет запись, ID и время проставляются, если не заданы.
+func (s *Store) Append(rec Record) (Record, error) {
+	if rec.ID == "" {
+		rec.ID = newID()
+	}
+	if rec.Time.IsZero() {
+		rec.Time = time.Now().UTC()
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.file != nil {
+		line, err := json.Marshal(rec)
+		if err != nil {
+			return rec, fmt.Errorf("failed to marshal audit record: %w", err)
+		}
+		if _, err := s.file.Write(append(line, '\n')); err != nil {
+			return rec, fmt.Errorf("failed to write audit record: %w", err)
+		}
+	}
+	s.insert(rec)
+	return rec, nil
+}
+
+// insert добавляет запись в память с сохранением порядка по времени;
+// вызывается под mu или до начала работы.
+func (s *Store) insert(rec Record) {
+	rec.Market.Candles = nil
+	i := len(s.records)
+	for i > 0 && s.records[i-1].Time.After(rec.Time) {
+		i--
+	}
+	s.records = append(s.records, Record{})
+	copy(s.records[i+1:], s.records[i:])
+	s.records[i] = rec
+}
+
+// Query возвращает записи, подходящие под фильтр, от новых к старым.
+// Копируется только запрошенная страница.
+func (s *Store) Query(f Filter) Page {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	page := Page{Offset: f.Offset, Limit: f.Limit, Records: []Record{}}
+	for i := len(s.records) - 1; i >= 0; i-- {
+		rec := &s.records[i]
+		// записи идут по времени, так что раньше Since искать нечего
+		if !f.Since.IsZero() && rec.Time.Before(f.Since) {
+			break
+		}
+		if !f.Until.IsZero() && !rec.Time.Before(f.Until) {
+			continue
+		}
+		if f.Symbol != "" && !strings.EqualFold(rec.Symbol, f.Symbol) {
+			continue
+		}
+		if f.Strategy != "" && !strings.EqualFold(rec.Strategy, f.Strategy) {
+			continue
+		}
+		if f.ChatID != 0 && rec.ChatID != f.ChatID {
+			continue
+		}
+		if page.Total >= f.Offset && (f.Limit <= 0 || len(page.Records) < f.Limit) {
+			page.Records = append(page.Records, *rec)
+		}
+		page.Total++
+	}
+	if f.Limit > 0 && f.Offset+f.Limit < page.Total {
+		page.NextOffset = f.Offset + f.Limit
+	}
+	return page
+}
+
+// Run удаляет записи старше периода хранения раз в interval до отмены ctx.
+func (s *Store) Run(ctx context.Context, interval time.Duration) {
+	if s.retention <= 0 {
+		return
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		if n, err := s.Compact(time.Now()); err != nil {
+			log.Printf("audit log compaction failed: %v", err)
+		} else if n > 0 {
+			log.Printf("removed %d audit records older than %s", n, s.retention)
+		}
+	}
+}
+
+// Compact удаляет из памяти и файла записи старше периода хранения и
+// возвращает их число.
+func (s *Store) Compact(now time.Time) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.file != nil {
+		if err := s.file.Close(); err != nil {
+			return 0, fmt.Errorf("failed to close audit log: %w", err)
+		}
+		s.file = nil
+	}
+	removed, err := s.compact(now)
+	if s.path != "" {
+		file, openErr := os.OpenFile(s.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+		if openErr != nil {
+			return removed, errors.Join(err, fmt.Errorf("failed to open audit log: %w", openErr))
+		}
+		s.file = file
+	}
+	return removed, err
+}
+
+// compact переписывает файл без устаревших строк. Строки копируются как
+// есть, чтобы в файле остались полные записи со свечами. Вызывается под mu
+// при закрытом файле.
+func (s *Store) compact(now time.Time) (int, error) {
+	if s.retention <= 0 {
+		return 0, nil
+	}
+	cutoff := now.Add(-s.retention)
+	n := sort.Search(len(s.records), func(i int) bool { return !s.records[i].Time.Before(cutoff) })
+	if n == 0 {
+		return 0, nil
+	}
+	s.records = append([]Record(nil), s.records[n:]...)
+	if s.path == "" {
+		return n, nil
+	}
+
+	src, err := os.Open(s.path)
+	if err != nil {
+		return n, fmt.Errorf("failed to read audit log: %w", err)
+	}
+	defer src.Close()
+	tmp := s.path + ".tmp"
+	dst, err := os.Create(tmp)
+	if err != nil {
+		return n, fmt.Errorf("failed to write audit log: %w", err)
+	}
+	w := bufio.NewWriter(dst)
+	scanner := bufio.NewScanner(src)
+	scanner.Buffer(make([]byte, 64*1024), 4*1024*1024)
+	for scanner.Scan() {
+		var head struct {
+			Time time.Time `json:"time"`
+		}
+		if json.Unmarshal(scanner.Bytes(), &head) != nil || head.Time.Before(cutoff) {
+			continue
+		}
+		_, _ = w.Write(scanner.Bytes())
+		_ = w.WriteByte('\n')
+	}
+	err = errors.Join(scanner.Err(), w.Flush(), dst.Close())
+	if err != nil {
+		_ = os.Remove(tmp)
+		return n, fmt.Errorf("failed to write audit log: %w", err)
+	}
+	if err := os.Rename(tmp, s.path); err != nil {
+		return n, fmt.Errorf("failed to save audit log: %w", err)
+	}
+	return n, nil
+}
+
+func (s *Store) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.file == nil {
+		return nil
+	}
+	return s.file.Close()
+}
+
+func newID() string {
+	b := make([]byte, 8)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,135 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/skomaroh1845/crypto_telemetry/decision_service/audit"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	defaultDecisionsLimit = 100
+	maxDecisionsLimit     = 1000
+)
+
+// recordDecision пишет решение в журнал аудита, ID трейса берётся из контекста.
+// Возвращает ID записи.
+func (h *Handler) recordDecision(ctx context.Context, rec audit.Record) string {
+	if spanCtx := trace.SpanContextFromContext(ctx); spanCtx.HasTraceID() {
+		rec.TraceID = spanCtx.TraceID().String()
+	}
+	rec, err := h.audit.Append(rec)
+	if err != nil {
+		log.Printf("failed to write audit record: %v", err)
+	}
+	return rec.ID
+}
+
+func (h *Handler) decisionsHandler(w http.ResponseWriter, r *http.Request) {
+	_, span := tracer.Start(r.Context(), "decisions-query",
+		trace.WithAttributes(attribute.String("handler", "decisions")),
+	)
+	defer span.End()
+
+	filter, err := parseDecisionsFilter(r)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "Invalid query")
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	page := h.audit.Query(filter)
+	span.SetAttributes(
+		attribute.String("symbol", filter.Symbol),
+		attribute.String("strategy", filter.Strategy),
+		attribute.Int("decisions.total", page.Total),
+		attribute.Int("decisions.returned", len(page.Records)),
+	)
+
+	switch r.URL.Query().Get("format") {
+	case "csv":
+		w.Header().Set("Content-Type", "text/csv")
+		w.Header().Set("Content-Disposition", `attachment; filename="decisions.csv"`)
+		w.Header().Set("X-Total-Count", strconv.Itoa(page.Total))
+		if page.NextOffset > 0 {
+			w.Header().Set("X-Next-Offset", strconv.Itoa(page.NextOffset))
+		}
+		if err := audit.WriteCSV(w, page.Records); err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, "CSV export failed")
+			return
+		}
+	case "", "json":
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		_ = json.NewEncoder(w).Encode(page)
+	default:
+		span.SetStatus(codes.Error, "Unknown format")
+		http.Error(w, "format must be json or csv", http.StatusBadRequest)
+		return
+	}
+
+	span.SetStatus(codes.Ok, "Decisions query completed successfully")
+}
+
+func parseDecisionsFilter(r *http.Request) (audit.Filter, error) {
+	q := r.URL.Query()
+	filter := audit.Filter{
+		Symbol:   q.Get("symbol"),
+		Strategy: q.Get("strategy"),
+		Limit:    defaultDecisionsLimit,
+	}
+
+	chatID, err := parseChatID(r)
+	if err != nil {
+		return filter, err
+	}
+	filter.ChatID = chatID
+
+	if filter.Since, err = parseSince(q.Get("since")); err != nil {
+		return filter, fmt.Errorf("invalid since: %w", err)
+	}
+	if filter.Until, err = parseSince(q.Get("until")); err != nil {
+		return filter, fmt.Errorf("invalid until: %w", err)
+	}
+
+	if v := q.Get("limit"); v != "" {
+		if filter.Limit, err = strconv.Atoi(v); err != nil || filter.Limit <= 0 {
+			return filter, fmt.Errorf("invalid limit: %s", v)
+		}
+		filter.Limit = min(filter.Limit, maxDecisionsLimit)
+	}
+	if v := q.Get("offset"); v != "" {
+		if filter.Offset, err = strconv.Atoi(v); err != nil || filter.Offset < 0 {
+			return filter, fmt.Errorf("invalid offset: %s", v)
+		}
+	}
+
+	return filter, nil
+}
+
+// parseSince принимает RFC3339, дату YYYY-MM-DD или длительность назад от
+// текущего момента, например 24h.
+func parseSince(value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	if d, err := time.ParseDuration(value); err == nil {
+		return time.Now().Add(-d), nil
+	}
+	for _, format := range []string{time.RFC3339, "2006-01-02"} {
+		if t, err := time.Parse(format, value); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("expected RFC3339, YYYY-MM-DD or duration, got %q", value)
+}
@@ -0,0 +1,242 @@
+package backtest
+
+import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/skomaroh1845/crypto_telemetry/decision_service/ai"
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+)
+
+// LoadFile читает свечи из CSV или JSONL файла, формат выбирается по расширению.
+func LoadFile(path string) ([]ai.Candle, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open %s: %w", path, err)
+	}
+	defer f.Close()
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".csv":
+		return LoadCSV(f)
+	case ".jsonl", ".ndjson", ".json":
+		return LoadJSONL(f)
+	default:
+		return nil, fmt.Errorf("unsupported history format: %s", path)
+	}
+}
+
+// LoadCSV ожидает заголовок с колонками time,open,high,low,close[,volume].
+// Колонка price может заменять close для тиковых данных.
+func LoadCSV(r io.Reader) ([]ai.Candle, error) {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read csv header: %w", err)
+	}
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	if _, ok := columns["close"]; !ok {
+		if i, ok := columns["price"]; ok {
+			columns["close"] = i
+		} else {
+			return nil, fmt.Errorf("csv must have a close or price column")
+		}
+	}
+	timeCol, ok := columns["time"]
+	if !ok {
+		if timeCol, ok = columns["timestamp"]; !ok {
+			return nil, fmt.Errorf("csv must have a time or timestamp column")
+		}
+	}
+
+	value := func(record []string, name string) (float64, error) {
+		i, ok := columns[name]
+		if !ok || i >= len(record) || record[i] == "" {
+			return 0, nil
+		}
+		return strconv.ParseFloat(strings.TrimSpace(record[i]), 64)
+	}
+
+	var candles []ai.Candle
+	for line := 2; ; line++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+
+		t, err := parseTime(record[timeCol])
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		c := ai.Candle{Time: t}
+		fields := []struct {
+			name string
+			dst  *float64
+		}{
+			{"open", &c.Open}, {"high", &c.High}, {"low", &c.Low},
+			{"close", &c.Close}, {"volume", &c.Volume},
+		}
+		for _, field := range fields {
+			if *field.dst, err = value(record, field.name); err != nil {
+				return nil, fmt.Errorf("line %d: invalid %s: %w", line, field.name, err)
+			}
+		}
+		candles = append(candles, fillMissing(c))
+	}
+
+	return Normalize(candles)
+}
+
+// LoadJSONL читает по одной свече в строке в формате ai.Candle.
+func LoadJSONL(r io.Reader) ([]ai.Candle, error) {
+	var candles []ai.Candle
+	scanner := bufio.NewScanner(r)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" {
+			continue
+		}
+		var c ai.Candle
+		if err := json.Unmarshal([]byte(text), &c); err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		candles = append(candles, fillMissing(c))
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return Normalize(candles)
+}
+
+// FetchHistory загружает дневные свечи из GET /history сервиса данных.
+func FetchHistory(ctx context.Context, dataServiceURL, symbol string, from, to time.Time) ([]ai.Candle, error) {
+	return FetchCandles(ctx, dataServiceURL, symbol, "", from, to)
+}
+
+// FetchCandles загружает свечи интервала interval (15m, 1h, 4h, 1d), пустой
+// интервал — дневные.
+func FetchCandles(ctx context.Context, dataServiceURL, symbol, interval string, from, to time.Time) ([]ai.Candle, error) {
+	client := http.Client{
+		Transport: otelhttp.NewTransport(http.DefaultTransport),
+		Timeout:   30 * time.Second,
+	}
+
+	query := url.Values{}
+	query.Set("symbol", symbol)
+	query.Set("from", from.Format("2006-01-02"))
+	query.Set("to", to.Format("2006-01-02"))
+	if interval != "" {
+		query.Set("interval", interval)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "GET", dataServiceURL+"/history?"+query.Encode(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch history: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("data service returned %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+
+	var history struct {
+		Status  string      `json:"status"`
+		Candles []ai.Candle `json:"candles"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&history); err != nil {
+		return nil, fmt.Errorf("failed to parse history response: %w", err)
+	}
+	if history.Status != "success" {
+		return nil, fmt.Errorf("data service status: %s", history.Status)
+	}
+
+	return Normalize(history.Candles)
+}
+
+func parseTime(value string) (time.Time, error) {
+	value = strings.TrimSpace(value)
+	formats := []string{
+		time.RFC3339,
+		"2006-01-02 15:04:05",
+		"2006-01-02",
+	}
+	for _, format := range formats {
+		if t, err := time.Parse(format, value); err == nil {
+			return t.UTC(), nil
+		}
+	}
+	if unix, err := strconv.ParseInt(value, 10, 64); err == nil {
+		// миллисекунды, как отдают биржи
+		if unix > 1e12 {
+			return time.UnixMilli(unix).UTC(), nil
+		}
+		return time.Unix(unix, 0).UTC(), nil
+	}
+	return time.Time{}, fmt.Errorf("unrecognized time %q", value)
+}
+
+// fillMissing подставляет close в пустые open/high/low, чтобы тиковые данные
+// можно было прогонять как свечи.
+func fillMissing(c ai.Candle) ai.Candle {
+	if c.Open == 0 {
+		c.Open = c.Close
+	}
+	if c.High == 0 {
+		c.High = max(c.Open, c.Close)
+	}
+	if c.Low == 0 {
+		c.Low = min(c.Open, c.Close)
+	}
+	return c
+}
+
+// Normalize готовит свечи к прогону: заполняет пустые open/high/low,
+// сортирует по времени и оставляет последнюю из свечей с одинаковым временем.
+func Normalize(candles []ai.Candle) ([]ai.Candle, error) {
+	if len(candles) == 0 {
+		return nil, fmt.Errorf("history is empty")
+	}
+	sorted := make([]ai.Candle, len(candles))
+	for i, c := range candles {
+		sorted[i] = fillMissing(c)
+	}
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Time.Before(sorted[j].Time)
+	})
+	out := sorted[:1]
+	for _, c := range sorted[1:] {
+		if c.Time.Equal(out[len(out)-1].Time) {
+			out[len(out)-1] = c
+			continue
+		}
+		out = append(out, c)
+	}
+	return out, nil
+}
@@ -0,0 +1,246 @@
+package backtest
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/skomaroh1845/crypto_telemetry/decision_service/ai"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Config задаёт параметры симуляции исполнения.
+type Config struct {
+	InitialCash  float64 `json:"initial_cash"`
+	FeeRate      float64 `json:"fee_rate"`      // комиссия от объёма сделки, 0.001 = 0.1%
+	Slippage     float64 `json:"slippage"`      // проскальзывание от цены открытия, 0.0005 = 0.05%
+	PositionSize float64 `json:"position_size"` // доля капитала на одну покупку, (0, 1]
+	Lookback     int     `json:"lookback"`      // сколько предыдущих свечей передавать стратегии
+	// Warmup — сколько первых свечей служат только историей для индикаторов:
+	// на них не принимаются решения и не считается капитал
+	Warmup int `json:"warmup,omitempty"`
+}
+
+func DefaultConfig() Config {
+	return Config{
+		InitialCash:  10000,
+		FeeRate:      0.001,
+		Slippage:     0.0005,
+		PositionSize: 1,
+		Lookback:     50,
+	}
+}
+
+func (c Config) Validate() error {
+	if c.InitialCash <= 0 {
+		return fmt.Errorf("initial_cash must be positive")
+	}
+	if c.FeeRate < 0 || c.FeeRate >= 1 {
+		return fmt.Errorf("fee_rate must be in [0, 1)")
+	}
+	if c.Slippage < 0 || c.Slippage >= 1 {
+		return fmt.Errorf("slippage must be in [0, 1)")
+	}
+	if c.PositionSize <= 0 || c.PositionSize > 1 {
+		return fmt.Errorf("position_size must be in (0, 1]")
+	}
+	if c.Lookback < 0 {
+		return fmt.Errorf("lookback must not be negative")
+	}
+	if c.Warmup < 0 {
+		return fmt.Errorf("warmup must not be negative")
+	}
+	return nil
+}
+
+type Trade struct {
+	EntryTime  time.Time `json:"entry_time"`
+	ExitTime   time.Time `json:"exit_time"`
+	EntryPrice float64   `json:"entry_price"`
+	ExitPrice  float64   `json:"exit_price"`
+	Quantity   float64   `json:"quantity"`
+	Fees       float64   `json:"fees"`
+	PnL        float64   `json:"pnl"`
+}
+
+type EquityPoint struct {
+	Time   time.Time `json:"time"`
+	Equity float64   `json:"equity"`
+}
+
+type Report struct {
+	Strategy    string         `json:"strategy"`
+	Symbol      string         `json:"symbol"`
+	From        time.Time      `json:"from"`
+	To          time.Time      `json:"to"`
+	Candles     int            `json:"candles"`
+	InitialCash float64        `json:"initial_cash"`
+	FinalEquity float64        `json:"final_equity"`
+	PnL         float64        `json:"pnl"`
+	ReturnPct   float64        `json:"return_pct"`
+	WinRate     float64        `json:"win_rate"`
+	Sharpe      float64        `json:"sharpe"`
+	MaxDrawdown float64        `json:"max_drawdown"`
+	Fees        float64        `json:"fees"`
+	Decisions   map[string]int `json:"decisions"`
+	Errors      int            `json:"errors"`
+	Trades      []Trade        `json:"trades"`
+	Equity      []EquityPoint  `json:"equity"`
+}
+
+// Run прогоняет стратегию по истории. Решение, принятое по закрытию свечи i,
+// исполняется по цене открытия свечи i+1, чтобы не заглядывать в будущее.
+// Открытая в конце периода позиция закрывается по последнему close.
+func Run(ctx context.Context, client ai.AIClient, symbol string, candles []ai.Candle, cfg Config) (*Report, error) {
+	tracer := otel.Tracer("backtest")
+	ctx, span := tracer.Start(ctx, "backtest.Run")
+	defer span.End()
+
+	if err := cfg.Validate(); err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "Invalid config")
+		return nil, err
+	}
+	if len(candles) < cfg.Warmup+2 {
+		err := fmt.Errorf("need at least %d candles, got %d", cfg.Warmup+2, len(candles))
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "Not enough history")
+		return nil, err
+	}
+
+	span.SetAttributes(
+		attribute.String("symbol", symbol),
+		attribute.Int("backtest.candles", len(candles)),
+		attribute.Float64("backtest.fee_rate", cfg.FeeRate),
+		attribute.Float64("backtest.slippage", cfg.Slippage),
+	)
+
+	report := &Report{
+		Symbol:      symbol,
+		From:        candles[cfg.Warmup].Time,
+		To:          candles[len(candles)-1].Time,
+		Candles:     len(candles) - cfg.Warmup,
+		InitialCash: cfg.InitialCash,
+		Decisions:   map[string]int{},
+		Trades:      []Trade{},
+	}
+
+	cash := cfg.InitialCash
+	var open *Trade
+	pending := ""
+
+	for i, candle := range candles {
+		if i < cfg.Warmup {
+			continue
+		}
+		if err := ctx.Err(); err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, "Backtest cancelled")
+			return nil, err
+		}
+
+		// исполняем решение предыдущей свечи по open текущей
+		switch {
+		case pending == "buy" && open == nil:
+			price := candle.Open * (1 + cfg.Slippage)
+			budget := cash * cfg.PositionSize
+			fee := budget * cfg.FeeRate
+			qty := (budget - fee) / price
+			if qty > 0 {
+				cash -= budget
+				open = &Trade{EntryTime: candle.Time, EntryPrice: price, Quantity: qty, Fees: fee}
+			}
+		case pending == "sell" && open != nil:
+			cash += closeTrade(open, candle.Time, candle.Open*(1-cfg.Slippage), cfg.FeeRate)
+			report.Trades = append(report.Trades, *open)
+			open = nil
+		}
+		pending = ""
+
+		equity := cash
+		if open != nil {
+			equity += open.Quantity * candle.Close
+		}
+		report.Equity = append(report.Equity, EquityPoint{Time: candle.Time, Equity: equity})
+
+		if i == len(candles)-1 {
+			break
+		}
+
+		decision, err := client.GetDecision(ctx, marketAt(symbol, candles, i, cfg.Lookback))
+		if err != nil {
+			// ошибка стратегии считается за hold, чтобы один сбой LLM не ронял весь прогон
+			report.Errors++
+			span.AddEvent("decision_error", trace.WithAttributes(
+				attribute.String("candle.time", candle.Time.Format(time.RFC3339)),
+				attribute.String("error", err.Error()),
+			))
+			continue
+		}
+		report.Decisions[decision.Decision]++
+		pending = decision.Decision
+	}
+
+	if open != nil {
+		last := candles[len(candles)-1]
+		cash += closeTrade(open, last.Time, last.Close*(1-cfg.Slippage), cfg.FeeRate)
+		report.Trades = append(report.Trades, *open)
+		report.Equity[len(report.Equity)-1].Equity = cash
+	}
+
+	report.FinalEquity = cash
+	report.PnL = cash - cfg.InitialCash
+	report.ReturnPct = report.PnL / cfg.InitialCash * 100
+	report.WinRate = winRate(report.Trades)
+	report.Sharpe = sharpe(report.Equity)
+	report.MaxDrawdown = maxDrawdown(report.Equity)
+	for _, t := range report.Trades {
+		report.Fees += t.Fees
+	}
+
+	span.SetAttributes(
+		attribute.Float64("backtest.pnl", report.PnL),
+		attribute.Float64("backtest.sharpe", report.Sharpe),
+		attribute.Float64("backtest.max_drawdown", report.MaxDrawdown),
+		attribute.Int("backtest.trades", len(report.Trades)),
+		attribute.Int("backtest.errors", report.Errors),
+	)
+	span.SetStatus(codes.Ok, "Backtest completed")
+
+	return report, nil
+}
+
+// closeTrade фиксирует выход из позиции и возвращает выручку после комиссии.
+func closeTrade(t *Trade, at time.Time, price, feeRate float64) float64 {
+	cost := t.Quantity*t.EntryPrice + t.Fees
+	gross := t.Quantity * price
+	fee := gross * feeRate
+	t.ExitTime = at
+	t.ExitPrice = price
+	t.Fees += fee
+	t.PnL = gross - fee - cost
+	return gross - fee
+}
+
+// marketAt — картина рынка на закрытии свечи i, как в живом запросе: цена —
+// её close, Candles — закрытые свечи до неё, а время — момент закрытия, то
+// есть начало следующей свечи. Решение исполняется по open следующей свечи.
+func marketAt(symbol string, candles []ai.Candle, i, lookback int) ai.MarketData {
+	start := max(0, i-lookback)
+	at := candles[i].Time
+	if i+1 < len(candles) {
+		at = candles[i+1].Time
+	}
+	return ai.WithIndicators(ai.MarketData{
+		Symbol:    symbol,
+		Price:     candles[i].Close,
+		Volume:    candles[i].Volume,
+		Timestamp: at,
+		High24h:   candles[i].High,
+		Low24h:    candles[i].Low,
+		Candles:   candles[start:i],
+	})
+}
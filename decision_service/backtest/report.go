@@ -0,0 +1,81 @@
+package backtest
+
+import (
+	"math"
+	"sort"
+	"time"
+)
+
+func winRate(trades []Trade) float64 {
+	if len(trades) == 0 {
+		return 0
+	}
+	wins := 0
+	for _, t := range trades {
+		if t.PnL > 0 {
+			wins++
+		}
+	}
+	return float64(wins) / float64(len(trades))
+}
+
+// sharpe считает годовой коэффициент Шарпа по доходностям между точками
+// кривой капитала, безрисковая ставка принимается равной нулю.
+func sharpe(equity []EquityPoint) float64 {
+	if len(equity) < 3 {
+		return 0
+	}
+
+	returns := make([]float64, 0, len(equity)-1)
+	intervals := make([]time.Duration, 0, len(equity)-1)
+	for i := 1; i < len(equity); i++ {
+		if equity[i-1].Equity == 0 {
+			continue
+		}
+		returns = append(returns, equity[i].Equity/equity[i-1].Equity-1)
+		intervals = append(intervals, equity[i].Time.Sub(equity[i-1].Time))
+	}
+	if len(returns) < 2 {
+		return 0
+	}
+
+	var mean float64
+	for _, r := range returns {
+		mean += r
+	}
+	mean /= float64(len(returns))
+
+	var variance float64
+	for _, r := range returns {
+		variance += (r - mean) * (r - mean)
+	}
+	std := math.Sqrt(variance / float64(len(returns)-1))
+	if std == 0 {
+		return 0
+	}
+
+	sort.Slice(intervals, func(i, j int) bool { return intervals[i] < intervals[j] })
+	step := intervals[len(intervals)/2]
+	if step <= 0 {
+		step = 24 * time.Hour
+	}
+	periodsPerYear := float64(365*24*time.Hour) / float64(step)
+
+	return mean / std * math.Sqrt(periodsPerYear)
+}
+
+// maxDrawdown возвращает максимальную просадку от пика как долю, 0.25 = 25%.
+func maxDrawdown(equity []EquityPoint) float64 {
+	var peak, worst float64
+	for _, p := range equity {
+		if p.Equity > peak {
+			peak = p.Equity
+		}
+		if peak > 0 {
+			if dd := (peak - p.Equity) / peak; dd > worst {
+				worst = dd
+			}
+		}
+	}
+	return worst
+}
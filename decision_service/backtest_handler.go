@@ -0,0 +1,125 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/skomaroh1845/crypto_telemetry/decision_service/ai"
+	"github.com/skomaroh1845/crypto_telemetry/decision_service/backtest"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+type BacktestRequest struct {
+	Strategy string           `json:"strategy"`
+	Symbol   string           `json:"symbol"`
+	From     string           `json:"from,omitempty"` // YYYY-MM-DD, если свечи не переданы
+	To       string           `json:"to,omitempty"`
+	Candles  []ai.Candle      `json:"candles,omitempty"`
+	Config   *backtest.Config `json:"config,omitempty"`
+}
+
+func (h *Handler) backtestHandler(w http.ResponseWriter, r *http.Request) {
+	ctx, span := tracer.Start(r.Context(), "backtest-process",
+		trace.WithAttributes(attribute.String("handler", "backtest")),
+	)
+	defer span.End()
+
+	// стратегия вызывается на каждой свече, так что прогон не укладывается
+	// в общий таймаут записи сервера
+	if h.backtestTimeout > 0 {
+		_ = http.NewResponseController(w).SetWriteDeadline(time.Now().Add(h.backtestTimeout))
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, h.backtestTimeout)
+		defer cancel()
+	}
+
+	var req BacktestRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "Invalid request body")
+		http.Error(w, "Invalid request body: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	if req.Symbol == "" {
+		req.Symbol = "BTC"
+	}
+	if req.Strategy == "" {
+		req.Strategy = "daniilfrolov"
+	}
+	cfg := backtest.DefaultConfig()
+	if req.Config != nil {
+		cfg = *req.Config
+	}
+
+	span.SetAttributes(
+		attribute.String("symbol", req.Symbol),
+		attribute.String("strategy", req.Strategy),
+	)
+
+	client, err := ai.NewClient(req.Strategy)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "Unknown strategy")
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	candles := req.Candles
+	if len(candles) > 0 {
+		// присланные свечи упорядочиваются так же, как загруженные из файла
+		if candles, err = backtest.Normalize(candles); err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, "Invalid candles")
+			http.Error(w, "Invalid candles: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+	} else {
+		to := time.Now().UTC()
+		from := to.AddDate(0, 0, -30)
+		if req.From != "" {
+			if from, err = time.Parse("2006-01-02", req.From); err != nil {
+				http.Error(w, "Invalid from date, expected YYYY-MM-DD", http.StatusBadRequest)
+				return
+			}
+		}
+		if req.To != "" {
+			if to, err = time.Parse("2006-01-02", req.To); err != nil {
+				http.Error(w, "Invalid to date, expected YYYY-MM-DD", http.StatusBadRequest)
+				return
+			}
+		}
+
+		dataServiceURL := os.Getenv("DATA_SERVICE_URL")
+		if dataServiceURL == "" {
+			dataServiceURL = "http://data_service:8080"
+		}
+
+		candles, err = backtest.FetchHistory(ctx, dataServiceURL, req.Symbol, from, to)
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, "History fetch failed")
+			http.Error(w, "Failed to fetch history: "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+	}
+
+	report, err := backtest.Run(ctx, client, req.Symbol, candles, cfg)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "Backtest failed")
+		http.Error(w, "Backtest failed: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	report.Strategy = req.Strategy
+
+	span.SetStatus(codes.Ok, "Backtest completed successfully")
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(report)
+}
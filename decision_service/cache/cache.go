@@ -0,0 +1,161 @@
+// Package cache хранит недавние ответы стратегий, чтобы одинаковые вопросы
+// в пределах окна не вызывали модель повторно. Ключ — символ, версия
+// стратегии и корзина цены: заметное движение рынка даёт новый ключ ещё до
+// истечения TTL. Одновременные запросы с одним ключом ждут один вызов.
+package cache
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/skomaroh1845/crypto_telemetry/decision_service/ai"
+)
+
+// Result — чем закончился поиск в кэше.
+type Result string
+
+const (
+	// Miss — ответа не было, стратегия вызвана этим запросом
+	Miss Result = "miss"
+	// Hit — ответ взят из кэша
+	Hit Result = "hit"
+	// Coalesced — запрос дождался ответа, который получал другой запрос
+	Coalesced Result = "coalesced"
+)
+
+// Key — символ, версия стратегии и корзина цены.
+type Key struct {
+	Symbol   string
+	Strategy string
+	Bucket   int64
+}
+
+func (k Key) String() string {
+	return fmt.Sprintf("%s/%s/%d", k.Symbol, k.Strategy, k.Bucket)
+}
+
+type entry struct {
+	done     chan struct{}
+	decision ai.DecisionResponse
+	err      error
+	expires  time.Time
+}
+
+// Cache — ответы стратегий с временем жизни ttl. Нулевой ttl или nil
+// отключает кэш.
+type Cache struct {
+	ttl     time.Duration
+	step    float64
+	timeout time.Duration
+
+	mu      sync.Mutex
+	entries map[Key]*entry
+}
+
+// New создаёт кэш; step — ширина корзины цены в долях, 0.001 — 0.1%;
+// timeout ограничивает общий вызов fetch, 0 — без ограничения.
+func New(ttl time.Duration, step float64, timeout time.Duration) *Cache {
+	return &Cache{ttl: ttl, step: step, timeout: timeout, entries: map[Key]*entry{}}
+}
+
+// Key строит ключ для рыночной картины. Корзины логарифмические, так что
+// их ширина в процентах одинакова для любой цены.
+func (c *Cache) Key(strategy string, market ai.MarketData) Key {
+	k := Key{Symbol: market.Symbol, Strategy: strategy}
+	if c != nil && c.step > 0 && market.Price > 0 {
+		k.Bucket = int64(math.Floor(math.Log(market.Price) / math.Log1p(c.step)))
+	}
+	return k
+}
+
+// Get возвращает ответ по ключу или получает его через fetch. Ошибки не
+// кэшируются, но достаются всем, кто ждал того же вызова. fetch общий для
+// всех ожидающих, поэтому не отменяется вместе с первым запросом и
+// ограничен только timeout; каждый запрос прекращает ждать по своему ctx.
+func (c *Cache) Get(ctx context.Context, key Key, fetch func(ctx context.Context) (ai.DecisionResponse, error)) (ai.DecisionResponse, Result, error) {
+	if c == nil || c.ttl <= 0 {
+		decision, err := fetch(ctx)
+		return decision, Miss, err
+	}
+
+	now := time.Now()
+	c.mu.Lock()
+	if e, ok := c.entries[key]; ok {
+		select {
+		case <-e.done:
+			if now.Before(e.expires) {
+				c.mu.Unlock()
+				return e.decision, Hit, nil
+			}
+		default:
+			c.mu.Unlock()
+			select {
+			case <-e.done:
+				return e.decision, Coalesced, e.err
+			case <-ctx.Done():
+				return ai.DecisionResponse{}, Coalesced, ctx.Err()
+			}
+		}
+	}
+	e := &entry{done: make(chan struct{})}
+	c.entries[key] = e
+	c.prune(now)
+	c.mu.Unlock()
+
+	go c.fetch(context.WithoutCancel(ctx), key, e, fetch)
+	select {
+	case <-e.done:
+		return e.decision, Miss, e.err
+	case <-ctx.Done():
+		return ai.DecisionResponse{}, Miss, ctx.Err()
+	}
+}
+
+// fetch заполняет запись и закрывает done даже при панике в fetch: иначе
+// ожидающие зависли бы, а ключ остался занят навсегда.
+func (c *Cache) fetch(ctx context.Context, key Key, e *entry, fetch func(ctx context.Context) (ai.DecisionResponse, error)) {
+	if c.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.timeout)
+		defer cancel()
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			e.err = fmt.Errorf("decision fetch panicked: %v", r)
+		}
+		c.mu.Lock()
+		if e.err != nil && c.entries[key] == e {
+			delete(c.entries, key)
+		}
+		e.expires = time.Now().Add(c.ttl)
+		close(e.done)
+		c.mu.Unlock()
+	}()
+	e.decision, e.err = fetch(ctx)
+}
+
+// Len — число ответов в кэше, включая ожидаемые.
+func (c *Cache) Len() int {
+	if c == nil {
+		return 0
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.entries)
+}
+
+// prune удаляет устаревшие ответы, вызывается под mu.
+func (c *Cache) prune(now time.Time) {
+	for k, e := range c.entries {
+		select {
+		case <-e.done:
+			if !now.Before(e.expires) {
+				delete(c.entries, k)
+			}
+		default:
+		}
+	}
+}
@@ -0,0 +1,127 @@
+// Command backtest прогоняет стратегию decision_service по исторической серии
+// цен и печатает PnL, win rate, Sharpe и максимальную просадку.
+//
+//	go run ./cmd/backtest -strategy daniilfrolov -file btc.csv
+//	go run ./cmd/backtest -strategy groq -symbol ETH -from 2025-01-01 -to 2025-06-01
+package main
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"strconv"
+	"time"
+
+	"github.com/skomaroh1845/crypto_telemetry/decision_service/ai"
+	"github.com/skomaroh1845/crypto_telemetry/decision_service/backtest"
+)
+
+func main() {
+	defaults := backtest.DefaultConfig()
+
+	strategy := flag.String("strategy", "daniilfrolov", "strategy name: daniilfrolov, groq, deepseek, ml, rules, regime")
+	file := flag.String("file", "", "history file (.csv or .jsonl); if empty, history is loaded from the data service")
+	symbol := flag.String("symbol", "BTC", "symbol to backtest")
+	from := flag.String("from", time.Now().AddDate(0, 0, -90).Format("2006-01-02"), "start date for data service history (YYYY-MM-DD)")
+	to := flag.String("to", time.Now().Format("2006-01-02"), "end date for data service history (YYYY-MM-DD)")
+	dataURL := flag.String("data-url", envOr("DATA_SERVICE_URL", "http://localhost:8080"), "data service base URL")
+	cash := flag.Float64("cash", defaults.InitialCash, "initial cash")
+	fee := flag.Float64("fee", defaults.FeeRate, "fee rate per fill, 0.001 = 0.1%")
+	slippage := flag.Float64("slippage", defaults.Slippage, "slippage per fill, 0.0005 = 0.05%")
+	size := flag.Float64("size", defaults.PositionSize, "fraction of equity per buy, (0, 1]")
+	lookback := flag.Int("lookback", defaults.Lookback, "number of previous candles passed to the strategy")
+	equityOut := flag.String("equity", "", "write equity curve to this CSV file")
+	asJSON := flag.Bool("json", false, "print full report as JSON")
+	flag.Parse()
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	client, err := ai.NewClient(*strategy)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	var candles []ai.Candle
+	if *file != "" {
+		candles, err = backtest.LoadFile(*file)
+	} else {
+		var start, end time.Time
+		if start, err = time.Parse("2006-01-02", *from); err != nil {
+			log.Fatalf("invalid -from: %v", err)
+		}
+		if end, err = time.Parse("2006-01-02", *to); err != nil {
+			log.Fatalf("invalid -to: %v", err)
+		}
+		candles, err = backtest.FetchHistory(ctx, *dataURL, *symbol, start, end)
+	}
+	if err != nil {
+		log.Fatalf("failed to load history: %v", err)
+	}
+
+	cfg := backtest.Config{
+		InitialCash:  *cash,
+		FeeRate:      *fee,
+		Slippage:     *slippage,
+		PositionSize: *size,
+		Lookback:     *lookback,
+	}
+
+	report, err := backtest.Run(ctx, client, *symbol, candles, cfg)
+	if err != nil {
+		log.Fatalf("backtest failed: %v", err)
+	}
+	report.Strategy = *strategy
+
+	if *equityOut != "" {
+		if err := writeEquity(*equityOut, report.Equity); err != nil {
+			log.Fatalf("failed to write equity curve: %v", err)
+		}
+	}
+
+	if *asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		_ = enc.Encode(report)
+		return
+	}
+
+	fmt.Printf("strategy:      %s\n", report.Strategy)
+	fmt.Printf("symbol:        %s\n", report.Symbol)
+	fmt.Printf("period:        %s .. %s (%d candles)\n", report.From.Format(time.RFC3339), report.To.Format(time.RFC3339), report.Candles)
+	fmt.Printf("final equity:  %.2f (start %.2f)\n", report.FinalEquity, report.InitialCash)
+	fmt.Printf("pnl:           %.2f (%.2f%%)\n", report.PnL, report.ReturnPct)
+	fmt.Printf("trades:        %d, win rate %.1f%%\n", len(report.Trades), report.WinRate*100)
+	fmt.Printf("sharpe:        %.2f\n", report.Sharpe)
+	fmt.Printf("max drawdown:  %.2f%%\n", report.MaxDrawdown*100)
+	fmt.Printf("fees paid:     %.2f\n", report.Fees)
+	fmt.Printf("decisions:     %v, errors %d\n", report.Decisions, report.Errors)
+}
+
+func writeEquity(path string, equity []backtest.EquityPoint) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	w := csv.NewWriter(f)
+	_ = w.Write([]string{"time", "equity"})
+	for _, p := range equity {
+		_ = w.Write([]string{p.Time.Format(time.RFC3339), strconv.FormatFloat(p.Equity, 'f', 2, 64)})
+	}
+	w.Flush()
+	return w.Error()
+}
+
+func envOr(key, fallback string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	return fallback
+}
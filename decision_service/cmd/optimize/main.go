@@ -0,0 +1,185 @@
+// Command optimize подбирает параметры файла правил по истории с проверкой
+// walk-forward и сохраняет лучший набор в файл для RULES_PARAMS_FILE.
+//
+//	go run ./cmd/optimize -rules rules/example.rules -space "oversold=20:40:5;overbought=60,70,80" -file btc.csv
+//	go run ./cmd/optimize -rules my.rules -space "oversold=10:45:1;overbought=55:90:1" -mode random -samples 200 -symbol ETH
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/skomaroh1845/crypto_telemetry/decision_service/ai"
+	"github.com/skomaroh1845/crypto_telemetry/decision_service/backtest"
+	"github.com/skomaroh1845/crypto_telemetry/decision_service/optimize"
+)
+
+func main() {
+	defaults := backtest.DefaultConfig()
+
+	rulesFile := flag.String("rules", "", "rules file with param declarations")
+	spaceSpec := flag.String("space", "", `parameter space, e.g. "oversold=20:40:5;period=10,14,20"`)
+	mode := flag.String("mode", "grid", "search mode: grid or random")
+	samples := flag.Int("samples", 100, "number of parameter sets for random search")
+	seed := flag.Int64("seed", 1, "random search seed")
+	train := flag.Int("train", 180, "train window, candles")
+	test := flag.Int("test", 60, "test window, candles")
+	objective := flag.String("objective", optimize.ObjectiveSharpe, "objective: sharpe or return")
+	workers := flag.Int("workers", runtime.NumCPU(), "number of parallel trials")
+	file := flag.String("file", "", "history file (.csv or .jsonl); if empty, history is loaded from the data service")
+	symbol := flag.String("symbol", "BTC", "symbol to optimize on")
+	from := flag.String("from", time.Now().AddDate(0, 0, -365).Format("2006-01-02"), "start date for data service history (YYYY-MM-DD)")
+	to := flag.String("to", time.Now().Format("2006-01-02"), "end date for data service history (YYYY-MM-DD)")
+	dataURL := flag.String("data-url", envOr("DATA_SERVICE_URL", "http://localhost:8080"), "data service base URL")
+	cash := flag.Float64("cash", defaults.InitialCash, "initial cash")
+	fee := flag.Float64("fee", defaults.FeeRate, "fee rate per fill, 0.001 = 0.1%")
+	slippage := flag.Float64("slippage", defaults.Slippage, "slippage per fill, 0.0005 = 0.05%")
+	size := flag.Float64("size", defaults.PositionSize, "fraction of equity per buy, (0, 1]")
+	lookback := flag.Int("lookback", defaults.Lookback, "number of previous candles passed to the strategy")
+	out := flag.String("out", "", "write the best parameters to this file (default <rules>.params.json)")
+	top := flag.Int("top", 10, "number of ranked parameter sets to print")
+	asJSON := flag.Bool("json", false, "print full report as JSON")
+	flag.Parse()
+
+	if *rulesFile == "" || *spaceSpec == "" {
+		log.Fatal("-rules and -space are required")
+	}
+	template, err := os.ReadFile(*rulesFile)
+	if err != nil {
+		log.Fatalf("failed to read rules: %v", err)
+	}
+	space, err := optimize.ParseSpace(*spaceSpec)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	var candidates []map[string]float64
+	switch *mode {
+	case "grid":
+		if candidates, err = optimize.Grid(space); err != nil {
+			log.Fatal(err)
+		}
+	case "random":
+		candidates = optimize.Random(space, *samples, *seed)
+	default:
+		log.Fatalf("unknown -mode %q, expected grid or random", *mode)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	var candles []ai.Candle
+	if *file != "" {
+		candles, err = backtest.LoadFile(*file)
+	} else {
+		var start, end time.Time
+		if start, err = time.Parse("2006-01-02", *from); err != nil {
+			log.Fatalf("invalid -from: %v", err)
+		}
+		if end, err = time.Parse("2006-01-02", *to); err != nil {
+			log.Fatalf("invalid -to: %v", err)
+		}
+		candles, err = backtest.FetchHistory(ctx, *dataURL, *symbol, start, end)
+	}
+	if err != nil {
+		log.Fatalf("failed to load history: %v", err)
+	}
+
+	cfg := optimize.Config{
+		Objective: *objective,
+		Train:     *train,
+		Test:      *test,
+		Workers:   *workers,
+		Backtest: backtest.Config{
+			InitialCash:  *cash,
+			FeeRate:      *fee,
+			Slippage:     *slippage,
+			PositionSize: *size,
+			Lookback:     *lookback,
+		},
+	}
+	if !*asJSON {
+		cfg.OnTrial = func(done, total int) {
+			if done%100 == 0 || done == total {
+				fmt.Fprintf(os.Stderr, "\rtrials: %d/%d", done, total)
+				if done == total {
+					fmt.Fprintln(os.Stderr)
+				}
+			}
+		}
+	}
+
+	name := filepath.Base(*rulesFile)
+	report, err := optimize.Run(ctx, *symbol, name, template, candidates, candles, cfg)
+	if err != nil {
+		log.Fatalf("optimization failed: %v", err)
+	}
+
+	path := *out
+	if path == "" {
+		path = strings.TrimSuffix(*rulesFile, filepath.Ext(*rulesFile)) + ".params.json"
+	}
+	if err := optimize.WriteParams(path, report); err != nil {
+		log.Fatalf("failed to write params: %v", err)
+	}
+
+	if *asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		_ = enc.Encode(report)
+		return
+	}
+
+	fmt.Printf("rules:         %s@%s\n", report.Rules, report.RulesVersion)
+	fmt.Printf("symbol:        %s, %d candles\n", report.Symbol, report.Candles)
+	fmt.Printf("search:        %s, %d parameter sets, %d failed\n", *mode, len(report.Candidates), report.Failed)
+	fmt.Printf("objective:     %s\n", report.Objective)
+	fmt.Printf("walk-forward:  %d folds, in-sample %.3f, out-of-sample %.3f", len(report.Folds), report.WalkForward.MeanTrain, report.WalkForward.MeanTest)
+	if report.WalkForward.Efficiency != 0 {
+		fmt.Printf(", efficiency %.2f", report.WalkForward.Efficiency)
+	}
+	fmt.Println()
+	for i, f := range report.Folds {
+		fmt.Printf("  fold %-3d test %s .. %s  train %.3f  test %.3f  %s\n", i+1,
+			f.TestFrom.Format("2006-01-02"), f.TestTo.Format("2006-01-02"), f.TrainScore, f.TestScore, formatParams(f.Best))
+	}
+	fmt.Println("ranked by score on the latest train window:")
+	for _, c := range report.Candidates {
+		if c.Rank == 0 || c.Rank > *top {
+			break
+		}
+		fmt.Printf("  %-3d latest %8.3f  train %8.3f  test %8.3f  trades %-4d %s\n",
+			c.Rank, c.LastTrain, c.MeanTrain, c.MeanTest, c.Trades, formatParams(c.Params))
+	}
+	fmt.Printf("best params:   %s, expected out-of-sample %.3f\n", path, report.WalkForward.MeanTest)
+}
+
+func formatParams(params map[string]float64) string {
+	keys := make([]string, 0, len(params))
+	for k := range params {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	parts := make([]string, len(keys))
+	for i, k := range keys {
+		parts[i] = fmt.Sprintf("%s=%v", k, params[k])
+	}
+	return strings.Join(parts, " ")
+}
+
+func envOr(key, fallback string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	return fallback
+}
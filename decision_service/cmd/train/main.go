@@ -0,0 +1,121 @@
+// Command train обучает модель направления цены стратегии ml по истории
+// свечей и сохраняет её в версионированный файл.
+//
+//	go run ./cmd/train -file btc.csv -symbol BTC
+//	go run ./cmd/train -symbol ETH -kind stumps -horizon 3 -from 2023-01-01
+//
+// Путь к файлу затем передаётся сервису через ML_MODEL_FILE.
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"time"
+
+	"github.com/skomaroh1845/crypto_telemetry/decision_service/ai"
+	"github.com/skomaroh1845/crypto_telemetry/decision_service/backtest"
+	"github.com/skomaroh1845/crypto_telemetry/decision_service/ml"
+)
+
+func main() {
+	defaults := ml.DefaultTrainConfig()
+
+	file := flag.String("file", "", "history file (.csv or .jsonl); if empty, history is loaded from the data service")
+	symbol := flag.String("symbol", "BTC", "symbol to train on")
+	from := flag.String("from", time.Now().AddDate(-2, 0, 0).Format("2006-01-02"), "start date for data service history (YYYY-MM-DD)")
+	to := flag.String("to", time.Now().Format("2006-01-02"), "end date for data service history (YYYY-MM-DD)")
+	dataURL := flag.String("data-url", envOr("DATA_SERVICE_URL", "http://localhost:8080"), "data service base URL")
+	out := flag.String("out", "models", "directory for the model file")
+	kind := flag.String("kind", defaults.Kind, "model kind: logreg, stumps")
+	horizon := flag.Int("horizon", defaults.Horizon, "predict the close this many candles ahead")
+	validation := flag.Float64("validation", defaults.Validation, "fraction of latest samples held out for calibration and metrics")
+	threshold := flag.Float64("threshold", defaults.BuyThreshold, "P(up) to buy; sell when P(down) reaches it")
+	epochs := flag.Int("epochs", defaults.Epochs, "logreg: gradient descent epochs")
+	lr := flag.Float64("lr", defaults.LearningRate, "logreg: learning rate")
+	l2 := flag.Float64("l2", defaults.L2, "logreg: L2 regularization")
+	rounds := flag.Int("rounds", defaults.Rounds, "stumps: boosting rounds")
+	rate := flag.Float64("rate", defaults.Rate, "stumps: shrinkage per round")
+	bins := flag.Int("bins", defaults.Bins, "stumps: candidate thresholds per feature")
+	asJSON := flag.Bool("json", false, "print the model as JSON")
+	flag.Parse()
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	var candles []ai.Candle
+	var err error
+	if *file != "" {
+		candles, err = backtest.LoadFile(*file)
+	} else {
+		var start, end time.Time
+		if start, err = time.Parse("2006-01-02", *from); err != nil {
+			log.Fatalf("invalid -from: %v", err)
+		}
+		if end, err = time.Parse("2006-01-02", *to); err != nil {
+			log.Fatalf("invalid -to: %v", err)
+		}
+		candles, err = backtest.FetchHistory(ctx, *dataURL, *symbol, start, end)
+	}
+	if err != nil {
+		log.Fatalf("failed to load history: %v", err)
+	}
+
+	series := ml.Series{}
+	for _, c := range candles {
+		series.Close = append(series.Close, c.Close)
+		series.High = append(series.High, c.High)
+		series.Low = append(series.Low, c.Low)
+		series.Volume = append(series.Volume, c.Volume)
+	}
+	x, y := ml.Dataset(series, *horizon)
+
+	cfg := ml.TrainConfig{
+		Kind:         *kind,
+		Horizon:      *horizon,
+		Validation:   *validation,
+		BuyThreshold: *threshold,
+		Epochs:       *epochs,
+		LearningRate: *lr,
+		L2:           *l2,
+		Rounds:       *rounds,
+		Rate:         *rate,
+		Bins:         *bins,
+	}
+	model, err := ml.Train(*symbol, x, y, cfg)
+	if err != nil {
+		log.Fatalf("training failed: %v", err)
+	}
+
+	path, err := model.Save(*out)
+	if err != nil {
+		log.Fatalf("failed to save model: %v", err)
+	}
+
+	if *asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		_ = enc.Encode(model)
+		return
+	}
+
+	v := model.Validation
+	fmt.Printf("model:         %s\n", model.Version)
+	fmt.Printf("file:          %s\n", path)
+	fmt.Printf("candles:       %d, samples %d train / %d validation\n", len(candles), model.TrainSamples, v.Samples)
+	fmt.Printf("accuracy:      %.1f%% (up rate %.1f%%)\n", v.Accuracy*100, v.BaseRate*100)
+	fmt.Printf("log loss:      %.4f\n", v.LogLoss)
+	fmt.Printf("brier:         %.4f\n", v.Brier)
+	fmt.Printf("calibration:   a=%.3f b=%.3f\n", model.Calibration.A, model.Calibration.B)
+}
+
+func envOr(key, fallback string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	return fallback
+}
@@ -0,0 +1,260 @@
+package main
+
+import (
+	"log"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/skomaroh1845/crypto_telemetry/decision_service/drift"
+	"github.com/skomaroh1845/crypto_telemetry/decision_service/paper"
+	"github.com/skomaroh1845/crypto_telemetry/decision_service/rebalance"
+	"github.com/skomaroh1845/crypto_telemetry/decision_service/risk"
+)
+
+type Config struct {
+	Strategy string
+	// FallbackChain — провайдеры через запятую с необязательным таймаутом,
+	// например "groq:5s,deepseek:15s,daniilfrolov". Пусто — только Strategy.
+	FallbackChain    string
+	LLMTimeout       time.Duration
+	BreakerThreshold int
+	BreakerCooldown  time.Duration
+
+	// RegistryFile — версии стратегий и стек активаций; активная версия
+	// заменяет Strategy и FallbackChain
+	RegistryFile string
+
+	// LLMPrices — цены за миллион токенов поверх встроенных,
+	// например "llama3-8b-8192=0.05/0.08,deepseek-chat=0.27/1.10"
+	LLMPrices string
+	// LLMDailyBudget — лимит расходов на LLM в долларах за сутки, 0 — без лимита
+	LLMDailyBudget float64
+	LLMBudgetFile  string
+
+	// PromptDir — каталог с *.tmpl, переопределяющими встроенные шаблоны
+	PromptDir      string
+	PromptTemplate string
+	// HistoryDays — сколько дневных свечей подгружать в контекст решения
+	HistoryDays int
+
+	// ExperimentVariants — варианты A/B эксперимента ExperimentName в формате
+	// "control=50,candidate=50:groq:default", пусто — эксперимента нет
+	ExperimentName     string
+	ExperimentVariants string
+
+	// ShadowStrategies — теневые стратегии в формате FALLBACK_CHAIN,
+	// например "groq:10s,deepseek"; пусто — теней нет
+	ShadowStrategies  string
+	ShadowMaxInFlight int
+	ShadowLogFile     string
+	// ShadowRetention — сколько хранятся ответы теней, 0 — без ограничения
+	ShadowRetention time.Duration
+
+	// SignalJobs — задания планировщика сигналов, например
+	// "BTC,ETH=*/15 * * * *;SOL:groq=@every 1h"; пусто — планировщик выключен
+	SignalJobs      string
+	SignalStateFile string
+	NotifierURL     string
+
+	// RulesFile — файл правил стратегии rules, он же проверяется через
+	// /v1/rules/dry-run; пусто — правил нет
+	RulesFile string
+	// RulesParamsFile — значения параметров правил, например из cmd/optimize
+	RulesParamsFile string
+
+	// AgentProvider — провайдер с вызовом функций для торгового агента
+	// (groq или deepseek); пусто — агент выключен
+	AgentProvider string
+	AgentModel    string
+	AgentMaxSteps int
+	AgentTimeout  time.Duration
+
+	// BacktestTimeout — сколько может идти POST /backtest: прогон по году
+	// свечей не укладывается в общий таймаут записи сервера
+	BacktestTimeout time.Duration
+
+	// Timeframes — таймфреймы для контекста решения, например "15m,1h,4h,1d";
+	// пусто — только дневные свечи
+	Timeframes string
+	// TimeframeAgreement — сколько таймфреймов должны поддержать buy или sell,
+	// иначе решение становится hold; 0 — не проверять
+	TimeframeAgreement int
+
+	// ExplainLanguage — язык объяснений решений, если в запросе нет lang
+	ExplainLanguage string
+	// ExplainProvider — провайдер (groq или deepseek), который переформулирует
+	// шаблонные объяснения; пусто — только шаблоны
+	ExplainProvider string
+	ExplainModel    string
+	ExplainTimeout  time.Duration
+
+	// SentimentFeeds — ленты новостей RSS, Atom или JSON Feed через запятую,
+	// адресом или путём к файлу; пусто — новости не учитываются
+	SentimentFeeds    string
+	SentimentInterval time.Duration
+	// SentimentWindow — за какой период новости входят в настроение
+	SentimentWindow time.Duration
+	// SentimentProvider — провайдер (groq или deepseek) для оценки новостей;
+	// пусто — оценка по словарю
+	SentimentProvider string
+	SentimentModel    string
+	SentimentTimeout  time.Duration
+
+	// CacheTTL — сколько ответ стратегии переиспользуется для того же символа,
+	// версии стратегии и корзины цены; 0 — без кэша
+	CacheTTL time.Duration
+	// CachePriceStep — ширина корзины цены в долях, движение больше неё
+	// сбрасывает кэш раньше TTL
+	CachePriceStep float64
+	// CacheFetchTimeout ограничивает вызов стратегии, который ждут несколько
+	// запросов: он не отменяется вместе с первым из них
+	CacheFetchTimeout time.Duration
+
+	// Drift задаёт сравнение свежих решений с базовым окном, оповещения
+	// уходят в админский чат notifier
+	Drift drift.Config
+
+	// RiskProfile — профиль риска по умолчанию, если в запросе нет risk
+	RiskProfile risk.Profile
+
+	AuditLogFile string
+	// AuditRetention — сколько хранятся записи журнала решений; должно быть
+	// больше самого длинного горизонта проверки, 0 — без ограничения
+	AuditRetention time.Duration
+
+	// OutcomeHorizons — через сколько проверять решения, например "1h,24h,7d"
+	OutcomeHorizons string
+	OutcomeInterval time.Duration
+	// OutcomeHoldBand — движение цены, в пределах которого hold считается верным
+	OutcomeHoldBand float64
+	OutcomeLogFile  string
+
+	// OutcomeMaxAttempts — сколько проходов подряд ждать цену для проверки
+	// решения, прежде чем бросить его; 0 — без ограничения
+	OutcomeMaxAttempts int
+
+	PaperStateFile string
+	Paper          paper.Config
+
+	// Rebalance задаёт построение целевого распределения корзины
+	Rebalance rebalance.Config
+}
+
+func LoadConfig() *Config {
+	paperDefaults := paper.DefaultConfig()
+	driftDefaults := drift.DefaultConfig()
+	rebalanceDefaults := rebalance.DefaultConfig()
+
+	riskProfile, err := risk.ParseProfile(getEnv("RISK_PROFILE", ""))
+	if err != nil {
+		log.Printf("%v, using %s", err, risk.Balanced)
+		riskProfile = risk.Balanced
+	}
+
+	return &Config{
+		Strategy:           getEnv("DECISION_STRATEGY", "daniilfrolov"),
+		FallbackChain:      getEnv("FALLBACK_CHAIN", ""),
+		RegistryFile:       getEnv("STRATEGY_REGISTRY_FILE", "data/strategies.json"),
+		LLMTimeout:         getEnvAsDuration("LLM_TIMEOUT", 10*time.Second),
+		BreakerThreshold:   getEnvAsInt("BREAKER_THRESHOLD", 3),
+		BreakerCooldown:    getEnvAsDuration("BREAKER_COOLDOWN", 30*time.Second),
+		LLMPrices:          getEnv("LLM_PRICES", ""),
+		LLMDailyBudget:     getEnvAsFloat("LLM_DAILY_BUDGET_USD", 0),
+		LLMBudgetFile:      getEnv("LLM_BUDGET_FILE", "data/llm_budget.json"),
+		PromptDir:          getEnv("PROMPT_DIR", "prompts"),
+		PromptTemplate:     getEnv("PROMPT_TEMPLATE", "default"),
+		HistoryDays:        getEnvAsInt("HISTORY_DAYS", 30),
+		ExperimentName:     getEnv("EXPERIMENT_NAME", "default"),
+		ExperimentVariants: getEnv("EXPERIMENT_VARIANTS", ""),
+		ShadowStrategies:   getEnv("SHADOW_STRATEGIES", ""),
+		ShadowMaxInFlight:  getEnvAsInt("SHADOW_MAX_IN_FLIGHT", 4),
+		ShadowLogFile:      getEnv("SHADOW_LOG_FILE", "data/shadow.jsonl"),
+		ShadowRetention:    getEnvAsDuration("SHADOW_RETENTION", 30*24*time.Hour),
+		SignalJobs:         getEnv("SIGNAL_JOBS", ""),
+		SignalStateFile:    getEnv("SIGNAL_STATE_FILE", "data/signals.json"),
+		NotifierURL:        getEnv("NOTIFIER_URL", "http://notifier_service:8082"),
+		RulesFile:          getEnv("RULES_FILE", ""),
+		RulesParamsFile:    getEnv("RULES_PARAMS_FILE", ""),
+		AgentProvider:      getEnv("AGENT_PROVIDER", ""),
+		AgentModel:         getEnv("AGENT_MODEL", ""),
+		AgentMaxSteps:      getEnvAsInt("AGENT_MAX_STEPS", 6),
+		AgentTimeout:       getEnvAsDuration("AGENT_TIMEOUT", 45*time.Second),
+		BacktestTimeout:    getEnvAsDuration("BACKTEST_TIMEOUT", 10*time.Minute),
+		Timeframes:         getEnv("TIMEFRAMES", ""),
+		TimeframeAgreement: getEnvAsInt("TIMEFRAME_AGREEMENT", 0),
+		ExplainLanguage:    getEnv("EXPLAIN_LANGUAGE", "en"),
+		ExplainProvider:    getEnv("EXPLAIN_PROVIDER", ""),
+		ExplainModel:       getEnv("EXPLAIN_MODEL", ""),
+		ExplainTimeout:     getEnvAsDuration("EXPLAIN_TIMEOUT", 5*time.Second),
+		SentimentFeeds:     getEnv("SENTIMENT_FEEDS", ""),
+		SentimentInterval:  getEnvAsDuration("SENTIMENT_INTERVAL", 10*time.Minute),
+		SentimentWindow:    getEnvAsDuration("SENTIMENT_WINDOW", 24*time.Hour),
+		SentimentProvider:  getEnv("SENTIMENT_PROVIDER", ""),
+		SentimentModel:     getEnv("SENTIMENT_MODEL", ""),
+		SentimentTimeout:   getEnvAsDuration("SENTIMENT_TIMEOUT", 5*time.Second),
+		CacheTTL:           getEnvAsDuration("DECISION_CACHE_TTL", time.Minute),
+		CachePriceStep:     getEnvAsFloat("DECISION_CACHE_PRICE_STEP", 0.002),
+		CacheFetchTimeout:  getEnvAsDuration("DECISION_CACHE_FETCH_TIMEOUT", time.Minute),
+		RiskProfile:        riskProfile,
+		AuditLogFile:       getEnv("AUDIT_LOG_FILE", "data/decisions.jsonl"),
+		AuditRetention:     getEnvAsDuration("AUDIT_RETENTION", 90*24*time.Hour),
+		OutcomeHorizons:    getEnv("OUTCOME_HORIZONS", "1h,24h,7d"),
+		OutcomeInterval:    getEnvAsDuration("OUTCOME_INTERVAL", 5*time.Minute),
+		OutcomeHoldBand:    getEnvAsFloat("OUTCOME_HOLD_BAND", 0.01),
+		OutcomeLogFile:     getEnv("OUTCOME_LOG_FILE", "data/outcomes.jsonl"),
+		OutcomeMaxAttempts: getEnvAsInt("OUTCOME_MAX_ATTEMPTS", 12),
+		PaperStateFile:     getEnv("PAPER_STATE_FILE", "data/paper_state.json"),
+		Paper: paper.Config{
+			InitialCash:  getEnvAsFloat("PAPER_INITIAL_CASH", paperDefaults.InitialCash),
+			BuyFraction:  getEnvAsFloat("PAPER_BUY_FRACTION", paperDefaults.BuyFraction),
+			SellFraction: getEnvAsFloat("PAPER_SELL_FRACTION", paperDefaults.SellFraction),
+			FeeRate:      getEnvAsFloat("PAPER_FEE_RATE", paperDefaults.FeeRate),
+		},
+		Drift: drift.Config{
+			Window:     getEnvAsDuration("DRIFT_WINDOW", driftDefaults.Window),
+			Baseline:   getEnvAsDuration("DRIFT_BASELINE", driftDefaults.Baseline),
+			Threshold:  getEnvAsFloat("DRIFT_THRESHOLD", driftDefaults.Threshold),
+			MinSamples: getEnvAsInt("DRIFT_MIN_SAMPLES", driftDefaults.MinSamples),
+			Cooldown:   getEnvAsDuration("DRIFT_COOLDOWN", driftDefaults.Cooldown),
+		},
+		Rebalance: rebalance.Config{
+			MinTrade:    getEnvAsFloat("REBALANCE_MIN_TRADE", rebalanceDefaults.MinTrade),
+			MaxInvested: getEnvAsFloat("REBALANCE_MAX_INVESTED", rebalanceDefaults.MaxInvested),
+		},
+	}
+}
+
+func getEnv(key, defaultValue string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return defaultValue
+}
+
+func getEnvAsFloat(key string, defaultValue float64) float64 {
+	if value := os.Getenv(key); value != "" {
+		if floatValue, err := strconv.ParseFloat(value, 64); err == nil {
+			return floatValue
+		}
+	}
+	return defaultValue
+}
+
+func getEnvAsInt(key string, defaultValue int) int {
+	if value := os.Getenv(key); value != "" {
+		if intValue, err := strconv.Atoi(value); err == nil {
+			return intValue
+		}
+	}
+	return defaultValue
+}
+
+func getEnvAsDuration(key string, defaultValue time.Duration) time.Duration {
+	if value := os.Getenv(key); value != "" {
+		if duration, err := time.ParseDuration(value); err == nil {
+			return duration
+		}
+	}
+	return defaultValue
+}
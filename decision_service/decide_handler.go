@@ -0,0 +1,119 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/skomaroh1845/crypto_telemetry/decision_service/ai"
+	"github.com/skomaroh1845/crypto_telemetry/decision_service/audit"
+	"github.com/skomaroh1845/crypto_telemetry/decision_service/risk"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// maxDecideBody ограничивает тело запроса: сотни свечей укладываются с запасом.
+const maxDecideBody = 1 << 20
+
+// DecideRequest — рыночный снимок от вызывающего. Поля ai.MarketData лежат
+// на верхнем уровне JSON, индикаторы вызывающего не пересчитываются.
+type DecideRequest struct {
+	ai.MarketData
+	ChatID int64  `json:"chat_id,omitempty"`
+	Risk   string `json:"risk,omitempty"`
+	Lang   string `json:"lang,omitempty"` // язык объяснения решения
+}
+
+// decideHandler принимает решение по переданным данным без обращения к
+// data_service. Решение пишется в аудит с source=push и не исполняется на
+// бумажном счёте: цены в снимке могут быть историческими или вымышленными.
+func (h *Handler) decideHandler(w http.ResponseWriter, r *http.Request) {
+	ctx, span := tracer.Start(r.Context(), "decide-process",
+		trace.WithAttributes(attribute.String("handler", "decide")),
+	)
+	defer span.End()
+
+	var req DecideRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxDecideBody)).Decode(&req); err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "Invalid request body")
+		http.Error(w, "Invalid request body: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := validateSnapshot(&req.MarketData); err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "Invalid market snapshot")
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	profile := h.riskProfile
+	if req.Risk != "" {
+		var err error
+		if profile, err = risk.ParseProfile(req.Risk); err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, "Invalid risk profile")
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+
+	market := ai.WithIndicators(req.MarketData)
+	span.SetAttributes(
+		attribute.String("symbol", market.Symbol),
+		attribute.Float64("price", market.Price),
+		attribute.Float64("volume", market.Volume),
+		attribute.Int("market.candles", len(market.Candles)),
+		attribute.String("risk.profile", string(profile)),
+	)
+
+	resp, status, err := h.decide(ctx, span, decisionInput{
+		market:  market,
+		chatID:  req.ChatID,
+		profile: profile,
+		source:  audit.SourcePush,
+		lang:    req.Lang,
+	})
+	if err != nil {
+		http.Error(w, "Failed to get AI decision: "+err.Error(), status)
+		return
+	}
+
+	span.SetStatus(codes.Ok, "Decision completed successfully")
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(resp)
+}
+
+// validateSnapshot проверяет снимок и дополняет необязательные поля:
+// время по умолчанию — сейчас, свечи сортируются от старых к новым.
+func validateSnapshot(data *ai.MarketData) error {
+	data.Symbol = strings.TrimSpace(data.Symbol)
+	if data.Symbol == "" {
+		return errors.New("symbol is required")
+	}
+	if data.Price <= 0 {
+		return errors.New("price must be positive")
+	}
+	if data.Volume < 0 {
+		return errors.New("volume must not be negative")
+	}
+	for i, c := range data.Candles {
+		if c.Close <= 0 || c.High < c.Low {
+			return fmt.Errorf("candle %d is invalid: close must be positive and high not below low", i)
+		}
+	}
+	if data.Timestamp.IsZero() {
+		data.Timestamp = time.Now().UTC()
+	}
+	sort.SliceStable(data.Candles, func(i, j int) bool {
+		return data.Candles[i].Time.Before(data.Candles[j].Time)
+	})
+	return nil
+}
@@ -0,0 +1,298 @@
+// Package drift следит за распределением решений buy/sell/hold по каждой
+// паре стратегия и символ и сообщает, когда свежие решения заметно
+// расходятся с базовым окном перед ними.
+package drift
+
+import (
+	"context"
+	"math"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/skomaroh1845/crypto_telemetry/decision_service/audit"
+	"github.com/skomaroh1845/crypto_telemetry/decision_service/format"
+)
+
+type Config struct {
+	// Window — текущее окно, которое сравнивается с базовым
+	Window time.Duration
+	// Baseline — базовое окно, идущее сразу перед текущим
+	Baseline time.Duration
+	// Threshold — расхождение Дженсена — Шеннона от 0 до 1, начиная с
+	// которого распределение считается сдвинутым; 0 — без оповещений
+	Threshold float64
+	// MinSamples — меньше решений в любом из окон не сравниваются
+	MinSamples int
+	// Cooldown — не чаще одного оповещения по паре за этот период
+	Cooldown time.Duration
+}
+
+func DefaultConfig() Config {
+	return Config{
+		Window:     6 * time.Hour,
+		Baseline:   7 * 24 * time.Hour,
+		Threshold:  0.2,
+		MinSamples: 20,
+		Cooldown:   6 * time.Hour,
+	}
+}
+
+// Distribution — доли решений в окне.
+type Distribution struct {
+	Count int     `json:"count"`
+	Buy   float64 `json:"buy"`
+	Sell  float64 `json:"sell"`
+	Hold  float64 `json:"hold"`
+}
+
+func (d Distribution) shares() [3]float64 {
+	return [3]float64{d.Buy, d.Sell, d.Hold}
+}
+
+// Status — сравнение текущего окна с базовым для пары стратегия и символ.
+type Status struct {
+	Strategy   string       `json:"strategy"`
+	Symbol     string       `json:"symbol"`
+	Current    Distribution `json:"current"`
+	Baseline   Distribution `json:"baseline"`
+	Divergence float64      `json:"divergence"`
+	// Drifting — в обоих окнах хватает решений и расхождение не ниже порога
+	Drifting  bool       `json:"drifting"`
+	LastAlert *time.Time `json:"last_alert,omitempty"`
+}
+
+// EventDecisionDrift — тип оповещения о сдвиге решений для notifier.
+const EventDecisionDrift = "decision_drift"
+
+// Alert — оповещение о сдвиге, уходит в админский чат.
+type Alert struct {
+	Type string `json:"type"`
+	Status
+	Threshold      float64   `json:"threshold"`
+	Window         string    `json:"window"`
+	BaselineWindow string    `json:"baseline_window"`
+	Time           time.Time `json:"time"`
+}
+
+type key struct {
+	strategy string
+	symbol   string
+}
+
+type event struct {
+	at       time.Time
+	decision string
+}
+
+type series struct {
+	events  []event // по возрастанию времени
+	alerted time.Time
+}
+
+// Monitor хранит решения за текущее и базовое окна. onAlert вызывается в
+// отдельной горутине, чтобы оповещение не задерживало ответ.
+type Monitor struct {
+	cfg     Config
+	onAlert func(ctx context.Context, alert Alert)
+
+	mu     sync.Mutex
+	series map[key]*series
+}
+
+func New(cfg Config, onAlert func(ctx context.Context, alert Alert)) *Monitor {
+	return &Monitor{cfg: cfg, onAlert: onAlert, series: map[key]*series{}}
+}
+
+// Observe учитывает решение стратегии и при сдвиге распределения отправляет
+// оповещение. Решения кроме buy, sell и hold пропускаются.
+func (m *Monitor) Observe(ctx context.Context, strategy, symbol, decision string, at time.Time) {
+	if m == nil || !valid(decision) {
+		return
+	}
+
+	m.mu.Lock()
+	s := m.add(key{strategy, symbol}, decision, at)
+	status := m.status(key{strategy, symbol}, s, at)
+	alert := m.cfg.Threshold > 0 && status.Drifting && at.Sub(s.alerted) >= m.cfg.Cooldown
+	if alert {
+		s.alerted = at
+		status.LastAlert = &at
+	}
+	m.mu.Unlock()
+
+	if alert && m.onAlert != nil {
+		go m.onAlert(context.WithoutCancel(ctx), Alert{
+			Type:           EventDecisionDrift,
+			Status:         status,
+			Threshold:      m.cfg.Threshold,
+			Window:         format.Duration(m.cfg.Window),
+			BaselineWindow: format.Duration(m.cfg.Baseline),
+			Time:           at,
+		})
+	}
+}
+
+// Seed восстанавливает окна из журнала решений после перезапуска, без
+// оповещений. Учитывается ответ стратегии до поправок таймфреймов и риска,
+// повторы из кэша пропускаются.
+func (m *Monitor) Seed(records []audit.Record) {
+	sorted := make([]audit.Record, len(records))
+	copy(sorted, records)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Time.Before(sorted[j].Time) })
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, rec := range sorted {
+		if rec.Error != "" || rec.Cached {
+			continue
+		}
+		if decision := ModelDecision(rec); valid(decision) {
+			m.add(key{rec.Strategy, rec.Symbol}, decision, rec.Time)
+		}
+	}
+}
+
+// ModelDecision — решение, которое вернула стратегия, до замены на hold
+// проверкой таймфреймов или риск-менеджментом.
+func ModelDecision(rec audit.Record) string {
+	switch {
+	case rec.Timeframes != nil && rec.Timeframes.Blocked:
+		return rec.Timeframes.OriginalDecision
+	case rec.Risk != nil && rec.Risk.Refused:
+		return rec.Risk.OriginalDecision
+	default:
+		return rec.Decision
+	}
+}
+
+// Statuses возвращает сравнение окон на момент now по всем парам.
+func (m *Monitor) Statuses(now time.Time) []Status {
+	if m == nil {
+		return nil
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	out := make([]Status, 0, len(m.series))
+	for k, s := range m.series {
+		m.prune(s, now)
+		if len(s.events) == 0 {
+			continue
+		}
+		out = append(out, m.status(k, s, now))
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Strategy != out[j].Strategy {
+			return out[i].Strategy < out[j].Strategy
+		}
+		return out[i].Symbol < out[j].Symbol
+	})
+	return out
+}
+
+// add дописывает решение в ряд пары и отбрасывает вышедшие из окон,
+// вызывается под mu.
+func (m *Monitor) add(k key, decision string, at time.Time) *series {
+	s := m.series[k]
+	if s == nil {
+		s = &series{}
+		m.series[k] = s
+	}
+	// решения приходят почти по порядку, так что вставка идёт с конца
+	i := len(s.events)
+	for i > 0 && s.events[i-1].at.After(at) {
+		i--
+	}
+	s.events = append(s.events, event{})
+	copy(s.events[i+1:], s.events[i:])
+	s.events[i] = event{at: at, decision: decision}
+	m.prune(s, at)
+	return s
+}
+
+func (m *Monitor) prune(s *series, now time.Time) {
+	from := now.Add(-m.cfg.Window - m.cfg.Baseline)
+	i := 0
+	for i < len(s.events) && !s.events[i].at.After(from) {
+		i++
+	}
+	s.events = s.events[i:]
+}
+
+func (m *Monitor) status(k key, s *series, now time.Time) Status {
+	split := now.Add(-m.cfg.Window)
+	var current, baseline [3]int
+	for _, e := range s.events {
+		if e.at.After(now) {
+			continue
+		}
+		if e.at.After(split) {
+			current[index(e.decision)]++
+		} else {
+			baseline[index(e.decision)]++
+		}
+	}
+
+	st := Status{
+		Strategy: k.strategy,
+		Symbol:   k.symbol,
+		Current:  distribution(current),
+		Baseline: distribution(baseline),
+	}
+	if !s.alerted.IsZero() {
+		alerted := s.alerted
+		st.LastAlert = &alerted
+	}
+	if st.Current.Count == 0 || st.Baseline.Count == 0 {
+		return st
+	}
+	st.Divergence = format.Round(JensenShannon(st.Current, st.Baseline), 3)
+	st.Drifting = st.Current.Count >= m.cfg.MinSamples && st.Baseline.Count >= m.cfg.MinSamples &&
+		m.cfg.Threshold > 0 && st.Divergence >= m.cfg.Threshold
+	return st
+}
+
+// JensenShannon — расхождение Дженсена — Шеннона по основанию 2: 0 для
+// одинаковых распределений, 1 для не пересекающихся.
+func JensenShannon(p, q Distribution) float64 {
+	ps, qs := p.shares(), q.shares()
+	var js float64
+	for i := range ps {
+		mid := (ps[i] + qs[i]) / 2
+		if ps[i] > 0 {
+			js += ps[i] * math.Log2(ps[i]/mid) / 2
+		}
+		if qs[i] > 0 {
+			js += qs[i] * math.Log2(qs[i]/mid) / 2
+		}
+	}
+	return js
+}
+
+func distribution(counts [3]int) Distribution {
+	total := counts[0] + counts[1] + counts[2]
+	d := Distribution{Count: total}
+	if total == 0 {
+		return d
+	}
+	d.Buy = format.Round(float64(counts[0])/float64(total), 3)
+	d.Sell = format.Round(float64(counts[1])/float64(total), 3)
+	d.Hold = format.Round(float64(counts[2])/float64(total), 3)
+	return d
+}
+
+func valid(decision string) bool {
+	return decision == "buy" || decision == "sell" || decision == "hold"
+}
+
+func index(decision string) int {
+	switch decision {
+	case "buy":
+		return 0
+	case "sell":
+		return 1
+	default:
+		return 2
+	}
+}
@@ -0,0 +1,116 @@
+// Package experiment распределяет запросы по вариантам A/B эксперимента и
+// сравнивает результаты вариантов.
+package experiment
+
+import (
+	"fmt"
+	"hash/fnv"
+	"math/rand/v2"
+	"strconv"
+	"strings"
+
+	"github.com/skomaroh1845/crypto_telemetry/decision_service/ai"
+)
+
+// Variant — ветка эксперимента. Пустые Strategy и Prompt означают боевые
+// стратегию и шаблон сервиса.
+type Variant struct {
+	Name     string `json:"name"`
+	Weight   int    `json:"weight"`
+	Strategy string `json:"strategy,omitempty"`
+	Prompt   string `json:"prompt,omitempty"`
+
+	client ai.AIClient
+	prompt *ai.PromptTemplate
+}
+
+// Client возвращает клиента варианта или nil, если вариант использует боевого.
+func (v *Variant) Client() ai.AIClient { return v.client }
+
+// PromptTemplate возвращает шаблон варианта или nil для шаблона по умолчанию.
+func (v *Variant) PromptTemplate() *ai.PromptTemplate { return v.prompt }
+
+type Experiment struct {
+	Name     string     `json:"name"`
+	Variants []*Variant `json:"variants"`
+	total    int
+}
+
+// Parse разбирает варианты вида "control=50,groq=25:groq,v2=25::default_v2":
+// имя=вес[:стратегия[:шаблон]]. Первый вариант считается контрольным.
+// Стратегии создаются через ai.NewClient, шаблоны ищутся в prompts.
+func Parse(name, spec string, prompts map[string]*ai.PromptTemplate) (*Experiment, error) {
+	e := &Experiment{Name: name}
+	seen := map[string]bool{}
+	for _, part := range strings.Split(spec, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		variantName, rest, ok := strings.Cut(part, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid variant %q: expected name=weight[:strategy[:prompt]]", part)
+		}
+		fields := strings.Split(rest, ":")
+		weight, err := strconv.Atoi(strings.TrimSpace(fields[0]))
+		if err != nil || weight < 0 {
+			return nil, fmt.Errorf("invalid weight for variant %s: %q", variantName, fields[0])
+		}
+
+		v := &Variant{Name: strings.TrimSpace(variantName), Weight: weight}
+		if seen[v.Name] {
+			return nil, fmt.Errorf("duplicate variant %s", v.Name)
+		}
+		seen[v.Name] = true
+
+		if len(fields) > 1 && strings.TrimSpace(fields[1]) != "" {
+			v.Strategy = strings.ToLower(strings.TrimSpace(fields[1]))
+			if v.client, err = ai.NewClient(v.Strategy); err != nil {
+				return nil, fmt.Errorf("variant %s: %w", v.Name, err)
+			}
+		}
+		if len(fields) > 2 && strings.TrimSpace(fields[2]) != "" {
+			v.Prompt = strings.TrimSpace(fields[2])
+			if v.prompt = prompts[v.Prompt]; v.prompt == nil {
+				return nil, fmt.Errorf("variant %s: prompt template %q not found", v.Name, v.Prompt)
+			}
+		}
+
+		e.Variants = append(e.Variants, v)
+		e.total += weight
+	}
+
+	if len(e.Variants) < 2 {
+		return nil, fmt.Errorf("experiment %s needs at least two variants", name)
+	}
+	if e.total == 0 {
+		return nil, fmt.Errorf("experiment %s has zero total weight", name)
+	}
+	return e, nil
+}
+
+// Assign выбирает вариант. Для чата выбор детерминирован хэшем имени
+// эксперимента и chat ID, поэтому пользователь всегда попадает в один и тот
+// же вариант. Запросы без чата распределяются случайно.
+func (e *Experiment) Assign(chatID int64) *Variant {
+	var bucket int
+	if chatID != 0 {
+		h := fnv.New64a()
+		_, _ = fmt.Fprintf(h, "%s/%d", e.Name, chatID)
+		bucket = int(h.Sum64() % uint64(e.total))
+	} else {
+		bucket = rand.IntN(e.total)
+	}
+
+	for _, v := range e.Variants {
+		if bucket < v.Weight {
+			return v
+		}
+		bucket -= v.Weight
+	}
+	return e.Variants[len(e.Variants)-1]
+}
+
+func (e *Experiment) Control() *Variant {
+	return e.Variants[0]
+}
@@ -0,0 +1,152 @@
+package experiment
+
+import (
+	"math"
+	"sort"
+
+	"github.com/skomaroh1845/crypto_telemetry/decision_service/outcome"
+)
+
+// z95 — квантиль нормального распределения для 95% доверительного интервала.
+const z95 = 1.959964
+
+// Interval — двусторонний 95% доверительный интервал.
+type Interval struct {
+	Low  float64 `json:"low"`
+	High float64 `json:"high"`
+}
+
+type VariantStats struct {
+	Variant     string   `json:"variant"`
+	Horizon     string   `json:"horizon"`
+	Count       int      `json:"count"`
+	Hits        int      `json:"hits"`
+	HitRate     float64  `json:"hit_rate"`
+	HitRateCI   Interval `json:"hit_rate_ci"`
+	AvgReturn   float64  `json:"avg_return"`
+	AvgReturnCI Interval `json:"avg_return_ci"`
+	// разница с контрольным вариантом на том же горизонте
+	HitRateDiff     *float64  `json:"hit_rate_diff,omitempty"`
+	HitRateDiffCI   *Interval `json:"hit_rate_diff_ci,omitempty"`
+	AvgReturnDiff   *float64  `json:"avg_return_diff,omitempty"`
+	AvgReturnDiffCI *Interval `json:"avg_return_diff_ci,omitempty"`
+}
+
+type Report struct {
+	Experiment string         `json:"experiment"`
+	Control    string         `json:"control"`
+	Confidence float64        `json:"confidence"`
+	Variants   []VariantStats `json:"variants"`
+}
+
+type sample struct {
+	hits    int
+	returns []float64
+}
+
+func (s sample) n() int { return len(s.returns) }
+
+func (s sample) hitRate() float64 { return float64(s.hits) / float64(s.n()) }
+
+func (s sample) mean() float64 {
+	var sum float64
+	for _, r := range s.returns {
+		sum += r
+	}
+	return sum / float64(s.n())
+}
+
+func (s sample) variance() float64 {
+	if s.n() < 2 {
+		return 0
+	}
+	m := s.mean()
+	var sum float64
+	for _, r := range s.returns {
+		sum += (r - m) * (r - m)
+	}
+	return sum / float64(s.n()-1)
+}
+
+// Compare считает по каждому варианту и горизонту hit-rate с интервалом
+// Уилсона, среднюю доходность с нормальным интервалом и разницу с контролем.
+func Compare(e *Experiment, outcomes []outcome.Outcome) Report {
+	type key struct{ variant, horizon string }
+	samples := map[key]*sample{}
+	for _, o := range outcomes {
+		if o.Experiment != e.Name || o.Variant == "" {
+			continue
+		}
+		k := key{o.Variant, o.Horizon}
+		s := samples[k]
+		if s == nil {
+			s = &sample{}
+			samples[k] = s
+		}
+		if o.Hit {
+			s.hits++
+		}
+		s.returns = append(s.returns, o.Return)
+	}
+
+	order := map[string]int{}
+	for i, v := range e.Variants {
+		order[v.Name] = i
+	}
+	keys := make([]key, 0, len(samples))
+	for k := range samples {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].horizon != keys[j].horizon {
+			return keys[i].horizon < keys[j].horizon
+		}
+		return order[keys[i].variant] < order[keys[j].variant]
+	})
+
+	report := Report{Experiment: e.Name, Control: e.Control().Name, Confidence: 0.95, Variants: []VariantStats{}}
+	for _, k := range keys {
+		s := samples[k]
+		stats := VariantStats{
+			Variant:     k.variant,
+			Horizon:     k.horizon,
+			Count:       s.n(),
+			Hits:        s.hits,
+			HitRate:     s.hitRate(),
+			HitRateCI:   wilson(s.hits, s.n()),
+			AvgReturn:   s.mean(),
+			AvgReturnCI: around(s.mean(), math.Sqrt(s.variance()/float64(s.n()))),
+		}
+
+		if control := samples[key{report.Control, k.horizon}]; control != nil && k.variant != report.Control {
+			p1, p2 := control.hitRate(), s.hitRate()
+			hitDiff := p2 - p1
+			hitSE := math.Sqrt(p1*(1-p1)/float64(control.n()) + p2*(1-p2)/float64(s.n()))
+			retDiff := s.mean() - control.mean()
+			retSE := math.Sqrt(control.variance()/float64(control.n()) + s.variance()/float64(s.n()))
+
+			hitCI, retCI := around(hitDiff, hitSE), around(retDiff, retSE)
+			stats.HitRateDiff, stats.HitRateDiffCI = &hitDiff, &hitCI
+			stats.AvgReturnDiff, stats.AvgReturnDiffCI = &retDiff, &retCI
+		}
+		report.Variants = append(report.Variants, stats)
+	}
+	return report
+}
+
+// wilson — интервал Уилсона для доли, устойчив при малых выборках и долях около 0 или 1.
+func wilson(hits, n int) Interval {
+	if n == 0 {
+		return Interval{}
+	}
+	p := float64(hits) / float64(n)
+	z2 := z95 * z95
+	denom := 1 + z2/float64(n)
+	center := (p + z2/(2*float64(n))) / denom
+	margin := z95 * math.Sqrt(p*(1-p)/float64(n)+z2/(4*float64(n)*float64(n))) / denom
+	return Interval{Low: center - margin, High: center + margin}
+}
+
+func around(value, se float64) Interval {
+	return Interval{Low: value - z95*se, High: value + z95*se}
+}
@@ -0,0 +1,224 @@
+// Package explain превращает входы решения, сработавшее правило и индикаторы
+// в короткое объяснение на языке пользователя. Текст строится по шаблонам
+// locales/<lang>.tmpl и при настроенной модели может быть переформулирован
+// ею; при ошибке модели остаётся шаблонный текст.
+package explain
+
+import (
+	"context"
+	"embed"
+	"fmt"
+	"math"
+	"path"
+	"sort"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/skomaroh1845/crypto_telemetry/decision_service/ai"
+	"github.com/skomaroh1845/crypto_telemetry/decision_service/regime"
+	"github.com/skomaroh1845/crypto_telemetry/decision_service/risk"
+	"github.com/skomaroh1845/crypto_telemetry/decision_service/timeframe"
+)
+
+//go:embed locales/*.tmpl
+var locales embed.FS
+
+// languageNames — как назвать язык модели, которая переформулирует текст.
+var languageNames = map[string]string{
+	"en": "English",
+	"ru": "Russian",
+}
+
+// ChatModel — модель для переформулировки, например ai.ToolChatClient.
+type ChatModel interface {
+	Chat(ctx context.Context, messages []ai.ChatMessage, tools []ai.ToolSpec) (ai.ChatMessage, error)
+}
+
+// Input — всё, из чего объясняется решение.
+type Input struct {
+	// Lang — язык объяснения, пусто или неизвестный — язык по умолчанию
+	Lang       string
+	Symbol     string
+	Decision   string
+	Confidence float64
+	// Rule — сработавшее правило, если стратегия на правилах
+	Rule string
+	// Reason — причина от стратегии. Если Written, это текст модели для
+	// человека, и к нему добавляются только пояснения о заменах решения
+	Reason  string
+	Written bool
+	// Plain — только шаблонный текст без переформулировки моделью, например
+	// для ответа из кэша, чтобы повтор не стоил вызова модели
+	Plain     bool
+	Market    ai.MarketData
+	Risk      *risk.Assessment
+	Agreement *timeframe.Agreement
+}
+
+// Explainer строит объяснения на языке запроса или языке по умолчанию.
+type Explainer struct {
+	lang      string
+	templates map[string]*template.Template
+	model     ChatModel
+	timeout   time.Duration
+}
+
+func New(lang string) (*Explainer, error) {
+	e := &Explainer{templates: map[string]*template.Template{}}
+	files, err := locales.ReadDir("locales")
+	if err != nil {
+		return nil, err
+	}
+	for _, f := range files {
+		tmpl, err := template.ParseFS(locales, path.Join("locales", f.Name()))
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse explanation locale %s: %w", f.Name(), err)
+		}
+		e.templates[strings.TrimSuffix(f.Name(), ".tmpl")] = tmpl
+	}
+	if lang == "" {
+		lang = "en"
+	}
+	if _, ok := e.templates[lang]; !ok {
+		return nil, fmt.Errorf("unknown explanation language %q, expected one of %s", lang, strings.Join(e.Languages(), ", "))
+	}
+	e.lang = lang
+	return e, nil
+}
+
+// WithModel включает переформулировку шаблонного текста моделью.
+func (e *Explainer) WithModel(model ChatModel, timeout time.Duration) *Explainer {
+	e.model, e.timeout = model, timeout
+	return e
+}
+
+func (e *Explainer) Languages() []string {
+	langs := make([]string, 0, len(e.templates))
+	for lang := range e.templates {
+		langs = append(langs, lang)
+	}
+	sort.Strings(langs)
+	return langs
+}
+
+// view — данные шаблона, уже разложенные на факты.
+type view struct {
+	Symbol      string
+	Decision    string
+	Confidence  float64 // в процентах
+	Basis       string
+	HasRSI      bool
+	RSI         float64
+	RSIState    string
+	HasSMA      bool
+	AboveSMA    bool
+	SMADistance float64
+	Regime      *regime.Regime
+	Agreement   *timeframe.Agreement
+	Risk        *risk.Assessment
+}
+
+// Explain возвращает объяснение решения. Текст модели сохраняется как есть с
+// пояснениями о заменах решения, остальные решения объясняются шаблоном.
+func (e *Explainer) Explain(ctx context.Context, in Input) string {
+	lang := in.Lang
+	tmpl, ok := e.templates[lang]
+	if !ok {
+		lang, tmpl = e.lang, e.templates[e.lang]
+	}
+
+	v := view{
+		Symbol:    in.Symbol,
+		Decision:  in.Decision,
+		Regime:    in.Market.Regime,
+		Agreement: in.Agreement,
+		Risk:      in.Risk,
+	}
+	if in.Written {
+		notes := render(tmpl, "notes", v)
+		if notes == "" {
+			return in.Reason
+		}
+		if in.Reason == "" {
+			return notes
+		}
+		return strings.TrimRight(in.Reason, " .") + ". " + notes
+	}
+
+	// уверенность относится к исходному решению, после замены на hold она не к месту
+	replaced := (in.Risk != nil && in.Risk.Refused) || (in.Agreement != nil && in.Agreement.Blocked)
+	if !replaced {
+		v.Confidence = math.Round(in.Confidence * 100)
+	}
+	v.Basis = in.Rule
+	if v.Basis == "" {
+		v.Basis = in.Reason
+	}
+	if rsi, ok := in.Market.Indicators["rsi_14"]; ok {
+		v.HasRSI, v.RSI = true, rsi
+		switch {
+		case rsi < 30:
+			v.RSIState = "oversold"
+		case rsi > 70:
+			v.RSIState = "overbought"
+		}
+	}
+	if sma, ok := in.Market.Indicators["sma_20"]; ok && sma > 0 && in.Market.Price > 0 {
+		v.HasSMA = true
+		v.AboveSMA = in.Market.Price >= sma
+		v.SMADistance = math.Abs(in.Market.Price/sma-1) * 100
+	}
+
+	text := render(tmpl, "explanation", v)
+	if e.model == nil || in.Plain || text == "" {
+		return text
+	}
+	if reworded, err := e.reword(ctx, lang, text); err == nil {
+		return reworded
+	}
+	return text
+}
+
+// render исполняет блок шаблона и склеивает непустые строки в абзац.
+func render(tmpl *template.Template, block string, v view) string {
+	var b strings.Builder
+	if err := tmpl.ExecuteTemplate(&b, block, v); err != nil {
+		return ""
+	}
+	var sentences []string
+	for _, line := range strings.Split(b.String(), "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			sentences = append(sentences, line)
+		}
+	}
+	return strings.Join(sentences, " ")
+}
+
+// maxReworded — длиннее ответ модели считается неудачным.
+const maxReworded = 600
+
+func (e *Explainer) reword(ctx context.Context, lang, text string) (string, error) {
+	if e.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, e.timeout)
+		defer cancel()
+	}
+	name := languageNames[lang]
+	if name == "" {
+		name = lang
+	}
+	msg, err := e.model.Chat(ctx, []ai.ChatMessage{
+		{Role: "system", Content: "Rewrite the explanation of a trading decision for a retail user in " + name +
+			". Use one or two short plain sentences, keep the decision and every number unchanged, add nothing else."},
+		{Role: "user", Content: text},
+	}, nil)
+	if err != nil {
+		return "", err
+	}
+	reworded := strings.TrimSpace(msg.Content)
+	if reworded == "" || len(reworded) > maxReworded {
+		return "", fmt.Errorf("unusable rewording of %d bytes", len(reworded))
+	}
+	return reworded, nil
+}
@@ -0,0 +1,28 @@
+// Package format — общие правила представления чисел и длительностей в
+// ответах сервиса, чтобы пакеты не заводили каждый свою копию.
+package format
+
+import (
+	"math"
+	"strings"
+	"time"
+)
+
+// Round округляет значение до digits знаков после запятой.
+func Round(value float64, digits int) float64 {
+	p := math.Pow(10, float64(digits))
+	return math.Round(value*p) / p
+}
+
+// Duration печатает длительность без нулевых хвостов: 6h вместо 6h0m0s,
+// 1h30m вместо 1h30m0s.
+func Duration(d time.Duration) string {
+	s := d.String()
+	if strings.HasSuffix(s, "m0s") {
+		s = strings.TrimSuffix(s, "0s")
+	}
+	if strings.HasSuffix(s, "h0m") {
+		s = strings.TrimSuffix(s, "0m")
+	}
+	return s
+}
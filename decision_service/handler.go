@@ -3,13 +3,31 @@ package main
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"os"
 	"strconv"
+	"sync"
 	"time"
 
+	"github.com/skomaroh1845/crypto_telemetry/decision_service/agent"
 	"github.com/skomaroh1845/crypto_telemetry/decision_service/ai"
+	"github.com/skomaroh1845/crypto_telemetry/decision_service/audit"
+	"github.com/skomaroh1845/crypto_telemetry/decision_service/backtest"
+	"github.com/skomaroh1845/crypto_telemetry/decision_service/cache"
+	"github.com/skomaroh1845/crypto_telemetry/decision_service/drift"
+	"github.com/skomaroh1845/crypto_telemetry/decision_service/experiment"
+	"github.com/skomaroh1845/crypto_telemetry/decision_service/explain"
+	"github.com/skomaroh1845/crypto_telemetry/decision_service/outcome"
+	"github.com/skomaroh1845/crypto_telemetry/decision_service/paper"
+	"github.com/skomaroh1845/crypto_telemetry/decision_service/rebalance"
+	"github.com/skomaroh1845/crypto_telemetry/decision_service/registry"
+	"github.com/skomaroh1845/crypto_telemetry/decision_service/risk"
+	"github.com/skomaroh1845/crypto_telemetry/decision_service/rules"
+	"github.com/skomaroh1845/crypto_telemetry/decision_service/sentiment"
+	"github.com/skomaroh1845/crypto_telemetry/decision_service/shadow"
+	"github.com/skomaroh1845/crypto_telemetry/decision_service/timeframe"
 	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
 	"go.opentelemetry.io/otel"
 	"go.opentelemetry.io/otel/attribute"
@@ -20,7 +38,63 @@ import (
 var tracer = otel.Tracer("decision-service")
 
 type DecisionResponse struct {
-	Decision string `json:"decision"`
+	ai.DecisionResponse
+	PaperTrade *paper.Fill      `json:"paper_trade,omitempty"`
+	Risk       *risk.Assessment `json:"risk,omitempty"`
+	Experiment string           `json:"experiment,omitempty"`
+	Variant    string           `json:"variant,omitempty"`
+	// Timeframes — вердикты по таймфреймам, Agreement — проверка решения по ним
+	Timeframes []timeframe.Frame    `json:"timeframes,omitempty"`
+	Agreement  *timeframe.Agreement `json:"timeframe_agreement,omitempty"`
+	// StrategyVersion — версия из реестра стратегий, принявшая решение
+	StrategyVersion string `json:"strategy_version,omitempty"`
+	// Cached — ответ стратегии взят из кэша или получен вместе с таким же запросом
+	Cached bool `json:"cached,omitempty"`
+}
+
+// Handler держит зависимости обработчиков, которым нужно состояние.
+type Handler struct {
+	client      ai.AIClient
+	strategy    string
+	historyDays int
+	riskProfile risk.Profile
+	ledger      *paper.Ledger
+	audit       *audit.Store
+	outcomes    *outcome.Store
+	rebalance   rebalance.Config
+	// backtestTimeout — дедлайн записи и прогона POST /backtest
+	backtestTimeout time.Duration
+
+	// experiment и metrics необязательны и задаются в main
+	experiment   *experiment.Experiment
+	shadow       *shadow.Runner
+	agent        *agent.Agent
+	rules        *rules.Watcher
+	agentTimeout time.Duration
+	metrics      *Metrics
+
+	timeframes         []timeframe.Timeframe
+	timeframeAgreement int
+	explainer          *explain.Explainer
+	sentiment          *sentiment.Tracker
+	drift              *drift.Monitor
+	registry           *registry.Registry
+	cache              *cache.Cache
+}
+
+func NewHandler(client ai.AIClient, cfg *Config, ledger *paper.Ledger, auditStore *audit.Store, outcomes *outcome.Store) *Handler {
+	return &Handler{
+		client:      client,
+		strategy:    cfg.Strategy,
+		historyDays: cfg.HistoryDays,
+		riskProfile: cfg.RiskProfile,
+		ledger:      ledger,
+		audit:       auditStore,
+		outcomes:    outcomes,
+		rebalance:   cfg.Rebalance,
+
+		backtestTimeout: cfg.BacktestTimeout,
+	}
 }
 
 func healthHandler(w http.ResponseWriter, _ *http.Request) {
@@ -28,7 +102,7 @@ func healthHandler(w http.ResponseWriter, _ *http.Request) {
 	_, _ = w.Write([]byte("OK"))
 }
 
-func decisionHandler(w http.ResponseWriter, r *http.Request) {
+func (h *Handler) decisionHandler(w http.ResponseWriter, r *http.Request) {
 	ctx, span := tracer.Start(r.Context(), "decision-process",
 		trace.WithAttributes(attribute.String("handler", "decision")),
 	)
@@ -37,6 +111,25 @@ func decisionHandler(w http.ResponseWriter, r *http.Request) {
 	symbol := r.URL.Query().Get("symbol")
 	span.SetAttributes(attribute.String("symbol", symbol))
 
+	chatID, err := parseChatID(r)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "Invalid chat_id")
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	profile := h.riskProfile
+	if value := r.URL.Query().Get("risk"); value != "" {
+		if profile, err = risk.ParseProfile(value); err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, "Invalid risk profile")
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+	span.SetAttributes(attribute.String("risk.profile", string(profile)))
+
 	if err := checkDataServiceHealth(ctx); err != nil {
 		span.RecordError(err)
 		span.SetStatus(codes.Error, "Health check failed")
@@ -57,21 +150,251 @@ func decisionHandler(w http.ResponseWriter, r *http.Request) {
 		attribute.Float64("volume", market.Volume),
 	)
 
-	aiClient := ai.NewDaniilFrolovAI()
-	decision, err := aiClient.GetDecision(ctx, market)
+	// свечи нужны только для контекста, без них решение всё равно принимается
+	if candles, err := getRecentCandles(ctx, symbol, h.historyDays); err == nil {
+		market.Candles = candles
+	}
+	market = ai.WithIndicators(market)
+	market = h.withTimeframes(ctx, symbol, market)
+	market = h.withSentiment(ctx, symbol, market)
+
+	resp, status, err := h.decide(ctx, span, decisionInput{
+		market:  market,
+		chatID:  chatID,
+		profile: profile,
+		lang:    r.URL.Query().Get("lang"),
+	})
 	if err != nil {
-		span.RecordError(err)
-		span.SetStatus(codes.Error, "AI decision failed")
-		http.Error(w, "Failed to get AI decision:"+err.Error(), http.StatusInternalServerError)
+		http.Error(w, "Failed to get AI decision:"+err.Error(), status)
 		return
 	}
+	if chatID != 0 {
+		resp.PaperTrade = h.executePaperTrade(ctx, chatID, symbol, resp.Decision, market)
+	}
 
-	span.SetAttributes(attribute.String("final.decision", decision.Decision))
 	span.SetStatus(codes.Ok, "Decision completed successfully")
 
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
-	_ = json.NewEncoder(w).Encode(decision)
+	_ = json.NewEncoder(w).Encode(resp)
+}
+
+// decisionInput — рыночные данные и параметры запроса, из которых
+// принимается решение, независимо от того, откуда взяты данные.
+type decisionInput struct {
+	market  ai.MarketData
+	chatID  int64
+	profile risk.Profile
+	// source попадает в журнал аудита, пусто — данные из data_service
+	source string
+	// client заменяет боевую стратегию, эксперимент в этом случае не применяется
+	client ai.AIClient
+	// lang — язык объяснения решения, пусто — язык сервиса
+	lang string
+}
+
+// decide прогоняет данные через эксперимент, модель и риск-менеджмент,
+// пишет аудит и запускает тени. При ошибке возвращает HTTP-статус для ответа,
+// ошибка уже записана в span.
+func (h *Handler) decide(ctx context.Context, span trace.Span, in decisionInput) (DecisionResponse, int, error) {
+	market := in.market
+
+	client := h.client
+	// активная версия реестра заменяет стратегию из окружения, но не
+	// стратегию, явно выбранную вызывающим
+	var version *registry.Version
+	if active := h.registry.Current(); active != nil && in.client == nil {
+		client = active.Client
+		version = &active.Version
+		if active.Prompt != nil {
+			ctx = ai.ContextWithPrompt(ctx, active.Prompt)
+		}
+	}
+
+	var variant *experiment.Variant
+	if in.client != nil {
+		client = in.client
+	} else if h.experiment != nil {
+		variant = h.experiment.Assign(in.chatID)
+		if c := variant.Client(); c != nil {
+			client = c
+			version = nil
+		}
+		if p := variant.PromptTemplate(); p != nil {
+			ctx = ai.ContextWithPrompt(ctx, p)
+		}
+		span.SetAttributes(
+			attribute.String("experiment.name", h.experiment.Name),
+			attribute.String("experiment.variant", variant.Name),
+		)
+		if h.metrics != nil {
+			h.metrics.RecordAssignment(ctx, h.experiment.Name, variant.Name)
+		}
+	}
+
+	strategyVersion := ""
+	if version != nil {
+		strategyVersion = version.Label()
+		span.SetAttributes(
+			attribute.String("strategy.version", strategyVersion),
+			attribute.String("strategy.version_hash", version.Hash),
+		)
+	} else {
+		span.SetAttributes(attribute.String("strategy.version", "env"))
+	}
+
+	if market.Regime != nil {
+		span.SetAttributes(attribute.String("market.regime", market.Regime.Regime))
+	}
+	if market.Sentiment != nil {
+		span.SetAttributes(
+			attribute.Float64("market.sentiment", market.Sentiment.Score),
+			attribute.Int("market.sentiment_items", market.Sentiment.Items),
+		)
+	}
+
+	// кэшируются только ответы боевой стратегии по рынку из data_service:
+	// явно выбранные стратегии и присланные данные всегда идут в модель
+	started := time.Now()
+	result := cache.Miss
+	var decision ai.DecisionResponse
+	var err error
+	if in.client == nil && in.source == "" {
+		key := h.cache.Key(h.cacheStrategy(version, variant), market)
+		decision, result, err = h.cache.Get(ctx, key, func(ctx context.Context) (ai.DecisionResponse, error) {
+			return client.GetDecision(ctx, market)
+		})
+		span.SetAttributes(
+			attribute.String("cache.key", key.String()),
+			attribute.String("cache.result", string(result)),
+		)
+		if h.metrics != nil {
+			h.metrics.RecordCache(ctx, market.Symbol, result)
+		}
+	} else {
+		decision, err = client.GetDecision(ctx, market)
+	}
+	cached := result != cache.Miss
+	rec := audit.Record{
+		Symbol:          market.Symbol,
+		ChatID:          in.chatID,
+		Source:          in.source,
+		Strategy:        h.strategy,
+		StrategyVersion: strategyVersion,
+		Market:          market,
+		LatencyMs:       float64(time.Since(started).Microseconds()) / 1000,
+		Cached:          cached,
+	}
+	if variant != nil {
+		rec.Experiment = h.experiment.Name
+		rec.Variant = variant.Name
+	}
+	if err != nil {
+		rec.Error = err.Error()
+		h.recordDecision(ctx, rec)
+
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "AI decision failed")
+		status := http.StatusInternalServerError
+		if errors.Is(err, ai.ErrBudgetExceeded) {
+			status = http.StatusServiceUnavailable
+		}
+		return DecisionResponse{}, status, err
+	}
+
+	// тени получают ту же картину рынка и сравниваются с ответом модели до
+	// поправок риск-менеджмента
+	live := decision
+
+	// buy и sell без поддержки таймфреймов становятся hold до риск-менеджмента
+	var agreement *timeframe.Agreement
+	if h.timeframeAgreement > 0 {
+		decision.Decision, agreement = timeframe.Confirm(decision.Decision, market.Timeframes, h.timeframeAgreement)
+	}
+	if agreement != nil {
+		span.SetAttributes(
+			attribute.Int("timeframe.agreed", agreement.Agreed),
+			attribute.Int("timeframe.required", agreement.Required),
+			attribute.Bool("timeframe.blocked", agreement.Blocked),
+		)
+	}
+
+	// риск-менеджмент может заменить покупку на hold в экстремальной волатильности
+	final, assessment := risk.Assess(decision.Decision, decision.Confidence, market, in.profile)
+	decision.Decision = final
+	rec.Risk = &assessment
+	rec.Timeframes = agreement
+
+	// объяснение строится по итоговому решению, с учётом замен на hold
+	if h.explainer != nil {
+		decision.Reason = h.explainer.Explain(ctx, explain.Input{
+			Lang:       in.lang,
+			Symbol:     market.Symbol,
+			Decision:   decision.Decision,
+			Confidence: decision.Confidence,
+			Rule:       decision.Rule,
+			Reason:     decision.Reason,
+			Written:    decision.RawOutput != "",
+			Plain:      cached,
+			Market:     market,
+			Risk:       &assessment,
+			Agreement:  agreement,
+		})
+	}
+
+	if decision.Strategy != "" {
+		rec.Strategy = decision.Strategy
+	}
+	rec.Decision = decision.Decision
+	rec.Confidence = decision.Confidence
+	rec.Reason = decision.Reason
+	rec.Horizon = decision.Horizon
+	rec.Provider = decision.Provider
+	rec.Model = decision.Model
+	rec.PromptVersion = decision.PromptVersion
+	rec.RawOutput = decision.RawOutput
+	decisionID := h.recordDecision(ctx, rec)
+	// повтор из кэша — не новое решение стратегии
+	if !cached {
+		h.shadow.Run(ctx, decisionID, market, live)
+		h.drift.Observe(ctx, rec.Strategy, market.Symbol, live.Decision, time.Now())
+	}
+
+	span.SetAttributes(
+		attribute.String("final.decision", decision.Decision),
+		attribute.String("strategy", rec.Strategy),
+		attribute.String("ai.provider", decision.Provider),
+		attribute.String("prompt.version", decision.PromptVersion),
+		attribute.Float64("ai.confidence", decision.Confidence),
+		attribute.String("ai.horizon", decision.Horizon),
+		attribute.String("risk.volatility_level", string(assessment.Level)),
+		attribute.Bool("risk.refused", assessment.Refused),
+		attribute.Float64("risk.position_size", assessment.PositionSize),
+	)
+
+	return DecisionResponse{
+		DecisionResponse: decision,
+		Risk:             &assessment,
+		Experiment:       rec.Experiment,
+		Variant:          rec.Variant,
+		Timeframes:       market.Timeframes,
+		Agreement:        agreement,
+		StrategyVersion:  strategyVersion,
+		Cached:           cached,
+	}, http.StatusOK, nil
+}
+
+// cacheStrategy — версия стратегии для ключа кэша: версия из реестра или
+// стратегия из окружения, с вариантом эксперимента, если он назначен.
+func (h *Handler) cacheStrategy(version *registry.Version, variant *experiment.Variant) string {
+	strategy := h.strategy
+	if version != nil {
+		strategy = version.Label() + "#" + version.Hash
+	}
+	if variant != nil {
+		strategy += "/" + h.experiment.Name + ":" + variant.Name
+	}
+	return strategy
 }
 
 func checkDataServiceHealth(ctx context.Context) error {
@@ -157,9 +480,12 @@ func getMarketData(ctx context.Context, symbol string) (ai.MarketData, error) {
 	var exchangeResp struct {
 		Status  string `json:"status"`
 		Symbols []struct {
-			Symbol string `json:"symbol"`
-			Last   string `json:"last"`
-			Date   string `json:"date"`
+			Symbol                string `json:"symbol"`
+			Last                  string `json:"last"`
+			Lowest                string `json:"lowest"`
+			Highest               string `json:"highest"`
+			DailyChangePercentage string `json:"daily_change_percentage"`
+			Date                  string `json:"date"`
 		} `json:"symbols"`
 	}
 
@@ -191,10 +517,19 @@ func getMarketData(ctx context.Context, symbol string) (ai.MarketData, error) {
 		return result, fmt.Errorf("failed to parse price: %w", err)
 	}
 
+	// необязательные поля: пустые или битые значения просто остаются нулями
+	high, _ := strconv.ParseFloat(s.Highest, 64)
+	low, _ := strconv.ParseFloat(s.Lowest, 64)
+	change, _ := strconv.ParseFloat(s.DailyChangePercentage, 64)
+
 	result = ai.MarketData{
-		Price:     price,
-		Volume:    0,
-		Timestamp: parseTimestamp(s.Date),
+		Symbol:         symbol,
+		Price:          price,
+		Volume:         0,
+		Timestamp:      parseTimestamp(s.Date),
+		High24h:        high,
+		Low24h:         low,
+		DailyChangePct: change,
 	}
 
 	span.SetAttributes(
@@ -206,6 +541,112 @@ func getMarketData(ctx context.Context, symbol string) (ai.MarketData, error) {
 	return result, nil
 }
 
+// getRecentCandles загружает дневные свечи за последние days дней для
+// контекста промпта и индикаторов.
+func getRecentCandles(ctx context.Context, symbol string, days int) ([]ai.Candle, error) {
+	ctx, span := tracer.Start(ctx, "data-service.get-history",
+		trace.WithAttributes(
+			attribute.String("symbol", symbol),
+			attribute.Int("history.days", days),
+		),
+		trace.WithSpanKind(trace.SpanKindClient),
+	)
+	defer span.End()
+
+	dataServiceURL := os.Getenv("DATA_SERVICE_URL")
+	if dataServiceURL == "" {
+		dataServiceURL = "http://data_service:8080"
+	}
+
+	to := time.Now().UTC()
+	candles, err := backtest.FetchHistory(ctx, dataServiceURL, symbol, to.AddDate(0, 0, -days), to)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "History fetch failed")
+		return nil, err
+	}
+
+	span.SetAttributes(attribute.Int("history.candles", len(candles)))
+	span.SetStatus(codes.Ok, "History OK")
+	return candles, nil
+}
+
+// withTimeframes подгружает свечи настроенных таймфреймов и добавляет их
+// признаки в market. Дневные свечи уже есть в market.Candles. Таймфрейм, свечи
+// которого получить не удалось, просто пропускается.
+func (h *Handler) withTimeframes(ctx context.Context, symbol string, market ai.MarketData) ai.MarketData {
+	if len(h.timeframes) == 0 {
+		return market
+	}
+	candles := make(map[timeframe.Timeframe][]ai.Candle, len(h.timeframes))
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for _, tf := range h.timeframes {
+		if tf == timeframe.D1 {
+			if len(market.Candles) > 0 {
+				candles[tf] = market.Candles
+			}
+			continue
+		}
+		wg.Add(1)
+		go func(tf timeframe.Timeframe) {
+			defer wg.Done()
+			series, err := getTimeframeCandles(ctx, symbol, tf)
+			if err != nil {
+				return
+			}
+			mu.Lock()
+			candles[tf] = series
+			mu.Unlock()
+		}(tf)
+	}
+	wg.Wait()
+	return ai.WithTimeframes(market, candles)
+}
+
+// withSentiment добавляет в market настроение новостей о символе, если
+// ленты новостей настроены и о символе за окно что-то писали.
+func (h *Handler) withSentiment(ctx context.Context, symbol string, market ai.MarketData) ai.MarketData {
+	if h.sentiment == nil || market.Sentiment != nil {
+		return market
+	}
+	if summary, ok := h.sentiment.Summary(ctx, symbol, time.Now()); ok {
+		market.Sentiment = summary
+	}
+	return market
+}
+
+// getTimeframeCandles загружает последние timeframe.Lookback свечей таймфрейма.
+func getTimeframeCandles(ctx context.Context, symbol string, tf timeframe.Timeframe) ([]ai.Candle, error) {
+	ctx, span := tracer.Start(ctx, "data-service.get-history",
+		trace.WithAttributes(
+			attribute.String("symbol", symbol),
+			attribute.String("history.interval", string(tf)),
+		),
+		trace.WithSpanKind(trace.SpanKindClient),
+	)
+	defer span.End()
+
+	dataServiceURL := os.Getenv("DATA_SERVICE_URL")
+	if dataServiceURL == "" {
+		dataServiceURL = "http://data_service:8080"
+	}
+
+	// history принимает даты, поэтому берём с запасом в сутки
+	to := time.Now().UTC()
+	from := to.Add(-time.Duration(timeframe.Lookback+1)*tf.Duration()).AddDate(0, 0, -1)
+	candles, err := backtest.FetchCandles(ctx, dataServiceURL, symbol, string(tf), from, to)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "History fetch failed")
+		return nil, err
+	}
+
+	span.SetAttributes(attribute.Int("history.candles", len(candles)))
+	span.SetStatus(codes.Ok, "History OK")
+	return candles, nil
+}
+
 func parseTimestamp(timestamp string) time.Time {
 	formats := []string{
 		time.RFC3339,
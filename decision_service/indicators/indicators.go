@@ -0,0 +1,141 @@
+// Package indicators считает технические индикаторы по ряду цен.
+// Все функции принимают значения от старых к новым и возвращают false,
+// если данных недостаточно.
+package indicators
+
+import "math"
+
+// SMA — простая скользящая средняя последних n значений.
+func SMA(values []float64, n int) (float64, bool) {
+	if n <= 0 || len(values) < n {
+		return 0, false
+	}
+	var sum float64
+	for _, v := range values[len(values)-n:] {
+		sum += v
+	}
+	return sum / float64(n), true
+}
+
+// EMA — экспоненциальная скользящая средняя, стартует с SMA первых n значений.
+func EMA(values []float64, n int) (float64, bool) {
+	if n <= 0 || len(values) < n {
+		return 0, false
+	}
+	k := 2 / float64(n+1)
+	ema, _ := SMA(values[:n], n)
+	for _, v := range values[n:] {
+		ema = v*k + ema*(1-k)
+	}
+	return ema, true
+}
+
+// RSI — индекс относительной силы Уайлдера за n периодов.
+func RSI(values []float64, n int) (float64, bool) {
+	if n <= 0 || len(values) < n+1 {
+		return 0, false
+	}
+	var gain, loss float64
+	for i := 1; i <= n; i++ {
+		d := values[i] - values[i-1]
+		if d > 0 {
+			gain += d
+		} else {
+			loss -= d
+		}
+	}
+	gain /= float64(n)
+	loss /= float64(n)
+	for i := n + 1; i < len(values); i++ {
+		d := values[i] - values[i-1]
+		g, l := 0.0, 0.0
+		if d > 0 {
+			g = d
+		} else {
+			l = -d
+		}
+		gain = (gain*float64(n-1) + g) / float64(n)
+		loss = (loss*float64(n-1) + l) / float64(n)
+	}
+	if loss == 0 {
+		return 100, true
+	}
+	return 100 - 100/(1+gain/loss), true
+}
+
+// Returns — простые доходности между соседними значениями.
+func Returns(values []float64) []float64 {
+	if len(values) < 2 {
+		return nil
+	}
+	out := make([]float64, 0, len(values)-1)
+	for i := 1; i < len(values); i++ {
+		if values[i-1] == 0 {
+			out = append(out, 0)
+			continue
+		}
+		out = append(out, values[i]/values[i-1]-1)
+	}
+	return out
+}
+
+// Volatility — стандартное отклонение доходностей за последние n периодов.
+func Volatility(values []float64, n int) (float64, bool) {
+	if n < 2 || len(values) < n+1 {
+		return 0, false
+	}
+	return StdDev(Returns(values[len(values)-n-1:])), true
+}
+
+// ATR — средний истинный диапазон за n периодов.
+func ATR(high, low, close []float64, n int) (float64, bool) {
+	if n <= 0 || len(close) < n+1 || len(high) != len(close) || len(low) != len(close) {
+		return 0, false
+	}
+	trs := make([]float64, 0, len(close)-1)
+	for i := 1; i < len(close); i++ {
+		tr := math.Max(high[i]-low[i], math.Max(math.Abs(high[i]-close[i-1]), math.Abs(low[i]-close[i-1])))
+		trs = append(trs, tr)
+	}
+	atr, _ := SMA(trs[:n], n)
+	for _, tr := range trs[n:] {
+		atr = (atr*float64(n-1) + tr) / float64(n)
+	}
+	return atr, true
+}
+
+// Change — изменение в процентах за последние n периодов.
+func Change(values []float64, n int) (float64, bool) {
+	if n <= 0 || len(values) < n+1 {
+		return 0, false
+	}
+	base := values[len(values)-n-1]
+	if base == 0 {
+		return 0, false
+	}
+	return (values[len(values)-1]/base - 1) * 100, true
+}
+
+func Mean(values []float64) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+	var sum float64
+	for _, v := range values {
+		sum += v
+	}
+	return sum / float64(len(values))
+}
+
+// StdDev — выборочное стандартное отклонение.
+func StdDev(values []float64) float64 {
+	if len(values) < 2 {
+		return 0
+	}
+	mean := Mean(values)
+	var sum float64
+	for _, v := range values {
+		sum += (v - mean) * (v - mean)
+	}
+	return math.Sqrt(sum / float64(len(values)-1))
+}
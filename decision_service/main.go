@@ -11,6 +11,21 @@ import (
 
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
+	"github.com/skomaroh1845/crypto_telemetry/decision_service/agent"
+	"github.com/skomaroh1845/crypto_telemetry/decision_service/ai"
+	"github.com/skomaroh1845/crypto_telemetry/decision_service/audit"
+	"github.com/skomaroh1845/crypto_telemetry/decision_service/cache"
+	"github.com/skomaroh1845/crypto_telemetry/decision_service/drift"
+	"github.com/skomaroh1845/crypto_telemetry/decision_service/experiment"
+	"github.com/skomaroh1845/crypto_telemetry/decision_service/explain"
+	"github.com/skomaroh1845/crypto_telemetry/decision_service/outcome"
+	"github.com/skomaroh1845/crypto_telemetry/decision_service/paper"
+	"github.com/skomaroh1845/crypto_telemetry/decision_service/registry"
+	"github.com/skomaroh1845/crypto_telemetry/decision_service/rules"
+	"github.com/skomaroh1845/crypto_telemetry/decision_service/scheduler"
+	"github.com/skomaroh1845/crypto_telemetry/decision_service/sentiment"
+	"github.com/skomaroh1845/crypto_telemetry/decision_service/shadow"
+	"github.com/skomaroh1845/crypto_telemetry/decision_service/timeframe"
 	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
 	"go.opentelemetry.io/otel"
 	"go.opentelemetry.io/otel/exporters/otlp/otlpmetric/otlpmetricgrpc"
@@ -83,6 +98,20 @@ func initMonitor() (func(), error) {
 	}, nil
 }
 
+// newDecisionClient собирает цепочку fallback из FALLBACK_CHAIN или, если она
+// не задана, одиночную стратегию DECISION_STRATEGY.
+func newDecisionClient(cfg *Config, metrics *Metrics) (ai.AIClient, error) {
+	if cfg.FallbackChain == "" {
+		return ai.NewClient(cfg.Strategy)
+	}
+
+	specs, err := ai.ParseChain(cfg.FallbackChain, cfg.LLMTimeout)
+	if err != nil {
+		return nil, err
+	}
+	return ai.NewFallbackChain(specs, cfg.BreakerThreshold, cfg.BreakerCooldown, metrics.RecordFallback)
+}
+
 func main() {
 	// Инициализация OpenTelemetry трейсера
 	shutdown, err := initMonitor()
@@ -98,6 +127,240 @@ func main() {
 		log.Fatalf("Failed to init metrics: %v", err)
 	}
 
+	cfg := LoadConfig()
+
+	// Виртуальные портфели пользователей
+	ledger, err := paper.NewLedger(cfg.PaperStateFile, cfg.Paper)
+	if err != nil {
+		log.Fatalf("Failed to init paper ledger: %v", err)
+	}
+
+	// Журнал всех принятых решений
+	auditStore, err := audit.Open(cfg.AuditLogFile, cfg.AuditRetention)
+	if err != nil {
+		log.Fatalf("Failed to open audit log: %v", err)
+	}
+	defer auditStore.Close()
+	auditCtx, stopAudit := context.WithCancel(context.Background())
+	defer stopAudit()
+	go auditStore.Run(auditCtx, time.Hour)
+
+	// Цены и дневной бюджет LLM, токены и стоимость уходят в метрики
+	prices, err := ai.ParsePrices(cfg.LLMPrices, ai.DefaultPrices())
+	if err != nil {
+		log.Fatalf("Failed to parse LLM prices: %v", err)
+	}
+	budget, err := ai.NewBudget(cfg.LLMDailyBudget, cfg.LLMBudgetFile)
+	if err != nil {
+		log.Fatalf("Failed to init LLM budget: %v", err)
+	}
+	ai.SetGenAIConfig(ai.GenAIConfig{
+		Prices:  prices,
+		Budget:  budget,
+		OnUsage: metrics.RecordLLMCall,
+	})
+
+	client, err := newDecisionClient(cfg, metrics)
+	if err != nil {
+		log.Fatalf("Failed to init decision strategy: %v", err)
+	}
+
+	// Шаблоны промптов для LLM-провайдеров
+	prompts, err := ai.LoadPrompts(cfg.PromptDir)
+	if err != nil {
+		log.Fatalf("Failed to load prompt templates: %v", err)
+	}
+	prompt, ok := prompts[cfg.PromptTemplate]
+	if !ok {
+		log.Fatalf("Prompt template %q not found", cfg.PromptTemplate)
+	}
+	ai.SetDefaultPrompt(prompt)
+	log.Printf("using prompt template %s", prompt.ID())
+
+	// Проверка решений задним числом и точность стратегий
+	horizons, err := outcome.ParseHorizons(cfg.OutcomeHorizons)
+	if err != nil {
+		log.Fatalf("Failed to parse outcome horizons: %v", err)
+	}
+	for _, h := range horizons {
+		if cfg.AuditRetention > 0 && h.Duration >= cfg.AuditRetention {
+			log.Printf("audit retention %s is not longer than outcome horizon %s, such decisions will not be evaluated", cfg.AuditRetention, h.Label)
+		}
+	}
+	outcomes, err := outcome.Open(cfg.OutcomeLogFile)
+	if err != nil {
+		log.Fatalf("Failed to open outcome log: %v", err)
+	}
+	defer outcomes.Close()
+	if err := metrics.RegisterPerformance(func() []outcome.Performance {
+		return outcomes.Performance(outcome.Filter{})
+	}); err != nil {
+		log.Fatalf("Failed to register performance metrics: %v", err)
+	}
+
+	evaluatorCtx, stopEvaluator := context.WithCancel(context.Background())
+	defer stopEvaluator()
+	evaluator := outcome.NewEvaluator(auditStore, outcomes, newMarketPrices(2*cfg.OutcomeInterval), horizons, cfg.OutcomeHoldBand, cfg.OutcomeMaxAttempts, metrics.RecordOutcome)
+	go evaluator.Run(evaluatorCtx, cfg.OutcomeInterval)
+
+	handler := NewHandler(client, cfg, ledger, auditStore, outcomes)
+	handler.metrics = metrics
+	if cfg.ShadowStrategies != "" {
+		specs, err := ai.ParseChain(cfg.ShadowStrategies, cfg.LLMTimeout)
+		if err != nil {
+			log.Fatalf("Failed to parse shadow strategies: %v", err)
+		}
+		runner, err := shadow.NewRunner(specs, cfg.ShadowMaxInFlight, cfg.ShadowLogFile, cfg.ShadowRetention, metrics.RecordShadow)
+		if err != nil {
+			log.Fatalf("Failed to init shadow strategies: %v", err)
+		}
+		defer runner.Close()
+		shadowCtx, stopShadow := context.WithCancel(context.Background())
+		defer stopShadow()
+		go runner.RunRetention(shadowCtx, time.Hour)
+		if err := metrics.RegisterShadowAgreement(func() []shadow.Agreement {
+			return runner.Agreements(time.Time{})
+		}); err != nil {
+			log.Fatalf("Failed to register shadow metrics: %v", err)
+		}
+		handler.shadow = runner
+		log.Printf("running shadow strategies %s", runner.Names())
+	}
+
+	// Версии стратегий из реестра переключаются без перезапуска
+	strategies, err := registry.Open(cfg.RegistryFile, newStrategyBuilder(prompts))
+	if err != nil {
+		log.Fatalf("Failed to open strategy registry: %v", err)
+	}
+	handler.registry = strategies
+	if active := strategies.Current(); active != nil {
+		log.Printf("using strategy version %s (%s)", active.Version.Label(), active.Version.Strategy)
+	}
+
+	// Одинаковые вопросы в пределах окна получают один ответ стратегии
+	decisions := cache.New(cfg.CacheTTL, cfg.CachePriceStep, cfg.CacheFetchTimeout)
+	if err := metrics.RegisterCache(decisions.Len); err != nil {
+		log.Fatalf("Failed to register cache metrics: %v", err)
+	}
+	handler.cache = decisions
+
+	// Сдвиг распределения решений по стратегиям и символам, оповещения в админский чат
+	alerts := scheduler.NewNotifierPublisher(cfg.NotifierURL, 10*time.Second)
+	monitor := drift.New(cfg.Drift, func(ctx context.Context, alert drift.Alert) {
+		metrics.RecordDriftAlert(ctx, alert)
+		log.Printf("decision drift for %s on %s: divergence %.3f", alert.Strategy, alert.Symbol, alert.Divergence)
+		if err := alerts.Send(ctx, alert); err != nil {
+			log.Printf("failed to send drift alert: %v", err)
+		}
+	})
+	monitor.Seed(auditStore.Query(audit.Filter{Since: time.Now().Add(-cfg.Drift.Window - cfg.Drift.Baseline)}).Records)
+	if err := metrics.RegisterDrift(func() []drift.Status {
+		return monitor.Statuses(time.Now())
+	}); err != nil {
+		log.Fatalf("Failed to register drift metrics: %v", err)
+	}
+	handler.drift = monitor
+
+	if cfg.ExperimentVariants != "" {
+		exp, err := experiment.Parse(cfg.ExperimentName, cfg.ExperimentVariants, prompts)
+		if err != nil {
+			log.Fatalf("Failed to init experiment: %v", err)
+		}
+		handler.experiment = exp
+		log.Printf("running experiment %s with %d variants", exp.Name, len(exp.Variants))
+	}
+
+	// Свечи младших таймфреймов подгружаются к каждому решению
+	if cfg.Timeframes != "" {
+		frames, err := timeframe.Parse(cfg.Timeframes)
+		if err != nil {
+			log.Fatalf("Failed to parse timeframes: %v", err)
+		}
+		if cfg.TimeframeAgreement > len(frames) {
+			log.Fatalf("TIMEFRAME_AGREEMENT %d exceeds the %d configured timeframes", cfg.TimeframeAgreement, len(frames))
+		}
+		handler.timeframes = frames
+		handler.timeframeAgreement = cfg.TimeframeAgreement
+		log.Printf("multi-timeframe context %v, agreement %d", frames, cfg.TimeframeAgreement)
+	}
+
+	// Объяснения решений строятся по шаблонам и при желании переписываются моделью
+	explainer, err := explain.New(cfg.ExplainLanguage)
+	if err != nil {
+		log.Fatalf("Failed to init explainer: %v", err)
+	}
+	if cfg.ExplainProvider != "" {
+		model, err := ai.NewToolChatClient(cfg.ExplainProvider, cfg.ExplainModel)
+		if err != nil {
+			log.Fatalf("Failed to init explanation model: %v", err)
+		}
+		explainer.WithModel(model, cfg.ExplainTimeout)
+		log.Printf("rewording explanations with %s/%s", model.Provider(), model.Model())
+	}
+	handler.explainer = explainer
+
+	// Настроение новостей из лент добавляется в контекст решений
+	if cfg.SentimentFeeds != "" {
+		var scorer sentiment.Scorer = sentiment.Lexicon{}
+		if cfg.SentimentProvider != "" {
+			model, err := ai.NewToolChatClient(cfg.SentimentProvider, cfg.SentimentModel)
+			if err != nil {
+				log.Fatalf("Failed to init sentiment model: %v", err)
+			}
+			scorer = ai.NewSentimentScorer(model, sentiment.Lexicon{}, cfg.SentimentTimeout)
+			log.Printf("scoring news with %s/%s", model.Provider(), model.Model())
+		}
+		sources := sentiment.ParseFeeds(cfg.SentimentFeeds, 15*time.Second)
+		tracker := sentiment.NewTracker(sources, scorer, cfg.SentimentWindow)
+		sentimentCtx, stopSentiment := context.WithCancel(context.Background())
+		defer stopSentiment()
+		go tracker.Run(sentimentCtx, cfg.SentimentInterval)
+		handler.sentiment = tracker
+		log.Printf("tracking news sentiment from %d feeds over %s", len(sources), cfg.SentimentWindow)
+	}
+
+	// Правила из файла перечитываются на лету, dry-run показывает их работу
+	if cfg.RulesFile != "" {
+		watcher, err := rules.Open(cfg.RulesFile, cfg.RulesParamsFile, rules.DefaultReloadInterval)
+		if err != nil {
+			log.Fatalf("Failed to load rules: %v", err)
+		}
+		handler.rules = watcher
+		log.Printf("loaded rules %s version %s", cfg.RulesFile, watcher.Status().Version)
+	}
+
+	// Торговый агент сам запрашивает данные через вызовы функций
+	if cfg.AgentProvider != "" {
+		model, err := ai.NewToolChatClient(cfg.AgentProvider, cfg.AgentModel)
+		if err != nil {
+			log.Fatalf("Failed to init trading agent: %v", err)
+		}
+		handler.agent = agent.New(model, agent.MarketTools(dataServiceSource{}), cfg.AgentMaxSteps)
+		handler.agentTimeout = cfg.AgentTimeout
+		log.Printf("trading agent on %s/%s with %d steps", model.Provider(), model.Model(), cfg.AgentMaxSteps)
+	}
+
+	// Планировщик сигналов рассылает смены решений подписчикам через notifier
+	if cfg.SignalJobs != "" {
+		jobs, err := scheduler.ParseJobs(cfg.SignalJobs)
+		if err != nil {
+			log.Fatalf("Failed to parse signal jobs: %v", err)
+		}
+		decider, err := handler.scheduledDecider(jobs)
+		if err != nil {
+			log.Fatalf("Failed to init signal strategies: %v", err)
+		}
+		publisher := scheduler.NewNotifierPublisher(cfg.NotifierURL, 10*time.Second)
+		signals, err := scheduler.New(jobs, decider, publisher, cfg.SignalStateFile, metrics.RecordSignalChange)
+		if err != nil {
+			log.Fatalf("Failed to init signal scheduler: %v", err)
+		}
+		schedulerCtx, stopScheduler := context.WithCancel(context.Background())
+		defer stopScheduler()
+		go signals.Run(schedulerCtx)
+		log.Printf("running %d signal jobs, publishing to %s", len(jobs), cfg.NotifierURL)
+	}
+
 	// Настройка сервера
 	logger := log.New(os.Stdout, "decision-service: ", log.LstdFlags|log.Lshortfile)
 	r := chi.NewRouter()
@@ -110,7 +373,23 @@ func main() {
 	r.Use(middleware.Timeout(60 * time.Second))
 
 	r.Get("/health", healthHandler)
-	r.Post("/decision", decisionHandler)
+	r.Post("/decision", handler.decisionHandler)
+	r.Post("/v1/decide", handler.decideHandler)
+	r.Post("/v1/rebalance", handler.rebalanceHandler)
+	r.Post("/v1/agent/decision", handler.agentDecisionHandler)
+	r.Post("/v1/rules/dry-run", handler.rulesDryRunHandler)
+	r.Get("/portfolio", handler.portfolioHandler)
+	r.Get("/pnl", handler.pnlHandler)
+	r.Get("/decisions", handler.decisionsHandler)
+	r.Get("/strategies", handler.strategiesHandler)
+	r.Post("/strategies", handler.strategiesUpdateHandler)
+	r.Get("/strategies/performance", handler.performanceHandler)
+	r.Get("/experiments", handler.experimentHandler)
+	r.Get("/shadow", handler.shadowHandler)
+	r.Get("/drift", handler.driftHandler)
+	r.Get("/regime", handler.regimeHandler)
+	r.Get("/sentiment", handler.sentimentHandler)
+	r.Post("/backtest", handler.backtestHandler)
 
 	srv := &http.Server{
 		Addr: ":8081",
@@ -3,6 +3,13 @@ package main
 import (
 	"context"
 
+	"github.com/skomaroh1845/crypto_telemetry/decision_service/ai"
+	"github.com/skomaroh1845/crypto_telemetry/decision_service/cache"
+	"github.com/skomaroh1845/crypto_telemetry/decision_service/drift"
+	"github.com/skomaroh1845/crypto_telemetry/decision_service/outcome"
+	"github.com/skomaroh1845/crypto_telemetry/decision_service/scheduler"
+	"github.com/skomaroh1845/crypto_telemetry/decision_service/shadow"
+
 	"go.opentelemetry.io/otel"
 	"go.opentelemetry.io/otel/attribute"
 	"go.opentelemetry.io/otel/metric"
@@ -16,6 +23,17 @@ type Metrics struct {
 	requestCount      metric.Int64Counter
 	requestErrorCount metric.Int64Counter
 	requestDuration   metric.Float64Histogram
+	fallbackCount     metric.Int64Counter
+	llmTokens         metric.Int64Counter
+	llmCost           metric.Float64Counter
+	llmDuration       metric.Float64Histogram
+	outcomeCount      metric.Int64Counter
+	assignmentCount   metric.Int64Counter
+	shadowCount       metric.Int64Counter
+	shadowLatency     metric.Float64Histogram
+	signalChangeCount metric.Int64Counter
+	driftAlertCount   metric.Int64Counter
+	cacheCount        metric.Int64Counter
 }
 
 func NewMetrics() (*Metrics, error) {
@@ -49,10 +67,124 @@ func NewMetrics() (*Metrics, error) {
 		return nil, err
 	}
 
+	// Счетчик переходов к следующему провайдеру в цепочке fallback
+	fallbackCount, err := meter.Int64Counter(
+		serviceName+"_llm_fallback_total",
+		metric.WithDescription("Total number of fallbacks from an LLM provider to the next one in the chain"),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	// Счетчик токенов LLM по провайдеру, модели и типу (input/output)
+	llmTokens, err := meter.Int64Counter(
+		serviceName+"_llm_tokens_total",
+		metric.WithDescription("Total number of LLM tokens used"),
+		metric.WithUnit("{token}"),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	// Оценка расходов на LLM по таблице цен
+	llmCost, err := meter.Float64Counter(
+		serviceName+"_llm_cost_usd_total",
+		metric.WithDescription("Estimated LLM spend in USD"),
+		metric.WithUnit("USD"),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	// Гистограмма времени ответа LLM
+	llmDuration, err := meter.Float64Histogram(
+		serviceName+"_llm_request_duration_sec",
+		metric.WithDescription("LLM chat completion duration in seconds"),
+		metric.WithUnit("s"),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	// Счетчик проверенных решений по стратегии, символу, горизонту и попаданию
+	outcomeCount, err := meter.Int64Counter(
+		serviceName+"_decision_outcomes_total",
+		metric.WithDescription("Total number of evaluated decision outcomes"),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	// Счетчик распределения запросов по вариантам A/B эксперимента
+	assignmentCount, err := meter.Int64Counter(
+		serviceName+"_experiment_assignments_total",
+		metric.WithDescription("Total number of requests assigned to experiment variants"),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	// Счетчик ответов теневых стратегий по совпадению с боевой
+	shadowCount, err := meter.Int64Counter(
+		serviceName+"_shadow_decisions_total",
+		metric.WithDescription("Total number of shadow strategy decisions"),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	// Гистограмма времени ответа теневых стратегий
+	shadowLatency, err := meter.Float64Histogram(
+		serviceName+"_shadow_duration_sec",
+		metric.WithDescription("Shadow strategy decision duration in seconds"),
+		metric.WithUnit("s"),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	// Счетчик смен сигнала, найденных планировщиком
+	signalChangeCount, err := meter.Int64Counter(
+		serviceName+"_signal_changes_total",
+		metric.WithDescription("Total number of scheduled signal changes"),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	// Счетчик оповещений о сдвиге распределения решений
+	driftAlertCount, err := meter.Int64Counter(
+		serviceName+"_decision_drift_alerts_total",
+		metric.WithDescription("Total number of decision drift alerts"),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	// Счетчик обращений к кэшу решений по результату: hit, miss или coalesced
+	cacheCount, err := meter.Int64Counter(
+		serviceName+"_decision_cache_requests_total",
+		metric.WithDescription("Total number of decision cache lookups"),
+	)
+	if err != nil {
+		return nil, err
+	}
+
 	return &Metrics{
 		requestCount:      requestCount,
 		requestErrorCount: requestErrorCount,
 		requestDuration:   requestDuration,
+		fallbackCount:     fallbackCount,
+		llmTokens:         llmTokens,
+		llmCost:           llmCost,
+		llmDuration:       llmDuration,
+		outcomeCount:      outcomeCount,
+		assignmentCount:   assignmentCount,
+		shadowCount:       shadowCount,
+		shadowLatency:     shadowLatency,
+		signalChangeCount: signalChangeCount,
+		driftAlertCount:   driftAlertCount,
+		cacheCount:        cacheCount,
 	}, nil
 }
 
@@ -72,3 +204,202 @@ func (m *Metrics) RecordRequest(ctx context.Context, route string, duration floa
 	// Записываем время выполнения
 	m.requestDuration.Record(ctx, duration, metric.WithAttributes(attrs...))
 }
+
+func (m *Metrics) RecordFallback(ctx context.Context, provider, reason string) {
+	m.fallbackCount.Add(ctx, 1, metric.WithAttributes(
+		attribute.String("provider", provider),
+		attribute.String("reason", reason),
+	))
+}
+
+func (m *Metrics) RecordLLMCall(ctx context.Context, call ai.LLMCall) {
+	attrs := []attribute.KeyValue{
+		attribute.String("provider", call.Provider),
+		attribute.String("model", call.Model),
+	}
+
+	m.llmTokens.Add(ctx, int64(call.Usage.PromptTokens), metric.WithAttributes(append(attrs, attribute.String("type", "input"))...))
+	m.llmTokens.Add(ctx, int64(call.Usage.CompletionTokens), metric.WithAttributes(append(attrs, attribute.String("type", "output"))...))
+	m.llmCost.Add(ctx, call.Cost, metric.WithAttributes(attrs...))
+
+	status := "ok"
+	if call.Err != nil {
+		status = "error"
+	}
+	m.llmDuration.Record(ctx, call.Duration.Seconds(), metric.WithAttributes(append(attrs, attribute.String("status", status))...))
+}
+
+func (m *Metrics) RecordOutcome(ctx context.Context, o outcome.Outcome) {
+	m.outcomeCount.Add(ctx, 1, metric.WithAttributes(
+		attribute.String("strategy", o.Strategy),
+		attribute.String("symbol", o.Symbol),
+		attribute.String("horizon", o.Horizon),
+		attribute.String("experiment", o.Experiment),
+		attribute.String("variant", o.Variant),
+		attribute.Bool("hit", o.Hit),
+	))
+}
+
+func (m *Metrics) RecordAssignment(ctx context.Context, experiment, variant string) {
+	m.assignmentCount.Add(ctx, 1, metric.WithAttributes(
+		attribute.String("experiment", experiment),
+		attribute.String("variant", variant),
+	))
+}
+
+// RegisterPerformance экспортирует hit-rate и среднюю доходность стратегий
+// как gauge, значения считаются при каждом сборе метрик.
+func (m *Metrics) RegisterPerformance(performance func() []outcome.Performance) error {
+	meter := otel.Meter(serviceName)
+
+	hitRate, err := meter.Float64ObservableGauge(
+		serviceName+"_strategy_hit_rate",
+		metric.WithDescription("Share of decisions that matched the subsequent price move"),
+	)
+	if err != nil {
+		return err
+	}
+	avgReturn, err := meter.Float64ObservableGauge(
+		serviceName+"_strategy_avg_return",
+		metric.WithDescription("Average return of following the strategy decisions"),
+	)
+	if err != nil {
+		return err
+	}
+
+	_, err = meter.RegisterCallback(func(_ context.Context, o metric.Observer) error {
+		for _, p := range performance() {
+			attrs := metric.WithAttributes(
+				attribute.String("strategy", p.Strategy),
+				attribute.String("symbol", p.Symbol),
+				attribute.String("horizon", p.Horizon),
+			)
+			o.ObserveFloat64(hitRate, p.HitRate, attrs)
+			o.ObserveFloat64(avgReturn, p.AvgReturn, attrs)
+		}
+		return nil
+	}, hitRate, avgReturn)
+	return err
+}
+
+func (m *Metrics) RecordShadow(ctx context.Context, rec shadow.Record) {
+	status := "ok"
+	switch {
+	case rec.Dropped:
+		status = "dropped"
+	case rec.Error != "":
+		status = "error"
+	}
+	attrs := []attribute.KeyValue{
+		attribute.String("shadow", rec.Shadow),
+		attribute.String("live_strategy", rec.LiveStrategy),
+		attribute.String("status", status),
+	}
+
+	m.shadowCount.Add(ctx, 1, metric.WithAttributes(append(attrs, attribute.Bool("agree", rec.Agree))...))
+	if !rec.Dropped {
+		m.shadowLatency.Record(ctx, rec.LatencyMs/1000, metric.WithAttributes(attrs...))
+	}
+}
+
+func (m *Metrics) RecordSignalChange(ctx context.Context, event scheduler.Event) {
+	m.signalChangeCount.Add(ctx, 1, metric.WithAttributes(
+		attribute.String("symbol", event.Symbol),
+		attribute.String("strategy", event.Strategy),
+		attribute.String("from", event.Previous),
+		attribute.String("to", event.Decision),
+	))
+}
+
+// RegisterShadowAgreement экспортирует долю совпадений теней с боевой стратегией.
+func (m *Metrics) RegisterShadowAgreement(agreements func() []shadow.Agreement) error {
+	meter := otel.Meter(serviceName)
+
+	rate, err := meter.Float64ObservableGauge(
+		serviceName+"_shadow_agreement_rate",
+		metric.WithDescription("Share of shadow decisions matching the live decision"),
+	)
+	if err != nil {
+		return err
+	}
+
+	_, err = meter.RegisterCallback(func(_ context.Context, o metric.Observer) error {
+		for _, a := range agreements() {
+			o.ObserveFloat64(rate, a.Rate, metric.WithAttributes(attribute.String("shadow", a.Shadow)))
+		}
+		return nil
+	}, rate)
+	return err
+}
+
+func (m *Metrics) RecordDriftAlert(ctx context.Context, alert drift.Alert) {
+	m.driftAlertCount.Add(ctx, 1, metric.WithAttributes(
+		attribute.String("strategy", alert.Strategy),
+		attribute.String("symbol", alert.Symbol),
+	))
+}
+
+// RegisterDrift экспортирует расхождение текущего распределения решений с
+// базовым и доли решений в текущем окне.
+func (m *Metrics) RegisterDrift(statuses func() []drift.Status) error {
+	meter := otel.Meter(serviceName)
+
+	divergence, err := meter.Float64ObservableGauge(
+		serviceName+"_decision_drift_divergence",
+		metric.WithDescription("Jensen-Shannon divergence of recent decisions from the baseline window"),
+	)
+	if err != nil {
+		return err
+	}
+	share, err := meter.Float64ObservableGauge(
+		serviceName+"_decision_share",
+		metric.WithDescription("Share of buy, sell and hold decisions in the recent window"),
+	)
+	if err != nil {
+		return err
+	}
+
+	_, err = meter.RegisterCallback(func(_ context.Context, o metric.Observer) error {
+		for _, s := range statuses() {
+			attrs := []attribute.KeyValue{
+				attribute.String("strategy", s.Strategy),
+				attribute.String("symbol", s.Symbol),
+			}
+			o.ObserveFloat64(divergence, s.Divergence, metric.WithAttributes(attrs...))
+			if s.Current.Count == 0 {
+				continue
+			}
+			for decision, v := range map[string]float64{"buy": s.Current.Buy, "sell": s.Current.Sell, "hold": s.Current.Hold} {
+				o.ObserveFloat64(share, v, metric.WithAttributes(append(attrs, attribute.String("decision", decision))...))
+			}
+		}
+		return nil
+	}, divergence, share)
+	return err
+}
+
+func (m *Metrics) RecordCache(ctx context.Context, symbol string, result cache.Result) {
+	m.cacheCount.Add(ctx, 1, metric.WithAttributes(
+		attribute.String("symbol", symbol),
+		attribute.String("result", string(result)),
+	))
+}
+
+// RegisterCache экспортирует число ответов в кэше решений.
+func (m *Metrics) RegisterCache(size func() int) error {
+	meter := otel.Meter(serviceName)
+
+	entries, err := meter.Int64ObservableGauge(
+		serviceName+"_decision_cache_entries",
+		metric.WithDescription("Number of cached strategy decisions"),
+	)
+	if err != nil {
+		return err
+	}
+
+	_, err = meter.RegisterCallback(func(_ context.Context, o metric.Observer) error {
+		o.ObserveInt64(entries, int64(size()))
+		return nil
+	}, entries)
+	return err
+}
@@ -36,3 +36,9 @@ func MetricsMiddleware(m *Metrics) func(http.Handler) http.Handler {
 		})
 	}
 }
+
+// Unwrap даёт http.ResponseController добраться до исходного writer,
+// например чтобы продлить дедлайн записи для долгих запросов.
+func (w *statusWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
@@ -0,0 +1,110 @@
+// Package ml — простые модели направления цены на чистом Go: логистическая
+// регрессия и градиентный бустинг на пнях поверх признаков из свечей.
+package ml
+
+import (
+	"github.com/skomaroh1845/crypto_telemetry/decision_service/indicators"
+)
+
+// Series — ряды свечей от старых к новым. Последнее значение Close — текущая
+// цена, для которой считаются признаки. Volume — объёмы уже закрытых свечей:
+// объём текущей ещё неполный, поэтому ряд может быть на одно значение короче.
+type Series struct {
+	Close  []float64
+	High   []float64
+	Low    []float64
+	Volume []float64
+}
+
+// MinHistory — сколько значений нужно для всех признаков.
+const MinHistory = 27
+
+// FeatureNames — имена признаков в порядке, в котором их возвращает Features.
+var FeatureNames = []string{
+	"return_1",
+	"return_3",
+	"return_7",
+	"sma_20_gap",
+	"ema_12_26_gap",
+	"rsi_14",
+	"volatility_20",
+	"atr_14_pct",
+	"range_pct",
+	"volume_ratio_20",
+}
+
+// Features считает признаки на последнем значении ряда. Все признаки
+// безразмерные, чтобы одна модель подходила к разным уровням цены.
+func Features(s Series) ([]float64, bool) {
+	n := len(s.Close)
+	if n < MinHistory || len(s.High) != n || len(s.Low) != n {
+		return nil, false
+	}
+	price := s.Close[n-1]
+	if price <= 0 {
+		return nil, false
+	}
+
+	r1, _ := indicators.Change(s.Close, 1)
+	r3, _ := indicators.Change(s.Close, 3)
+	r7, _ := indicators.Change(s.Close, 7)
+	sma20, _ := indicators.SMA(s.Close, 20)
+	ema12, _ := indicators.EMA(s.Close, 12)
+	ema26, _ := indicators.EMA(s.Close, 26)
+	rsi, _ := indicators.RSI(s.Close, 14)
+	vol, _ := indicators.Volatility(s.Close, 20)
+	atr, _ := indicators.ATR(s.High, s.Low, s.Close, 14)
+	if sma20 == 0 || ema26 == 0 {
+		return nil, false
+	}
+
+	volumeRatio := 1.0
+	if len(s.Volume) >= 21 {
+		vs := s.Volume[len(s.Volume)-21:]
+		if mean := indicators.Mean(vs[:20]); mean > 0 {
+			volumeRatio = vs[20] / mean
+		}
+	}
+
+	return []float64{
+		r1 / 100,
+		r3 / 100,
+		r7 / 100,
+		price/sma20 - 1,
+		ema12/ema26 - 1,
+		rsi/100 - 0.5,
+		vol,
+		atr / price,
+		(s.High[n-1] - s.Low[n-1]) / price,
+		volumeRatio - 1,
+	}, true
+}
+
+// Dataset строит обучающую выборку: признаки на каждой свече и метка 1, если
+// через horizon свечей закрытие выше текущего.
+func Dataset(s Series, horizon int) (x [][]float64, y []float64) {
+	if horizon <= 0 {
+		horizon = 1
+	}
+	for i := MinHistory - 1; i+horizon < len(s.Close); i++ {
+		window := Series{
+			Close: s.Close[:i+1],
+			High:  s.High[:i+1],
+			Low:   s.Low[:i+1],
+		}
+		if len(s.Volume) == len(s.Close) {
+			window.Volume = s.Volume[:i]
+		}
+		features, ok := Features(window)
+		if !ok {
+			continue
+		}
+		label := 0.0
+		if s.Close[i+horizon] > s.Close[i] {
+			label = 1
+		}
+		x = append(x, features)
+		y = append(y, label)
+	}
+	return x, y
+}
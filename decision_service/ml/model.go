@@ -0,0 +1,190 @@
+package ml
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// FormatVersion — версия формата файла модели. Файлы другой версии не
+// загружаются, чтобы несовместимые признаки не давали тихо неверных прогнозов.
+const FormatVersion = 1
+
+const (
+	KindLogistic = "logreg"
+	KindStumps   = "stumps"
+)
+
+// Stump — пень бустинга: значение Left, если признак Feature <= Threshold,
+// иначе Right.
+type Stump struct {
+	Feature   int     `json:"feature"`
+	Threshold float64 `json:"threshold"`
+	Left      float64 `json:"left"`
+	Right     float64 `json:"right"`
+}
+
+// Calibration — шкалирование Платта сырой оценки модели:
+// P(up) = sigmoid(A*score + B).
+type Calibration struct {
+	A float64 `json:"a"`
+	B float64 `json:"b"`
+}
+
+// Metrics — качество модели на отложенной выборке.
+type Metrics struct {
+	Samples  int     `json:"samples"`
+	Accuracy float64 `json:"accuracy"`
+	LogLoss  float64 `json:"log_loss"`
+	Brier    float64 `json:"brier"`
+	BaseRate float64 `json:"base_rate"` // доля роста в выборке
+}
+
+// Model — обученная модель вместе со всем, что нужно для прогноза.
+type Model struct {
+	Format    int       `json:"format"`
+	Version   string    `json:"version"`
+	Kind      string    `json:"kind"`
+	Symbol    string    `json:"symbol"`
+	Horizon   int       `json:"horizon"` // в свечах
+	TrainedAt time.Time `json:"trained_at"`
+	Features  []string  `json:"features"`
+
+	// стандартизация признаков по обучающей выборке
+	Mean []float64 `json:"mean"`
+	Std  []float64 `json:"std"`
+
+	Bias    float64   `json:"bias"`
+	Weights []float64 `json:"weights,omitempty"` // logreg
+	Stumps  []Stump   `json:"stumps,omitempty"`  // stumps
+	Rate    float64   `json:"rate,omitempty"`    // шаг бустинга
+
+	Calibration Calibration `json:"calibration"`
+	// BuyThreshold — P(up), начиная с которой модель покупает; продаёт при
+	// P(up) <= 1-BuyThreshold, между ними — hold
+	BuyThreshold float64 `json:"buy_threshold"`
+
+	TrainSamples int     `json:"train_samples"`
+	Validation   Metrics `json:"validation"`
+}
+
+// Score — сырая оценка модели до калибровки.
+func (m *Model) Score(features []float64) float64 {
+	x := m.standardize(features)
+	score := m.Bias
+	switch m.Kind {
+	case KindLogistic:
+		for i, w := range m.Weights {
+			score += w * x[i]
+		}
+	case KindStumps:
+		for _, s := range m.Stumps {
+			if x[s.Feature] <= s.Threshold {
+				score += m.Rate * s.Left
+			} else {
+				score += m.Rate * s.Right
+			}
+		}
+	}
+	return score
+}
+
+// Predict возвращает откалиброванную вероятность роста цены.
+func (m *Model) Predict(features []float64) float64 {
+	return sigmoid(m.Calibration.A*m.Score(features) + m.Calibration.B)
+}
+
+func (m *Model) standardize(features []float64) []float64 {
+	x := make([]float64, len(features))
+	for i, v := range features {
+		x[i] = v
+		if i < len(m.Mean) {
+			x[i] = (v - m.Mean[i]) / m.Std[i]
+		}
+	}
+	return x
+}
+
+func (m *Model) validate() error {
+	if m.Format != FormatVersion {
+		return fmt.Errorf("unsupported model format %d, expected %d", m.Format, FormatVersion)
+	}
+	if len(m.Features) != len(FeatureNames) {
+		return fmt.Errorf("model has %d features, expected %d", len(m.Features), len(FeatureNames))
+	}
+	for i, name := range m.Features {
+		if name != FeatureNames[i] {
+			return fmt.Errorf("model feature %d is %q, expected %q", i, name, FeatureNames[i])
+		}
+	}
+	if len(m.Mean) != len(m.Features) || len(m.Std) != len(m.Features) {
+		return errors.New("model standardization does not match features")
+	}
+	switch m.Kind {
+	case KindLogistic:
+		if len(m.Weights) != len(m.Features) {
+			return errors.New("model weights do not match features")
+		}
+	case KindStumps:
+		for _, s := range m.Stumps {
+			if s.Feature < 0 || s.Feature >= len(m.Features) {
+				return fmt.Errorf("stump uses unknown feature %d", s.Feature)
+			}
+		}
+	default:
+		return fmt.Errorf("unknown model kind %q", m.Kind)
+	}
+	if m.BuyThreshold < 0.5 || m.BuyThreshold >= 1 {
+		return fmt.Errorf("buy threshold must be in [0.5, 1), got %v", m.BuyThreshold)
+	}
+	return nil
+}
+
+// Load читает и проверяет файл модели.
+func Load(path string) (*Model, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read model: %w", err)
+	}
+	var m Model
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, fmt.Errorf("failed to parse model %s: %w", path, err)
+	}
+	if err := m.validate(); err != nil {
+		return nil, fmt.Errorf("invalid model %s: %w", path, err)
+	}
+	return &m, nil
+}
+
+// Save пишет модель в dir под именем <version>.json и возвращает путь.
+// Существующий файл не перезаписывается: версия однозначно определяет модель.
+func (m *Model) Save(dir string) (string, error) {
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal model: %w", err)
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return "", fmt.Errorf("failed to create model dir: %w", err)
+	}
+	path := filepath.Join(dir, m.Version+".json")
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o644)
+	if err != nil {
+		return "", fmt.Errorf("failed to create model file: %w", err)
+	}
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return "", fmt.Errorf("failed to write model: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		return "", fmt.Errorf("failed to write model: %w", err)
+	}
+	return path, nil
+}
+
+func sigmoid(z float64) float64 {
+	return 1 / (1 + math.Exp(-z))
+}
@@ -0,0 +1,320 @@
+package ml
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/skomaroh1845/crypto_telemetry/decision_service/indicators"
+)
+
+// TrainConfig задаёт обучение. Выборка делится по времени: последние
+// Validation долей примеров не участвуют в обучении и идут на калибровку и
+// оценку качества.
+type TrainConfig struct {
+	Kind         string
+	Horizon      int
+	Validation   float64
+	BuyThreshold float64
+
+	// logreg
+	Epochs       int
+	LearningRate float64
+	L2           float64
+
+	// stumps
+	Rounds int
+	Rate   float64
+	Bins   int // сколько порогов перебирать по каждому признаку
+}
+
+func DefaultTrainConfig() TrainConfig {
+	return TrainConfig{
+		Kind:         KindLogistic,
+		Horizon:      1,
+		Validation:   0.2,
+		BuyThreshold: 0.55,
+		Epochs:       500,
+		LearningRate: 0.1,
+		L2:           0.01,
+		Rounds:       100,
+		Rate:         0.1,
+		Bins:         16,
+	}
+}
+
+func (c TrainConfig) Validate() error {
+	if c.Kind != KindLogistic && c.Kind != KindStumps {
+		return fmt.Errorf("unknown model kind %q, expected %s or %s", c.Kind, KindLogistic, KindStumps)
+	}
+	if c.Horizon <= 0 {
+		return fmt.Errorf("horizon must be positive")
+	}
+	if c.Validation <= 0 || c.Validation >= 1 {
+		return fmt.Errorf("validation must be in (0, 1)")
+	}
+	if c.BuyThreshold < 0.5 || c.BuyThreshold >= 1 {
+		return fmt.Errorf("buy threshold must be in [0.5, 1)")
+	}
+	if c.Kind == KindLogistic && (c.Epochs <= 0 || c.LearningRate <= 0 || c.L2 < 0) {
+		return fmt.Errorf("epochs and learning rate must be positive, l2 not negative")
+	}
+	if c.Kind == KindStumps && (c.Rounds <= 0 || c.Rate <= 0 || c.Bins < 2) {
+		return fmt.Errorf("rounds and rate must be positive, bins at least 2")
+	}
+	return nil
+}
+
+// minSamples — меньше примеров не хватит ни на обучение, ни на калибровку.
+const minSamples = 50
+
+// Train обучает модель на признаках x с метками y (1 — рост) в порядке времени.
+func Train(symbol string, x [][]float64, y []float64, cfg TrainConfig) (*Model, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	if len(x) != len(y) {
+		return nil, fmt.Errorf("got %d feature rows and %d labels", len(x), len(y))
+	}
+	if len(x) < minSamples {
+		return nil, fmt.Errorf("need at least %d samples, got %d", minSamples, len(x))
+	}
+
+	split := len(x) - int(float64(len(x))*cfg.Validation)
+	if split < minSamples/2 || len(x)-split < minSamples/5 {
+		return nil, fmt.Errorf("not enough samples for a %.0f%% validation split", cfg.Validation*100)
+	}
+
+	now := time.Now().UTC()
+	m := &Model{
+		Format:       FormatVersion,
+		Version:      fmt.Sprintf("%s-%s-h%d-%s", strings.ToLower(symbol), cfg.Kind, cfg.Horizon, now.Format("20060102T150405Z")),
+		Kind:         cfg.Kind,
+		Symbol:       strings.ToUpper(symbol),
+		Horizon:      cfg.Horizon,
+		TrainedAt:    now,
+		Features:     append([]string(nil), FeatureNames...),
+		BuyThreshold: cfg.BuyThreshold,
+		TrainSamples: split,
+	}
+	m.Mean, m.Std = moments(x[:split])
+
+	train := make([][]float64, split)
+	for i := range train {
+		train[i] = m.standardize(x[i])
+	}
+	switch cfg.Kind {
+	case KindLogistic:
+		m.Bias, m.Weights = trainLogistic(train, y[:split], cfg)
+	case KindStumps:
+		m.Rate = cfg.Rate
+		m.Bias, m.Stumps = trainStumps(train, y[:split], cfg)
+	}
+
+	// калибровка на отложенной выборке: обучающую модель уже видела и на
+	// ней вероятности слишком уверенные
+	scores := make([]float64, 0, len(x)-split)
+	for _, row := range x[split:] {
+		scores = append(scores, m.Score(row))
+	}
+	m.Calibration = fitPlatt(scores, y[split:])
+	m.Validation = evaluate(m, x[split:], y[split:])
+	return m, nil
+}
+
+func moments(x [][]float64) (mean, std []float64) {
+	n := len(x[0])
+	mean = make([]float64, n)
+	std = make([]float64, n)
+	for _, row := range x {
+		for j, v := range row {
+			mean[j] += v
+		}
+	}
+	for j := range mean {
+		mean[j] /= float64(len(x))
+	}
+	for _, row := range x {
+		for j, v := range row {
+			std[j] += (v - mean[j]) * (v - mean[j])
+		}
+	}
+	for j := range std {
+		std[j] = math.Sqrt(std[j] / float64(len(x)))
+		// постоянный признак ничего не даёт, но и делить на ноль нельзя
+		if std[j] < 1e-12 {
+			std[j] = 1
+		}
+	}
+	return mean, std
+}
+
+// trainLogistic — полный градиентный спуск по логистической функции потерь
+// с L2-регуляризацией весов.
+func trainLogistic(x [][]float64, y []float64, cfg TrainConfig) (float64, []float64) {
+	n := float64(len(x))
+	weights := make([]float64, len(x[0]))
+	bias := 0.0
+	grad := make([]float64, len(weights))
+	for epoch := 0; epoch < cfg.Epochs; epoch++ {
+		for j := range grad {
+			grad[j] = 0
+		}
+		gradBias := 0.0
+		for i, row := range x {
+			z := bias
+			for j, w := range weights {
+				z += w * row[j]
+			}
+			diff := sigmoid(z) - y[i]
+			for j, v := range row {
+				grad[j] += diff * v
+			}
+			gradBias += diff
+		}
+		for j := range weights {
+			weights[j] -= cfg.LearningRate * (grad[j]/n + cfg.L2*weights[j])
+		}
+		bias -= cfg.LearningRate * gradBias / n
+	}
+	return bias, weights
+}
+
+// trainStumps — градиентный бустинг пней по логистической функции потерь.
+// Каждый пень подбирается по остаткам методом наименьших квадратов, значения
+// листьев — шаг Ньютона.
+func trainStumps(x [][]float64, y []float64, cfg TrainConfig) (float64, []Stump) {
+	base := logit(clampProb(indicators.Mean(y)))
+	scores := make([]float64, len(x))
+	for i := range scores {
+		scores[i] = base
+	}
+
+	thresholds := make([][]float64, len(x[0]))
+	for j := range thresholds {
+		thresholds[j] = quantiles(x, j, cfg.Bins)
+	}
+
+	residuals := make([]float64, len(x))
+	hessians := make([]float64, len(x))
+	var stumps []Stump
+	for round := 0; round < cfg.Rounds; round++ {
+		for i := range x {
+			p := sigmoid(scores[i])
+			residuals[i] = y[i] - p
+			hessians[i] = p * (1 - p)
+		}
+
+		best, bestGain := Stump{}, 0.0
+		for j, ts := range thresholds {
+			for _, t := range ts {
+				var sumL, sumR, hL, hR float64
+				var nL, nR int
+				for i, row := range x {
+					if row[j] <= t {
+						sumL += residuals[i]
+						hL += hessians[i]
+						nL++
+					} else {
+						sumR += residuals[i]
+						hR += hessians[i]
+						nR++
+					}
+				}
+				if nL == 0 || nR == 0 {
+					continue
+				}
+				// снижение суммы квадратов остатков при разбиении
+				gain := sumL*sumL/float64(nL) + sumR*sumR/float64(nR)
+				if gain > bestGain {
+					bestGain = gain
+					best = Stump{Feature: j, Threshold: t, Left: newton(sumL, hL), Right: newton(sumR, hR)}
+				}
+			}
+		}
+		if bestGain == 0 {
+			break
+		}
+
+		stumps = append(stumps, best)
+		for i, row := range x {
+			if row[best.Feature] <= best.Threshold {
+				scores[i] += cfg.Rate * best.Left
+			} else {
+				scores[i] += cfg.Rate * best.Right
+			}
+		}
+	}
+	return base, stumps
+}
+
+func newton(gradient, hessian float64) float64 {
+	if hessian < 1e-12 {
+		return 0
+	}
+	return gradient / hessian
+}
+
+// quantiles — уникальные пороги по квантилям признака j.
+func quantiles(x [][]float64, j, bins int) []float64 {
+	values := make([]float64, len(x))
+	for i, row := range x {
+		values[i] = row[j]
+	}
+	sort.Float64s(values)
+
+	var out []float64
+	for b := 1; b < bins; b++ {
+		v := values[b*len(values)/bins]
+		if len(out) == 0 || v > out[len(out)-1] {
+			out = append(out, v)
+		}
+	}
+	return out
+}
+
+// fitPlatt подбирает A и B шкалирования Платта градиентным спуском по
+// логистической функции потерь, начиная с тождественного преобразования.
+func fitPlatt(scores, y []float64) Calibration {
+	c := Calibration{A: 1}
+	n := float64(len(scores))
+	for iter := 0; iter < 1000; iter++ {
+		var gradA, gradB float64
+		for i, s := range scores {
+			diff := sigmoid(c.A*s+c.B) - y[i]
+			gradA += diff * s
+			gradB += diff
+		}
+		c.A -= 0.1 * gradA / n
+		c.B -= 0.1 * gradB / n
+	}
+	return c
+}
+
+func evaluate(m *Model, x [][]float64, y []float64) Metrics {
+	metrics := Metrics{Samples: len(x), BaseRate: indicators.Mean(y)}
+	for i, row := range x {
+		p := m.Predict(row)
+		if (p >= 0.5) == (y[i] == 1) {
+			metrics.Accuracy++
+		}
+		pc := clampProb(p)
+		metrics.LogLoss -= y[i]*math.Log(pc) + (1-y[i])*math.Log(1-pc)
+		metrics.Brier += (p - y[i]) * (p - y[i])
+	}
+	n := float64(len(x))
+	metrics.Accuracy /= n
+	metrics.LogLoss /= n
+	metrics.Brier /= n
+	return metrics
+}
+
+func clampProb(p float64) float64 {
+	return math.Min(math.Max(p, 1e-6), 1-1e-6)
+}
+
+func logit(p float64) float64 {
+	return math.Log(p / (1 - p))
+}
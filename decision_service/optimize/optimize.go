@@ -0,0 +1,343 @@
+package optimize
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/skomaroh1845/crypto_telemetry/decision_service/ai"
+	"github.com/skomaroh1845/crypto_telemetry/decision_service/backtest"
+	"github.com/skomaroh1845/crypto_telemetry/decision_service/indicators"
+	"github.com/skomaroh1845/crypto_telemetry/decision_service/rules"
+)
+
+const (
+	ObjectiveSharpe = "sharpe"
+	ObjectiveReturn = "return"
+)
+
+// Config задаёт окна walk-forward и параллельность. Train и Test — размеры
+// окон в свечах; перед каждым окном берётся Backtest.Lookback свечей истории
+// для индикаторов, на которых не торгуют.
+type Config struct {
+	Objective string
+	Train     int
+	Test      int
+	Workers   int
+	Backtest  backtest.Config
+	// OnTrial вызывается после каждого испытания, например для прогресса
+	OnTrial func(done, total int)
+}
+
+func (c Config) Validate() error {
+	if c.Objective != ObjectiveSharpe && c.Objective != ObjectiveReturn {
+		return fmt.Errorf("unknown objective %q, expected %s or %s", c.Objective, ObjectiveSharpe, ObjectiveReturn)
+	}
+	if c.Train < 2 || c.Test < 2 {
+		return fmt.Errorf("train and test windows need at least 2 candles")
+	}
+	if c.Workers <= 0 {
+		return fmt.Errorf("workers must be positive")
+	}
+	return c.Backtest.Validate()
+}
+
+// Fold — одно окно walk-forward и лучший на обучающем окне набор параметров.
+type Fold struct {
+	TrainFrom  time.Time          `json:"train_from"`
+	TrainTo    time.Time          `json:"train_to"`
+	TestFrom   time.Time          `json:"test_from"`
+	TestTo     time.Time          `json:"test_to"`
+	Best       map[string]float64 `json:"best,omitempty"`
+	TrainScore float64            `json:"train_score"`
+	TestScore  float64            `json:"test_score"`
+}
+
+// Candidate — набор параметров и его оценки по окнам. LastTrain — оценка на
+// последнем обучающем окне, по ней выбираются параметры для боевой стратегии.
+type Candidate struct {
+	Rank        int                `json:"rank,omitempty"`
+	Params      map[string]float64 `json:"params"`
+	LastTrain   float64            `json:"last_train"`
+	MeanTrain   float64            `json:"mean_train"`
+	MeanTest    float64            `json:"mean_test"`
+	TrainScores []float64          `json:"train_scores,omitempty"`
+	TestScores  []float64          `json:"test_scores,omitempty"`
+	Trades      int                `json:"trades"` // на тестовых окнах
+	Error       string             `json:"error,omitempty"`
+}
+
+// Summary — итог walk-forward: средние оценки выбранных на каждом окне
+// параметров в выборке и вне её. Efficiency — отношение второго к первому,
+// близкое к 1 значит, что параметры не переобучены. MeanTest — честная
+// оценка того, как выбранные так параметры поведут себя на новых данных.
+type Summary struct {
+	MeanTrain  float64 `json:"mean_train"`
+	MeanTest   float64 `json:"mean_test"`
+	Efficiency float64 `json:"efficiency,omitempty"`
+}
+
+// Report — результат оптимизации. Candidates отсортированы по оценке на
+// последнем обучающем окне, первый — лучший. Тестовые окна в выборе не
+// участвуют: иначе оценка вне выборки перестала бы быть таковой.
+type Report struct {
+	Symbol       string      `json:"symbol"`
+	Rules        string      `json:"rules"`
+	RulesVersion string      `json:"rules_version"`
+	Objective    string      `json:"objective"`
+	Candles      int         `json:"candles"`
+	Folds        []Fold      `json:"folds"`
+	WalkForward  Summary     `json:"walk_forward"`
+	Candidates   []Candidate `json:"candidates"`
+	Failed       int         `json:"failed"`
+}
+
+// Best — лучший набор параметров или nil, если ни один не удалось оценить.
+func (r *Report) Best() *Candidate {
+	if len(r.Candidates) == 0 || r.Candidates[0].Error != "" {
+		return nil
+	}
+	return &r.Candidates[0]
+}
+
+type window struct {
+	train, test []ai.Candle
+}
+
+// windows режет историю на окна: обучающее, за ним тестовое, затем сдвиг на
+// размер тестового окна.
+func windows(candles []ai.Candle, cfg Config) []window {
+	warm := cfg.Backtest.Warmup
+	var out []window
+	for start := 0; start+warm+cfg.Train+cfg.Test <= len(candles); start += cfg.Test {
+		out = append(out, window{
+			train: candles[start : start+warm+cfg.Train],
+			test:  candles[start+cfg.Train : start+warm+cfg.Train+cfg.Test],
+		})
+	}
+	return out
+}
+
+// Run оценивает каждый набор параметров на всех окнах пулом из cfg.Workers
+// воркеров.
+func Run(ctx context.Context, symbol, name string, template []byte, candidates []map[string]float64, candles []ai.Candle, cfg Config) (*Report, error) {
+	if cfg.Backtest.Warmup == 0 {
+		cfg.Backtest.Warmup = cfg.Backtest.Lookback
+	}
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	base, err := rules.Parse(name, template)
+	if err != nil {
+		return nil, err
+	}
+	if len(candidates) == 0 {
+		return nil, errors.New("no parameter sets to evaluate")
+	}
+	folds := windows(candles, cfg)
+	if len(folds) == 0 {
+		return nil, fmt.Errorf("need at least %d candles for one train/test window, got %d",
+			cfg.Backtest.Warmup+cfg.Train+cfg.Test, len(candles))
+	}
+
+	report := &Report{
+		Symbol:       symbol,
+		Rules:        name,
+		RulesVersion: base.Version,
+		Objective:    cfg.Objective,
+		Candles:      len(candles),
+		Candidates:   make([]Candidate, len(candidates)),
+	}
+
+	type job struct{ candidate, fold int }
+	jobs := make(chan job)
+	sets := make([]*rules.RuleSet, len(candidates))
+	for i, params := range candidates {
+		c := &report.Candidates[i]
+		c.Params = params
+		if sets[i], err = rules.ParseWithParams(name, template, params); err != nil {
+			c.Error = err.Error()
+			continue
+		}
+		c.TrainScores = make([]float64, len(folds))
+		c.TestScores = make([]float64, len(folds))
+	}
+
+	var mu sync.Mutex
+	done, total := 0, len(candidates)*len(folds)
+	trades := make([][]int, len(candidates))
+	for i := range trades {
+		trades[i] = make([]int, len(folds))
+	}
+
+	var wg sync.WaitGroup
+	for w := 0; w < cfg.Workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := range jobs {
+				c := &report.Candidates[j.candidate]
+				client := ai.NewStaticRulesClient(sets[j.candidate])
+				train, trainErr := backtest.Run(ctx, client, symbol, folds[j.fold].train, cfg.Backtest)
+				test, testErr := backtest.Run(ctx, client, symbol, folds[j.fold].test, cfg.Backtest)
+
+				mu.Lock()
+				if err := errors.Join(trainErr, testErr); err != nil {
+					if c.Error == "" {
+						c.Error = err.Error()
+					}
+				} else {
+					// каждое испытание пишет только свою ячейку
+					c.TrainScores[j.fold] = score(train, cfg.Objective)
+					c.TestScores[j.fold] = score(test, cfg.Objective)
+					trades[j.candidate][j.fold] = len(test.Trades)
+				}
+				done++
+				if cfg.OnTrial != nil {
+					cfg.OnTrial(done, total)
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+
+feed:
+	for i := range candidates {
+		if sets[i] == nil {
+			continue
+		}
+		for f := range folds {
+			select {
+			case jobs <- job{candidate: i, fold: f}:
+			case <-ctx.Done():
+				break feed
+			}
+		}
+	}
+	close(jobs)
+	wg.Wait()
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	for i := range report.Candidates {
+		c := &report.Candidates[i]
+		if c.Error != "" {
+			c.TrainScores, c.TestScores = nil, nil
+			report.Failed++
+			continue
+		}
+		c.LastTrain = c.TrainScores[len(c.TrainScores)-1]
+		c.MeanTrain = indicators.Mean(c.TrainScores)
+		c.MeanTest = indicators.Mean(c.TestScores)
+		for _, n := range trades[i] {
+			c.Trades += n
+		}
+	}
+
+	report.Folds = make([]Fold, len(folds))
+	var trainBest, testBest []float64
+	for f, w := range folds {
+		fold := Fold{
+			TrainFrom: w.train[cfg.Backtest.Warmup].Time,
+			TrainTo:   w.train[len(w.train)-1].Time,
+			TestFrom:  w.test[cfg.Backtest.Warmup].Time,
+			TestTo:    w.test[len(w.test)-1].Time,
+		}
+		best := -1
+		for i, c := range report.Candidates {
+			if c.Error == "" && (best < 0 || c.TrainScores[f] > report.Candidates[best].TrainScores[f]) {
+				best = i
+			}
+		}
+		if best >= 0 {
+			c := report.Candidates[best]
+			fold.Best, fold.TrainScore, fold.TestScore = c.Params, c.TrainScores[f], c.TestScores[f]
+			trainBest = append(trainBest, fold.TrainScore)
+			testBest = append(testBest, fold.TestScore)
+		}
+		report.Folds[f] = fold
+	}
+	if len(trainBest) > 0 {
+		report.WalkForward.MeanTrain = indicators.Mean(trainBest)
+		report.WalkForward.MeanTest = indicators.Mean(testBest)
+		if report.WalkForward.MeanTrain > 0 {
+			report.WalkForward.Efficiency = report.WalkForward.MeanTest / report.WalkForward.MeanTrain
+		}
+	}
+
+	sort.SliceStable(report.Candidates, func(i, j int) bool {
+		a, b := report.Candidates[i], report.Candidates[j]
+		if (a.Error == "") != (b.Error == "") {
+			return a.Error == ""
+		}
+		if a.LastTrain != b.LastTrain {
+			return a.LastTrain > b.LastTrain
+		}
+		return a.MeanTrain > b.MeanTrain
+	})
+	for i := range report.Candidates {
+		if report.Candidates[i].Error == "" {
+			report.Candidates[i].Rank = i + 1
+		}
+	}
+	if report.Best() == nil {
+		return report, errors.New("no parameter set could be evaluated")
+	}
+	return report, nil
+}
+
+func score(r *backtest.Report, objective string) float64 {
+	if objective == ObjectiveReturn {
+		return r.ReturnPct
+	}
+	return r.Sharpe
+}
+
+// ParamsFile — лучший набор параметров в формате RULES_PARAMS_FILE с
+// описанием того, как он получен.
+type ParamsFile struct {
+	rules.ParamsFile
+	Symbol    string    `json:"symbol"`
+	Objective string    `json:"objective"`
+	Score     float64   `json:"score"` // оценка walk-forward вне выборки
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// WriteParams сохраняет лучший набор параметров отчёта атомарно, чтобы
+// сервис с RULES_PARAMS_FILE не прочитал файл наполовину.
+func WriteParams(path string, report *Report) error {
+	best := report.Best()
+	if best == nil {
+		return errors.New("report has no evaluated parameter set")
+	}
+	data, err := json.MarshalIndent(ParamsFile{
+		ParamsFile: rules.ParamsFile{Rules: report.RulesVersion, Params: best.Params},
+		Symbol:     report.Symbol,
+		Objective:  report.Objective,
+		Score:      report.WalkForward.MeanTest,
+		CreatedAt:  time.Now().UTC(),
+	}, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal params: %w", err)
+	}
+	if dir := filepath.Dir(path); dir != "" {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return fmt.Errorf("failed to create params dir: %w", err)
+		}
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return fmt.Errorf("failed to write params: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return fmt.Errorf("failed to save params: %w", err)
+	}
+	return nil
+}
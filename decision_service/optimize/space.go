@@ -0,0 +1,173 @@
+// Package optimize подбирает параметры правил стратегии по истории с
+// проверкой walk-forward: параметры выбираются на обучающем окне и
+// оцениваются на следующем за ним тестовом.
+package optimize
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"strconv"
+	"strings"
+)
+
+// Param — параметр правил и значения, которые нужно перебрать.
+type Param struct {
+	Name   string    `json:"name"`
+	Values []float64 `json:"values"`
+}
+
+// maxGrid ограничивает полный перебор: больше стоит искать случайно.
+const maxGrid = 100000
+
+// ParseSpace разбирает пространство параметров через ";": диапазон
+// "name=from:to:step" или список "name=a,b,c", например
+// "oversold=20:40:5;period=10,14,20".
+func ParseSpace(spec string) ([]Param, error) {
+	var space []Param
+	seen := map[string]bool{}
+	for _, part := range strings.Split(spec, ";") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		name, values, ok := strings.Cut(part, "=")
+		name = strings.TrimSpace(name)
+		if !ok || name == "" {
+			return nil, fmt.Errorf("invalid param %q: expected name=from:to:step or name=a,b,c", part)
+		}
+		if seen[name] {
+			return nil, fmt.Errorf("duplicate param %q", name)
+		}
+		seen[name] = true
+
+		p := Param{Name: name}
+		var err error
+		if strings.Contains(values, ":") {
+			p.Values, err = parseRange(values)
+		} else {
+			p.Values, err = parseList(values)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid param %q: %w", name, err)
+		}
+		space = append(space, p)
+	}
+	if len(space) == 0 {
+		return nil, fmt.Errorf("empty parameter space")
+	}
+	return space, nil
+}
+
+func parseRange(text string) ([]float64, error) {
+	fields := strings.Split(text, ":")
+	if len(fields) != 3 {
+		return nil, fmt.Errorf("range must be from:to:step")
+	}
+	var nums [3]float64
+	for i, f := range fields {
+		v, err := strconv.ParseFloat(strings.TrimSpace(f), 64)
+		if err != nil {
+			return nil, fmt.Errorf("bad number %q", f)
+		}
+		nums[i] = v
+	}
+	from, to, step := nums[0], nums[1], nums[2]
+	if step <= 0 || to < from {
+		return nil, fmt.Errorf("range needs from <= to and a positive step")
+	}
+	if (to-from)/step > maxGrid {
+		return nil, fmt.Errorf("range has more than %d values", maxGrid)
+	}
+	var values []float64
+	for i := 0; ; i++ {
+		// шаг через умножение, чтобы 0.1 не накапливал ошибку округления
+		v := from + float64(i)*step
+		if v > to+step*1e-9 {
+			break
+		}
+		values = append(values, math.Round(v*1e9)/1e9)
+	}
+	return values, nil
+}
+
+func parseList(text string) ([]float64, error) {
+	var values []float64
+	for _, f := range strings.Split(text, ",") {
+		v, err := strconv.ParseFloat(strings.TrimSpace(f), 64)
+		if err != nil {
+			return nil, fmt.Errorf("bad number %q", f)
+		}
+		values = append(values, v)
+	}
+	return values, nil
+}
+
+// Grid — все сочетания значений параметров.
+func Grid(space []Param) ([]map[string]float64, error) {
+	total := 1
+	for _, p := range space {
+		total *= len(p.Values)
+		if total > maxGrid {
+			return nil, fmt.Errorf("grid has more than %d combinations, use random search", maxGrid)
+		}
+	}
+
+	out := make([]map[string]float64, 0, total)
+	idx := make([]int, len(space))
+	for {
+		c := make(map[string]float64, len(space))
+		for i, p := range space {
+			c[p.Name] = p.Values[idx[i]]
+		}
+		out = append(out, c)
+
+		// следующее сочетание как счётчик со смешанным основанием
+		i := len(space) - 1
+		for ; i >= 0; i-- {
+			idx[i]++
+			if idx[i] < len(space[i].Values) {
+				break
+			}
+			idx[i] = 0
+		}
+		if i < 0 {
+			return out, nil
+		}
+	}
+}
+
+// Random — n случайных различных сочетаний; если сочетаний меньше n,
+// возвращаются все.
+func Random(space []Param, n int, seed int64) []map[string]float64 {
+	total := 1
+	for _, p := range space {
+		total *= len(p.Values)
+		if total > maxGrid {
+			break
+		}
+	}
+	if total <= n {
+		all, _ := Grid(space)
+		return all
+	}
+
+	rng := rand.New(rand.NewSource(seed))
+	seen := map[string]bool{}
+	out := make([]map[string]float64, 0, n)
+	for attempts := 0; len(out) < n && attempts < n*20; attempts++ {
+		c := make(map[string]float64, len(space))
+		var key strings.Builder
+		for _, p := range space {
+			v := p.Values[rng.Intn(len(p.Values))]
+			c[p.Name] = v
+			fmt.Fprintf(&key, "%s=%v;", p.Name, v)
+		}
+		if seen[key.String()] {
+			continue
+		}
+		seen[key.String()] = true
+		out = append(out, c)
+	}
+	return out
+}
@@ -0,0 +1,248 @@
+package outcome
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/skomaroh1845/crypto_telemetry/decision_service/audit"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+)
+
+// Horizon — через сколько после решения оно проверяется.
+type Horizon struct {
+	Label    string
+	Duration time.Duration
+}
+
+// ParseHorizons разбирает "1h,24h,7d": к форматам time.ParseDuration
+// добавлен суффикс d для суток.
+func ParseHorizons(spec string) ([]Horizon, error) {
+	var horizons []Horizon
+	for _, part := range strings.Split(spec, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		d, err := parseDuration(part)
+		if err != nil {
+			return nil, fmt.Errorf("invalid horizon %q: %w", part, err)
+		}
+		if d <= 0 {
+			return nil, fmt.Errorf("invalid horizon %q: must be positive", part)
+		}
+		horizons = append(horizons, Horizon{Label: part, Duration: d})
+	}
+	return horizons, nil
+}
+
+func parseDuration(value string) (time.Duration, error) {
+	if days, ok := strings.CutSuffix(value, "d"); ok {
+		n, err := strconv.ParseFloat(days, 64)
+		if err != nil {
+			return 0, err
+		}
+		return time.Duration(n * float64(24*time.Hour)), nil
+	}
+	return time.ParseDuration(value)
+}
+
+// PriceSource отдаёт цену символа на момент at.
+type PriceSource interface {
+	PriceAt(ctx context.Context, symbol string, at time.Time) (float64, error)
+}
+
+// ResultHook вызывается для каждого нового результата, например для метрик.
+type ResultHook func(ctx context.Context, o Outcome)
+
+// Evaluator периодически проверяет решения из журнала аудита, у которых
+// наступил горизонт.
+type Evaluator struct {
+	decisions *audit.Store
+	outcomes  *Store
+	prices    PriceSource
+	horizons  []Horizon
+	// holdBand — в пределах какого движения цены hold считается верным
+	holdBand float64
+	onResult ResultHook
+	// maxAttempts — сколько проходов подряд можно не получить цену, прежде
+	// чем проверка решения на горизонте бросается; 0 — без ограничения
+	maxAttempts int
+
+	// cursor — все решения раньше него уже проверены или брошены, и следующий
+	// проход начинается с него, а не со всего журнала
+	cursor   time.Time
+	attempts map[string]attempt
+}
+
+// attempt — неудачные попытки получить цену для решения на горизонте.
+type attempt struct {
+	count int
+	at    time.Time // время решения
+}
+
+func NewEvaluator(decisions *audit.Store, outcomes *Store, prices PriceSource, horizons []Horizon, holdBand float64, maxAttempts int, onResult ResultHook) *Evaluator {
+	return &Evaluator{
+		decisions:   decisions,
+		outcomes:    outcomes,
+		prices:      prices,
+		horizons:    horizons,
+		holdBand:    holdBand,
+		onResult:    onResult,
+		maxAttempts: maxAttempts,
+		attempts:    map[string]attempt{},
+	}
+}
+
+// Run проверяет решения раз в interval до отмены контекста.
+func (e *Evaluator) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if n, err := e.EvaluateDue(ctx, time.Now()); err != nil {
+			log.Printf("outcome evaluation failed after %d results: %v", n, err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// EvaluateDue проверяет все решения, горизонт которых наступил к now, и
+// возвращает число новых результатов. Решение, для которого не удалось
+// получить цену, будет проверено на следующем проходе, но не больше
+// maxAttempts раз. Проходы не пересекаются: их запускает только Run.
+func (e *Evaluator) EvaluateDue(ctx context.Context, now time.Time) (int, error) {
+	ctx, span := otel.Tracer("outcome-evaluator").Start(ctx, "outcome.evaluate")
+	defer span.End()
+
+	page := e.decisions.Query(audit.Filter{Since: e.cursor})
+	evaluated, failed, abandoned := 0, 0, 0
+	var lastErr error
+	// settled — все решения до текущего закрыты, и курсор можно сдвинуть
+	settled := true
+	for i := len(page.Records) - 1; i >= 0; i-- {
+		rec := page.Records[i]
+		done := true
+		// решения по присланным данным нельзя сверять с реальной ценой, а
+		// повторы из кэша учли бы одно решение стратегии несколько раз
+		if rec.Error != "" || rec.Cached || rec.Source == audit.SourcePush || rec.Market.Price <= 0 || rec.Decision == "" {
+			e.advance(rec, settled)
+			continue
+		}
+		for _, h := range e.horizons {
+			due := rec.Time.Add(h.Duration)
+			if due.After(now) {
+				done = false
+				continue
+			}
+			key := attemptKey(rec.ID, h.Label)
+			if e.outcomes.Evaluated(rec.ID, h.Label) || e.gaveUp(key) {
+				continue
+			}
+
+			exit, err := e.prices.PriceAt(ctx, rec.Symbol, due)
+			if err != nil {
+				failed++
+				lastErr = fmt.Errorf("%s %s: %w", rec.Symbol, h.Label, err)
+				e.attempts[key] = attempt{count: e.attempts[key].count + 1, at: rec.Time}
+				if e.gaveUp(key) {
+					abandoned++
+					log.Printf("giving up on outcome of decision %s at %s after %d attempts: %v", rec.ID, h.Label, e.maxAttempts, err)
+				} else {
+					done = false
+				}
+				continue
+			}
+
+			o := e.evaluate(rec, h, exit, now)
+			if err := e.outcomes.Append(o); err != nil {
+				span.RecordError(err)
+				span.SetStatus(codes.Error, "Outcome store failed")
+				return evaluated, err
+			}
+			delete(e.attempts, key)
+			evaluated++
+			if e.onResult != nil {
+				e.onResult(ctx, o)
+			}
+		}
+		settled = settled && done
+		e.advance(rec, settled)
+	}
+	// решения раньше курсора больше не перебираются, их попытки не нужны
+	for key, a := range e.attempts {
+		if a.at.Before(e.cursor) {
+			delete(e.attempts, key)
+		}
+	}
+
+	span.SetAttributes(
+		attribute.Int("outcome.evaluated", evaluated),
+		attribute.Int("outcome.failed", failed),
+		attribute.Int("outcome.abandoned", abandoned),
+	)
+	if lastErr != nil {
+		span.RecordError(lastErr)
+		span.SetStatus(codes.Error, "Some prices unavailable")
+		return evaluated, lastErr
+	}
+	span.SetStatus(codes.Ok, "Outcomes evaluated")
+	return evaluated, nil
+}
+
+// advance сдвигает курсор к закрытому решению. Решения с временем курсора
+// перебираются ещё раз, так что курсор не проскакивает одновременные записи.
+func (e *Evaluator) advance(rec audit.Record, settled bool) {
+	if settled {
+		e.cursor = rec.Time
+	}
+}
+
+func (e *Evaluator) gaveUp(key string) bool {
+	return e.maxAttempts > 0 && e.attempts[key].count >= e.maxAttempts
+}
+
+func attemptKey(id, horizon string) string {
+	return id + "/" + horizon
+}
+
+func (e *Evaluator) evaluate(rec audit.Record, h Horizon, exit float64, now time.Time) Outcome {
+	entry := rec.Market.Price
+	change := exit/entry - 1
+
+	o := Outcome{
+		DecisionID:  rec.ID,
+		Symbol:      strings.ToUpper(rec.Symbol),
+		Strategy:    rec.Strategy,
+		Experiment:  rec.Experiment,
+		Variant:     rec.Variant,
+		Decision:    rec.Decision,
+		Horizon:     h.Label,
+		DecidedAt:   rec.Time,
+		EvaluatedAt: now.UTC(),
+		EntryPrice:  entry,
+		ExitPrice:   exit,
+		PriceChange: change,
+	}
+	switch rec.Decision {
+	case "buy":
+		o.Return = change
+		o.Hit = change > 0
+	case "sell":
+		o.Return = -change
+		o.Hit = change < 0
+	default:
+		o.Hit = math.Abs(change) <= e.holdBand
+	}
+	return o
+}
@@ -0,0 +1,229 @@
+// Package outcome проверяет принятые решения задним числом: через заданные
+// горизонты сравнивает решение с фактическим движением цены и считает
+// точность стратегий.
+package outcome
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Outcome — результат проверки одного решения на одном горизонте.
+type Outcome struct {
+	DecisionID  string    `json:"decision_id"`
+	Symbol      string    `json:"symbol"`
+	Strategy    string    `json:"strategy"`
+	Experiment  string    `json:"experiment,omitempty"`
+	Variant     string    `json:"variant,omitempty"`
+	Decision    string    `json:"decision"`
+	Horizon     string    `json:"horizon"`
+	DecidedAt   time.Time `json:"decided_at"`
+	EvaluatedAt time.Time `json:"evaluated_at"`
+	EntryPrice  float64   `json:"entry_price"`
+	ExitPrice   float64   `json:"exit_price"`
+	// PriceChange — изменение цены за горизонт, в долях
+	PriceChange float64 `json:"price_change"`
+	// Return — доходность следования решению: buy зарабатывает на росте,
+	// sell на падении, hold ничего не зарабатывает
+	Return float64 `json:"return"`
+	Hit    bool    `json:"hit"`
+}
+
+func (o Outcome) key() string {
+	return o.DecisionID + "/" + o.Horizon
+}
+
+// Performance — агрегат по стратегии, символу и горизонту.
+type Performance struct {
+	Strategy  string  `json:"strategy"`
+	Symbol    string  `json:"symbol"`
+	Horizon   string  `json:"horizon"`
+	Count     int     `json:"count"`
+	Hits      int     `json:"hits"`
+	HitRate   float64 `json:"hit_rate"`
+	AvgReturn float64 `json:"avg_return"`
+}
+
+// Store хранит результаты в памяти и дописывает их в JSONL файл.
+type Store struct {
+	mu       sync.RWMutex
+	file     *os.File
+	outcomes []Outcome
+	seen     map[string]bool
+}
+
+func Open(path string) (*Store, error) {
+	s := &Store{seen: map[string]bool{}}
+	if path == "" {
+		return s, nil
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create outcome dir: %w", err)
+	}
+	if err := s.load(path); err != nil {
+		return nil, err
+	}
+
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open outcome log: %w", err)
+	}
+	s.file = file
+	return s, nil
+}
+
+func (s *Store) load(path string) error {
+	f, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read outcome log: %w", err)
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		if len(strings.TrimSpace(scanner.Text())) == 0 {
+			continue
+		}
+		var o Outcome
+		if err := json.Unmarshal(scanner.Bytes(), &o); err != nil {
+			continue
+		}
+		s.outcomes = append(s.outcomes, o)
+		s.seen[o.key()] = true
+	}
+	return scanner.Err()
+}
+
+// Evaluated сообщает, проверено ли уже решение на этом горизонте.
+func (s *Store) Evaluated(decisionID, horizon string) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.seen[decisionID+"/"+horizon]
+}
+
+func (s *Store) Append(o Outcome) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.seen[o.key()] {
+		return nil
+	}
+	if s.file != nil {
+		line, err := json.Marshal(o)
+		if err != nil {
+			return fmt.Errorf("failed to marshal outcome: %w", err)
+		}
+		if _, err := s.file.Write(append(line, '\n')); err != nil {
+			return fmt.Errorf("failed to write outcome: %w", err)
+		}
+	}
+	s.outcomes = append(s.outcomes, o)
+	s.seen[o.key()] = true
+	return nil
+}
+
+// Filter ограничивает агрегирование, пустые поля не фильтруют.
+type Filter struct {
+	Strategy   string
+	Symbol     string
+	Horizon    string
+	Experiment string
+	Since      time.Time
+}
+
+func (f Filter) match(o Outcome) bool {
+	if f.Strategy != "" && !strings.EqualFold(o.Strategy, f.Strategy) {
+		return false
+	}
+	if f.Symbol != "" && !strings.EqualFold(o.Symbol, f.Symbol) {
+		return false
+	}
+	if f.Horizon != "" && o.Horizon != f.Horizon {
+		return false
+	}
+	if f.Experiment != "" && o.Experiment != f.Experiment {
+		return false
+	}
+	return f.Since.IsZero() || !o.DecidedAt.Before(f.Since)
+}
+
+// List возвращает копию результатов, подходящих под фильтр.
+func (s *Store) List(f Filter) []Outcome {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	result := make([]Outcome, 0)
+	for _, o := range s.outcomes {
+		if f.match(o) {
+			result = append(result, o)
+		}
+	}
+	return result
+}
+
+// Performance считает hit-rate и среднюю доходность по стратегии, символу и
+// горизонту, результат отсортирован по этим полям.
+func (s *Store) Performance(f Filter) []Performance {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	type key struct{ strategy, symbol, horizon string }
+	groups := map[key]*Performance{}
+	for _, o := range s.outcomes {
+		if !f.match(o) {
+			continue
+		}
+
+		k := key{o.Strategy, o.Symbol, o.Horizon}
+		p := groups[k]
+		if p == nil {
+			p = &Performance{Strategy: o.Strategy, Symbol: o.Symbol, Horizon: o.Horizon}
+			groups[k] = p
+		}
+		p.Count++
+		if o.Hit {
+			p.Hits++
+		}
+		p.AvgReturn += o.Return
+	}
+
+	result := make([]Performance, 0, len(groups))
+	for _, p := range groups {
+		p.HitRate = float64(p.Hits) / float64(p.Count)
+		p.AvgReturn /= float64(p.Count)
+		result = append(result, *p)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		a, b := result[i], result[j]
+		if a.Strategy != b.Strategy {
+			return a.Strategy < b.Strategy
+		}
+		if a.Symbol != b.Symbol {
+			return a.Symbol < b.Symbol
+		}
+		return a.Horizon < b.Horizon
+	})
+	return result
+}
+
+func (s *Store) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.file == nil {
+		return nil
+	}
+	return s.file.Close()
+}
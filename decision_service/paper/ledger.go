@@ -0,0 +1,268 @@
+package paper
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Config задаёт размер сделок виртуального портфеля.
+type Config struct {
+	InitialCash  float64
+	BuyFraction  float64 // доля свободного кэша на одну покупку
+	SellFraction float64 // доля позиции, продаваемая по сигналу sell
+	FeeRate      float64
+}
+
+func DefaultConfig() Config {
+	return Config{
+		InitialCash:  10000,
+		BuyFraction:  0.1,
+		SellFraction: 1,
+		FeeRate:      0.001,
+	}
+}
+
+type Position struct {
+	Symbol   string  `json:"symbol"`
+	Quantity float64 `json:"quantity"`
+	AvgPrice float64 `json:"avg_price"`
+}
+
+type Fill struct {
+	Symbol   string    `json:"symbol"`
+	Side     string    `json:"side"`
+	Quantity float64   `json:"quantity"`
+	Price    float64   `json:"price"`
+	Fee      float64   `json:"fee"`
+	PnL      float64   `json:"pnl,omitempty"` // реализованный результат для продаж
+	Time     time.Time `json:"time"`
+}
+
+type Account struct {
+	ChatID      int64                `json:"chat_id"`
+	Cash        float64              `json:"cash"`
+	InitialCash float64              `json:"initial_cash"`
+	RealizedPnL float64              `json:"realized_pnl"`
+	Fees        float64              `json:"fees"`
+	Positions   map[string]*Position `json:"positions"`
+	Fills       []Fill               `json:"fills"`
+	// Trades — число сделок за всё время, Fills хранит только последние
+	Trades    int       `json:"trades"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// maxFills ограничивает историю сделок в файле состояния.
+const maxFills = 200
+
+// Ledger хранит виртуальные счета по Telegram chat ID и сохраняет их в JSON файл
+// после каждого изменения.
+type Ledger struct {
+	mu       sync.Mutex
+	path     string
+	cfg      Config
+	accounts map[int64]*Account
+}
+
+func NewLedger(path string, cfg Config) (*Ledger, error) {
+	l := &Ledger{
+		path:     path,
+		cfg:      cfg,
+		accounts: map[int64]*Account{},
+	}
+	if path == "" {
+		return l, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return l, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read paper state: %w", err)
+	}
+	if err := json.Unmarshal(data, &l.accounts); err != nil {
+		return nil, fmt.Errorf("failed to parse paper state: %w", err)
+	}
+	for _, acc := range l.accounts {
+		acc.normalize()
+	}
+	return l, nil
+}
+
+// normalize приводит счёт из старого файла состояния к текущему виду:
+// символы позиций в верхнем регистре, счётчик сделок не меньше истории.
+func (a *Account) normalize() {
+	positions := make(map[string]*Position, len(a.Positions))
+	for symbol, pos := range a.Positions {
+		symbol = strings.ToUpper(symbol)
+		pos.Symbol = symbol
+		if merged := positions[symbol]; merged != nil {
+			qty := merged.Quantity + pos.Quantity
+			if qty > 0 {
+				merged.AvgPrice = (merged.AvgPrice*merged.Quantity + pos.AvgPrice*pos.Quantity) / qty
+			}
+			merged.Quantity = qty
+			continue
+		}
+		positions[symbol] = pos
+	}
+	a.Positions = positions
+	for i := range a.Fills {
+		a.Fills[i].Symbol = strings.ToUpper(a.Fills[i].Symbol)
+	}
+	a.Trades = max(a.Trades, len(a.Fills))
+}
+
+// Execute исполняет решение по текущей цене. Для hold, покупки без кэша
+// или продажи без позиции возвращает nil.
+func (l *Ledger) Execute(chatID int64, symbol, decision string, price float64, at time.Time) (*Fill, error) {
+	if price <= 0 {
+		return nil, fmt.Errorf("invalid price %.2f", price)
+	}
+
+	// btc и BTC — одна позиция
+	symbol = strings.ToUpper(symbol)
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	acc := l.account(chatID, at)
+	var fill *Fill
+
+	switch decision {
+	case "buy":
+		budget := acc.Cash * l.cfg.BuyFraction
+		if budget < 0.01 {
+			return nil, nil
+		}
+		fee := budget * l.cfg.FeeRate
+		qty := (budget - fee) / price
+
+		pos := acc.Positions[symbol]
+		if pos == nil {
+			pos = &Position{Symbol: symbol}
+			acc.Positions[symbol] = pos
+		}
+		// комиссия входит в среднюю цену, чтобы реализованный PnL её учитывал
+		pos.AvgPrice = (pos.AvgPrice*pos.Quantity + budget) / (pos.Quantity + qty)
+		pos.Quantity += qty
+		acc.Cash -= budget
+		acc.Fees += fee
+		fill = &Fill{Symbol: symbol, Side: "buy", Quantity: qty, Price: price, Fee: fee, Time: at}
+
+	case "sell":
+		pos := acc.Positions[symbol]
+		if pos == nil || pos.Quantity <= 0 {
+			return nil, nil
+		}
+		qty := pos.Quantity * l.cfg.SellFraction
+		gross := qty * price
+		fee := gross * l.cfg.FeeRate
+		pnl := gross - fee - qty*pos.AvgPrice
+
+		pos.Quantity -= qty
+		if pos.Quantity*price < 0.01 {
+			delete(acc.Positions, symbol)
+		}
+		acc.Cash += gross - fee
+		acc.RealizedPnL += pnl
+		acc.Fees += fee
+		fill = &Fill{Symbol: symbol, Side: "sell", Quantity: qty, Price: price, Fee: fee, PnL: pnl, Time: at}
+
+	default:
+		return nil, nil
+	}
+
+	acc.Trades++
+	acc.Fills = append(acc.Fills, *fill)
+	if len(acc.Fills) > maxFills {
+		acc.Fills = acc.Fills[len(acc.Fills)-maxFills:]
+	}
+
+	if err := l.save(); err != nil {
+		return fill, err
+	}
+	return fill, nil
+}
+
+// Account возвращает копию счёта, счёт создаётся при первом обращении.
+func (l *Ledger) Account(chatID int64) Account {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	acc := l.account(chatID, time.Now())
+	copied := *acc
+	copied.Positions = make(map[string]*Position, len(acc.Positions))
+	for symbol, pos := range acc.Positions {
+		p := *pos
+		copied.Positions[symbol] = &p
+	}
+	copied.Fills = append([]Fill(nil), acc.Fills...)
+	return copied
+}
+
+// Reset возвращает счёт к начальному состоянию.
+func (l *Ledger) Reset(chatID int64) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	delete(l.accounts, chatID)
+	return l.save()
+}
+
+func (l *Ledger) account(chatID int64, at time.Time) *Account {
+	acc, ok := l.accounts[chatID]
+	if !ok {
+		acc = &Account{
+			ChatID:      chatID,
+			Cash:        l.cfg.InitialCash,
+			InitialCash: l.cfg.InitialCash,
+			Positions:   map[string]*Position{},
+			CreatedAt:   at,
+		}
+		l.accounts[chatID] = acc
+	}
+	if acc.Positions == nil {
+		acc.Positions = map[string]*Position{}
+	}
+	return acc
+}
+
+// save пишет состояние во временный файл и атомарно переименовывает его.
+func (l *Ledger) save() error {
+	if l.path == "" {
+		return nil
+	}
+	data, err := json.MarshalIndent(l.accounts, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal paper state: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(l.path), 0o755); err != nil {
+		return fmt.Errorf("failed to create paper state dir: %w", err)
+	}
+	tmp := l.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return fmt.Errorf("failed to write paper state: %w", err)
+	}
+	if err := os.Rename(tmp, l.path); err != nil {
+		return fmt.Errorf("failed to save paper state: %w", err)
+	}
+	return nil
+}
+
+// Symbols возвращает отсортированный список символов с открытыми позициями.
+func (a Account) Symbols() []string {
+	symbols := make([]string, 0, len(a.Positions))
+	for symbol := range a.Positions {
+		symbols = append(symbols, symbol)
+	}
+	sort.Strings(symbols)
+	return symbols
+}
@@ -0,0 +1,83 @@
+package paper
+
+type PositionView struct {
+	Symbol        string  `json:"symbol"`
+	Quantity      float64 `json:"quantity"`
+	AvgPrice      float64 `json:"avg_price"`
+	Price         float64 `json:"price"`
+	MarketValue   float64 `json:"market_value"`
+	UnrealizedPnL float64 `json:"unrealized_pnl"`
+}
+
+type Portfolio struct {
+	ChatID      int64          `json:"chat_id"`
+	Cash        float64        `json:"cash"`
+	Equity      float64        `json:"equity"`
+	Positions   []PositionView `json:"positions"`
+	RecentFills []Fill         `json:"recent_fills"`
+}
+
+type PnL struct {
+	ChatID        int64   `json:"chat_id"`
+	InitialCash   float64 `json:"initial_cash"`
+	Equity        float64 `json:"equity"`
+	RealizedPnL   float64 `json:"realized_pnl"`
+	UnrealizedPnL float64 `json:"unrealized_pnl"`
+	TotalPnL      float64 `json:"total_pnl"`
+	ReturnPct     float64 `json:"return_pct"`
+	Fees          float64 `json:"fees"`
+	Trades        int     `json:"trades"`
+}
+
+// Value оценивает счёт по текущим котировкам. Если котировки по символу нет,
+// позиция оценивается по средней цене входа.
+func Value(acc Account, quotes map[string]float64) Portfolio {
+	p := Portfolio{
+		ChatID:    acc.ChatID,
+		Cash:      acc.Cash,
+		Equity:    acc.Cash,
+		Positions: []PositionView{},
+	}
+	for _, symbol := range acc.Symbols() {
+		pos := acc.Positions[symbol]
+		price, ok := quotes[symbol]
+		if !ok || price <= 0 {
+			price = pos.AvgPrice
+		}
+		view := PositionView{
+			Symbol:        symbol,
+			Quantity:      pos.Quantity,
+			AvgPrice:      pos.AvgPrice,
+			Price:         price,
+			MarketValue:   pos.Quantity * price,
+			UnrealizedPnL: pos.Quantity * (price - pos.AvgPrice),
+		}
+		p.Equity += view.MarketValue
+		p.Positions = append(p.Positions, view)
+	}
+
+	const recent = 10
+	start := max(0, len(acc.Fills)-recent)
+	p.RecentFills = append([]Fill{}, acc.Fills[start:]...)
+	return p
+}
+
+func Summary(acc Account, quotes map[string]float64) PnL {
+	p := Value(acc, quotes)
+	s := PnL{
+		ChatID:      acc.ChatID,
+		InitialCash: acc.InitialCash,
+		Equity:      p.Equity,
+		RealizedPnL: acc.RealizedPnL,
+		Fees:        acc.Fees,
+		Trades:      acc.Trades,
+	}
+	for _, pos := range p.Positions {
+		s.UnrealizedPnL += pos.UnrealizedPnL
+	}
+	s.TotalPnL = p.Equity - acc.InitialCash
+	if acc.InitialCash > 0 {
+		s.ReturnPct = s.TotalPnL / acc.InitialCash * 100
+	}
+	return s
+}
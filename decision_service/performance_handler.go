@@ -0,0 +1,204 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/skomaroh1845/crypto_telemetry/decision_service/backtest"
+	"github.com/skomaroh1845/crypto_telemetry/decision_service/drift"
+	"github.com/skomaroh1845/crypto_telemetry/decision_service/experiment"
+	"github.com/skomaroh1845/crypto_telemetry/decision_service/outcome"
+	"github.com/skomaroh1845/crypto_telemetry/decision_service/shadow"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+type PerformanceResponse struct {
+	Strategies []outcome.Performance `json:"strategies"`
+}
+
+func (h *Handler) performanceHandler(w http.ResponseWriter, r *http.Request) {
+	_, span := tracer.Start(r.Context(), "strategies-performance",
+		trace.WithAttributes(attribute.String("handler", "strategies-performance")),
+	)
+	defer span.End()
+
+	q := r.URL.Query()
+	filter := outcome.Filter{
+		Strategy: q.Get("strategy"),
+		Symbol:   q.Get("symbol"),
+		Horizon:  q.Get("horizon"),
+	}
+	since, err := parseSince(q.Get("since"))
+	if err != nil {
+		err = fmt.Errorf("invalid since: %w", err)
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "Invalid query")
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	filter.Since = since
+
+	resp := PerformanceResponse{Strategies: h.outcomes.Performance(filter)}
+	span.SetAttributes(attribute.Int("performance.groups", len(resp.Strategies)))
+	span.SetStatus(codes.Ok, "Performance completed successfully")
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(resp)
+}
+
+// marketPrices — источник цен для оценки решений. Недавние моменты
+// оцениваются по текущей котировке, более старые — по закрытию дневной
+// свечи из data_service.
+type marketPrices struct {
+	// recent — насколько свежим должен быть момент, чтобы взять текущую цену
+	recent time.Duration
+
+	mu     sync.Mutex
+	closes map[string]float64 // symbol/день -> закрытие, только завершённые дни
+}
+
+func newMarketPrices(recent time.Duration) *marketPrices {
+	return &marketPrices{recent: recent, closes: map[string]float64{}}
+}
+
+func (p *marketPrices) PriceAt(ctx context.Context, symbol string, at time.Time) (float64, error) {
+	if time.Since(at) <= p.recent {
+		market, err := getMarketData(ctx, symbol)
+		if err != nil {
+			return 0, err
+		}
+		return market.Price, nil
+	}
+
+	day := at.UTC().Truncate(24 * time.Hour)
+	key := strings.ToUpper(symbol) + "/" + day.Format("2006-01-02")
+
+	p.mu.Lock()
+	price, ok := p.closes[key]
+	p.mu.Unlock()
+	if ok {
+		return price, nil
+	}
+
+	dataServiceURL := os.Getenv("DATA_SERVICE_URL")
+	if dataServiceURL == "" {
+		dataServiceURL = "http://data_service:8080"
+	}
+	candles, err := backtest.FetchHistory(ctx, dataServiceURL, symbol, day, day)
+	if err != nil {
+		return 0, err
+	}
+	if len(candles) == 0 {
+		return 0, fmt.Errorf("no candle for %s on %s", symbol, day.Format("2006-01-02"))
+	}
+	price = candles[len(candles)-1].Close
+
+	// текущий день ещё не закрыт, его не кэшируем
+	if day.Add(24 * time.Hour).Before(time.Now()) {
+		p.mu.Lock()
+		p.closes[key] = price
+		p.mu.Unlock()
+	}
+	return price, nil
+}
+
+func (h *Handler) experimentHandler(w http.ResponseWriter, r *http.Request) {
+	_, span := tracer.Start(r.Context(), "experiment-report",
+		trace.WithAttributes(attribute.String("handler", "experiment")),
+	)
+	defer span.End()
+
+	if h.experiment == nil {
+		span.SetStatus(codes.Error, "No experiment")
+		http.Error(w, "no experiment is running", http.StatusNotFound)
+		return
+	}
+
+	outcomes := h.outcomes.List(outcome.Filter{
+		Experiment: h.experiment.Name,
+		Horizon:    r.URL.Query().Get("horizon"),
+	})
+	report := experiment.Compare(h.experiment, outcomes)
+	span.SetAttributes(
+		attribute.String("experiment.name", h.experiment.Name),
+		attribute.Int("experiment.outcomes", len(outcomes)),
+	)
+	span.SetStatus(codes.Ok, "Experiment report completed successfully")
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(report)
+}
+
+type ShadowResponse struct {
+	Shadows []shadow.Agreement `json:"shadows"`
+}
+
+func (h *Handler) shadowHandler(w http.ResponseWriter, r *http.Request) {
+	_, span := tracer.Start(r.Context(), "shadow-agreement",
+		trace.WithAttributes(attribute.String("handler", "shadow")),
+	)
+	defer span.End()
+
+	since, err := parseSince(r.URL.Query().Get("since"))
+	if err != nil {
+		err = fmt.Errorf("invalid since: %w", err)
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "Invalid query")
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	resp := ShadowResponse{Shadows: h.shadow.Agreements(since)}
+	if resp.Shadows == nil {
+		resp.Shadows = []shadow.Agreement{}
+	}
+	span.SetStatus(codes.Ok, "Shadow agreement completed successfully")
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(resp)
+}
+
+type DriftResponse struct {
+	Drift []drift.Status `json:"drift"`
+}
+
+// driftHandler показывает распределение решений в текущем и базовом окнах
+// по каждой паре стратегия и символ; ?drifting=true оставляет только сдвинутые.
+func (h *Handler) driftHandler(w http.ResponseWriter, r *http.Request) {
+	_, span := tracer.Start(r.Context(), "decision-drift",
+		trace.WithAttributes(attribute.String("handler", "drift")),
+	)
+	defer span.End()
+
+	onlyDrifting := r.URL.Query().Get("drifting") == "true"
+	resp := DriftResponse{Drift: []drift.Status{}}
+	drifting := 0
+	for _, s := range h.drift.Statuses(time.Now()) {
+		if s.Drifting {
+			drifting++
+		} else if onlyDrifting {
+			continue
+		}
+		resp.Drift = append(resp.Drift, s)
+	}
+	span.SetAttributes(
+		attribute.Int("drift.series", len(resp.Drift)),
+		attribute.Int("drift.drifting", drifting),
+	)
+	span.SetStatus(codes.Ok, "Decision drift computed")
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(resp)
+}
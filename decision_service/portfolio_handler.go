@@ -0,0 +1,128 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/skomaroh1845/crypto_telemetry/decision_service/ai"
+	"github.com/skomaroh1845/crypto_telemetry/decision_service/paper"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// parseChatID читает необязательный chat_id из query, 0 означает отсутствие.
+func parseChatID(r *http.Request) (int64, error) {
+	value := r.URL.Query().Get("chat_id")
+	if value == "" {
+		return 0, nil
+	}
+	chatID, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid chat_id: %s", value)
+	}
+	return chatID, nil
+}
+
+// executePaperTrade исполняет решение на виртуальном счёте чата. Ошибки только
+// логируются: бумажная торговля не должна ломать выдачу решения.
+func (h *Handler) executePaperTrade(ctx context.Context, chatID int64, symbol, decision string, market ai.MarketData) *paper.Fill {
+	_, span := tracer.Start(ctx, "paper.execute",
+		trace.WithAttributes(
+			attribute.Int64("telegram.chat_id", chatID),
+			attribute.String("symbol", symbol),
+			attribute.String("decision", decision),
+		),
+	)
+	defer span.End()
+
+	fill, err := h.ledger.Execute(chatID, symbol, decision, market.Price, market.Timestamp)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "Paper trade failed")
+		log.Printf("paper trade for chat %d failed: %v", chatID, err)
+	}
+	if fill != nil {
+		span.SetAttributes(
+			attribute.String("paper.side", fill.Side),
+			attribute.Float64("paper.quantity", fill.Quantity),
+			attribute.Float64("paper.price", fill.Price),
+		)
+	}
+	return fill
+}
+
+func (h *Handler) portfolioHandler(w http.ResponseWriter, r *http.Request) {
+	ctx, span := tracer.Start(r.Context(), "portfolio-process",
+		trace.WithAttributes(attribute.String("handler", "portfolio")),
+	)
+	defer span.End()
+
+	acc, ok := h.requestAccount(w, r, span)
+	if !ok {
+		return
+	}
+
+	portfolio := paper.Value(acc, h.quotes(ctx, acc))
+	span.SetAttributes(attribute.Float64("paper.equity", portfolio.Equity))
+	span.SetStatus(codes.Ok, "Portfolio completed successfully")
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(portfolio)
+}
+
+func (h *Handler) pnlHandler(w http.ResponseWriter, r *http.Request) {
+	ctx, span := tracer.Start(r.Context(), "pnl-process",
+		trace.WithAttributes(attribute.String("handler", "pnl")),
+	)
+	defer span.End()
+
+	acc, ok := h.requestAccount(w, r, span)
+	if !ok {
+		return
+	}
+
+	pnl := paper.Summary(acc, h.quotes(ctx, acc))
+	span.SetAttributes(attribute.Float64("paper.total_pnl", pnl.TotalPnL))
+	span.SetStatus(codes.Ok, "PnL completed successfully")
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(pnl)
+}
+
+func (h *Handler) requestAccount(w http.ResponseWriter, r *http.Request, span trace.Span) (paper.Account, bool) {
+	chatID, err := parseChatID(r)
+	if err == nil && chatID == 0 {
+		err = fmt.Errorf("chat_id is required")
+	}
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "Invalid chat_id")
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return paper.Account{}, false
+	}
+
+	span.SetAttributes(attribute.Int64("telegram.chat_id", chatID))
+	return h.ledger.Account(chatID), true
+}
+
+// quotes запрашивает текущие цены по открытым позициям. Если цену получить
+// не удалось, позиция оценивается по цене входа.
+func (h *Handler) quotes(ctx context.Context, acc paper.Account) map[string]float64 {
+	quotes := make(map[string]float64, len(acc.Positions))
+	for _, symbol := range acc.Symbols() {
+		market, err := getMarketData(ctx, symbol)
+		if err != nil {
+			log.Printf("failed to get quote for %s: %v", symbol, err)
+			continue
+		}
+		quotes[symbol] = market.Price
+	}
+	return quotes
+}
@@ -0,0 +1,171 @@
+// Package rebalance строит целевое распределение корзины по решениям для
+// отдельных монет и считает сделки, которые к нему приводят.
+package rebalance
+
+import (
+	"math"
+	"sort"
+	"strings"
+
+	"github.com/skomaroh1845/crypto_telemetry/decision_service/format"
+)
+
+type Holding struct {
+	Symbol   string  `json:"symbol"`
+	Quantity float64 `json:"quantity"`
+}
+
+// Signal — решение по одной монете после риск-менеджмента.
+type Signal struct {
+	Symbol   string
+	Price    float64
+	Decision string
+	// PositionSize — доля капитала, которую риск-менеджмент разрешает под покупку
+	PositionSize float64
+}
+
+type Config struct {
+	// MinTrade — сделки меньше этой доли капитала не предлагаются
+	MinTrade float64
+	// MaxInvested — какую долю капитала можно держать в монетах, остальное кэш
+	MaxInvested float64
+}
+
+func DefaultConfig() Config {
+	return Config{MinTrade: 0.01, MaxInvested: 1}
+}
+
+type Allocation struct {
+	Symbol        string  `json:"symbol"`
+	Decision      string  `json:"decision"`
+	Price         float64 `json:"price"`
+	Quantity      float64 `json:"quantity"`
+	Value         float64 `json:"value"`
+	CurrentWeight float64 `json:"current_weight"`
+	TargetWeight  float64 `json:"target_weight"`
+}
+
+type Trade struct {
+	Symbol   string  `json:"symbol"`
+	Side     string  `json:"side"`
+	Quantity float64 `json:"quantity"`
+	Price    float64 `json:"price"`
+	Value    float64 `json:"value"`
+}
+
+// Plan — текущее и целевое распределение с ребалансирующими сделками.
+// Сделки отсортированы так, что продажи идут первыми и освобождают кэш
+// под покупки.
+type Plan struct {
+	Equity           float64      `json:"equity"`
+	Cash             float64      `json:"cash"`
+	CashWeight       float64      `json:"cash_weight"`
+	TargetCashWeight float64      `json:"target_cash_weight"`
+	Allocations      []Allocation `json:"allocations"`
+	Trades           []Trade      `json:"trades"`
+}
+
+// Build считает план по кэшу, количествам монет и сигналам. sell закрывает
+// позицию, hold оставляет её как есть, buy доводит долю до PositionSize, но
+// не уменьшает уже большую позицию. Если покупкам не хватает места до
+// MaxInvested, их прирост урезается пропорционально. Монеты без сигнала или
+// без цены в план не попадают.
+func Build(cash float64, holdings map[string]float64, signals []Signal, cfg Config) Plan {
+	plan := Plan{Cash: cash, Equity: cash, Allocations: []Allocation{}, Trades: []Trade{}}
+
+	allocs := make([]Allocation, 0, len(signals))
+	sizes := make([]float64, 0, len(signals))
+	for _, s := range signals {
+		if s.Price <= 0 {
+			continue
+		}
+		qty := holdings[strings.ToUpper(s.Symbol)]
+		a := Allocation{
+			Symbol:   strings.ToUpper(s.Symbol),
+			Decision: s.Decision,
+			Price:    s.Price,
+			Quantity: qty,
+			Value:    format.Round(qty*s.Price, 2),
+		}
+		plan.Equity += a.Value
+		allocs = append(allocs, a)
+		sizes = append(sizes, s.PositionSize)
+	}
+	if plan.Equity <= 0 {
+		plan.Allocations = allocs
+		return plan
+	}
+
+	// fixed — доля, уже занятая позициями после ребалансировки без учёта
+	// прироста покупок; increments — сколько покупки хотят добавить
+	fixed, increments := 0.0, 0.0
+	for i := range allocs {
+		a := &allocs[i]
+		a.CurrentWeight = a.Value / plan.Equity
+		switch a.Decision {
+		case "sell":
+			a.TargetWeight = 0
+		case "buy":
+			a.TargetWeight = math.Max(a.CurrentWeight, sizes[i])
+			fixed += a.CurrentWeight
+			increments += a.TargetWeight - a.CurrentWeight
+			continue
+		default:
+			a.TargetWeight = a.CurrentWeight
+		}
+		fixed += a.TargetWeight
+	}
+
+	maxInvested := cfg.MaxInvested
+	if maxInvested <= 0 || maxInvested > 1 {
+		maxInvested = 1
+	}
+	if increments > 0 {
+		scale := math.Min(1, math.Max(maxInvested-fixed, 0)/increments)
+		for i := range allocs {
+			a := &allocs[i]
+			if a.Decision == "buy" {
+				a.TargetWeight = a.CurrentWeight + (a.TargetWeight-a.CurrentWeight)*scale
+			}
+		}
+	}
+
+	plan.CashWeight = cash / plan.Equity
+	plan.TargetCashWeight = 1
+	for i := range allocs {
+		a := &allocs[i]
+		a.TargetWeight = format.Round(a.TargetWeight, 4)
+		plan.TargetCashWeight -= a.TargetWeight
+
+		delta := (a.TargetWeight - a.CurrentWeight) * plan.Equity
+		if delta == 0 || math.Abs(delta) < cfg.MinTrade*plan.Equity {
+			continue
+		}
+		t := Trade{Symbol: a.Symbol, Side: "buy", Price: a.Price}
+		if delta < 0 {
+			t.Side = "sell"
+		}
+		t.Quantity = math.Abs(delta) / a.Price
+		// закрытие позиции продаёт всё количество, без остатка от округления
+		if a.TargetWeight == 0 {
+			t.Quantity = a.Quantity
+		}
+		t.Value = format.Round(t.Quantity*a.Price, 2)
+		plan.Trades = append(plan.Trades, t)
+	}
+	plan.TargetCashWeight = format.Round(math.Max(plan.TargetCashWeight, 0), 4)
+	plan.CashWeight = format.Round(plan.CashWeight, 4)
+	for i := range allocs {
+		allocs[i].CurrentWeight = format.Round(allocs[i].CurrentWeight, 4)
+	}
+
+	sort.Slice(allocs, func(i, j int) bool { return allocs[i].Symbol < allocs[j].Symbol })
+	sort.SliceStable(plan.Trades, func(i, j int) bool {
+		if plan.Trades[i].Side != plan.Trades[j].Side {
+			return plan.Trades[i].Side == "sell"
+		}
+		return plan.Trades[i].Symbol < plan.Trades[j].Symbol
+	})
+	plan.Allocations = allocs
+	return plan
+}
@@ -0,0 +1,246 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/skomaroh1845/crypto_telemetry/decision_service/ai"
+	"github.com/skomaroh1845/crypto_telemetry/decision_service/rebalance"
+	"github.com/skomaroh1845/crypto_telemetry/decision_service/risk"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// maxRebalanceSymbols ограничивает корзину: на каждую монету идёт вызов модели.
+const maxRebalanceSymbols = 20
+
+// RebalanceRequest описывает корзину. Если не переданы ни holdings, ни cash,
+// берётся бумажный счёт chat_id. Symbols добавляет монеты, которых в
+// корзине ещё нет.
+type RebalanceRequest struct {
+	ChatID   int64               `json:"chat_id,omitempty"`
+	Risk     string              `json:"risk,omitempty"`
+	Symbols  []string            `json:"symbols,omitempty"`
+	Holdings []rebalance.Holding `json:"holdings,omitempty"`
+	Cash     *float64            `json:"cash,omitempty"`
+}
+
+// SymbolAction — решение по одной монете корзины. Если решение получить не
+// удалось, монета остаётся в корзине как есть, а Error объясняет почему.
+type SymbolAction struct {
+	Symbol string `json:"symbol"`
+	DecisionResponse
+	Error string `json:"error,omitempty"`
+}
+
+type RebalanceResponse struct {
+	// Source — откуда взяты позиции: request или paper
+	Source  string         `json:"source"`
+	Actions []SymbolAction `json:"actions"`
+	rebalance.Plan
+}
+
+// rebalanceHandler принимает решения по всем монетам корзины параллельно на
+// одном снимке рынка и строит по ним целевое распределение со сделками.
+// Сделки только предлагаются и на бумажном счёте не исполняются.
+func (h *Handler) rebalanceHandler(w http.ResponseWriter, r *http.Request) {
+	ctx, span := tracer.Start(r.Context(), "rebalance-process",
+		trace.WithAttributes(attribute.String("handler", "rebalance")),
+	)
+	defer span.End()
+
+	var req RebalanceRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "Invalid request body")
+		http.Error(w, "Invalid request body: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	profile := h.riskProfile
+	if req.Risk != "" {
+		var err error
+		if profile, err = risk.ParseProfile(req.Risk); err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, "Invalid risk profile")
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+
+	resp := RebalanceResponse{Source: "request"}
+	cash := 0.0
+	holdings := map[string]float64{}
+	if len(req.Holdings) == 0 && req.Cash == nil && req.ChatID != 0 {
+		resp.Source = "paper"
+		acc := h.ledger.Account(req.ChatID)
+		cash = acc.Cash
+		for symbol, pos := range acc.Positions {
+			holdings[strings.ToUpper(symbol)] += pos.Quantity
+		}
+	} else {
+		if req.Cash != nil {
+			cash = *req.Cash
+		}
+		for _, hld := range req.Holdings {
+			holdings[strings.ToUpper(strings.TrimSpace(hld.Symbol))] += hld.Quantity
+		}
+	}
+
+	symbols, err := basketSymbols(holdings, req.Symbols)
+	if err == nil && cash < 0 {
+		err = errors.New("cash must not be negative")
+	}
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "Invalid basket")
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	span.SetAttributes(
+		attribute.Int64("telegram.chat_id", req.ChatID),
+		attribute.String("risk.profile", string(profile)),
+		attribute.String("rebalance.source", resp.Source),
+		attribute.StringSlice("rebalance.symbols", symbols),
+	)
+
+	if err := checkDataServiceHealth(ctx); err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "Health check failed")
+		http.Error(w, "Market data service is unavailable: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	snapshot, err := h.marketSnapshot(ctx, symbols)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "Market data fetch failed")
+		http.Error(w, "Failed to fetch market data: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	resp.Actions = make([]SymbolAction, len(symbols))
+	var wg sync.WaitGroup
+	for i, symbol := range symbols {
+		wg.Add(1)
+		go func(i int, symbol string) {
+			defer wg.Done()
+			resp.Actions[i] = h.decideSymbol(ctx, snapshot[i], req.ChatID, profile)
+		}(i, symbol)
+	}
+	wg.Wait()
+
+	signals := make([]rebalance.Signal, 0, len(symbols))
+	for i, action := range resp.Actions {
+		signal := rebalance.Signal{Symbol: action.Symbol, Price: snapshot[i].Price, Decision: "hold"}
+		if action.Error == "" {
+			signal.Decision = action.Decision
+			if action.Risk != nil {
+				signal.PositionSize = action.Risk.PositionSize
+			}
+		}
+		signals = append(signals, signal)
+	}
+	resp.Plan = rebalance.Build(cash, holdings, signals, h.rebalance)
+
+	span.SetAttributes(
+		attribute.Float64("rebalance.equity", resp.Equity),
+		attribute.Int("rebalance.trades", len(resp.Trades)),
+	)
+	span.SetStatus(codes.Ok, "Rebalance completed successfully")
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(resp)
+}
+
+// basketSymbols объединяет монеты из позиций и запроса, без повторов и по алфавиту.
+func basketSymbols(holdings map[string]float64, extra []string) ([]string, error) {
+	seen := map[string]bool{}
+	var symbols []string
+	add := func(symbol string) {
+		symbol = strings.ToUpper(strings.TrimSpace(symbol))
+		if symbol != "" && !seen[symbol] {
+			seen[symbol] = true
+			symbols = append(symbols, symbol)
+		}
+	}
+	for symbol, qty := range holdings {
+		if qty < 0 {
+			return nil, fmt.Errorf("quantity of %s must not be negative", symbol)
+		}
+		add(symbol)
+	}
+	for _, symbol := range extra {
+		add(symbol)
+	}
+
+	if len(symbols) == 0 {
+		return nil, errors.New("symbols are required")
+	}
+	if len(symbols) > maxRebalanceSymbols {
+		return nil, fmt.Errorf("too many symbols: %d, max %d", len(symbols), maxRebalanceSymbols)
+	}
+	sort.Strings(symbols)
+	return symbols, nil
+}
+
+// marketSnapshot параллельно загружает котировки и свечи для всех монет,
+// чтобы решения и распределение считались по одним и тем же ценам.
+func (h *Handler) marketSnapshot(ctx context.Context, symbols []string) ([]ai.MarketData, error) {
+	snapshot := make([]ai.MarketData, len(symbols))
+	errs := make([]error, len(symbols))
+
+	var wg sync.WaitGroup
+	for i, symbol := range symbols {
+		wg.Add(1)
+		go func(i int, symbol string) {
+			defer wg.Done()
+			market, err := getMarketData(ctx, symbol)
+			if err != nil {
+				errs[i] = fmt.Errorf("%s: %w", symbol, err)
+				return
+			}
+			if candles, err := getRecentCandles(ctx, symbol, h.historyDays); err == nil {
+				market.Candles = candles
+			}
+			market = h.withTimeframes(ctx, symbol, ai.WithIndicators(market))
+			snapshot[i] = h.withSentiment(ctx, symbol, market)
+		}(i, symbol)
+	}
+	wg.Wait()
+
+	return snapshot, errors.Join(errs...)
+}
+
+// decideSymbol принимает решение по одной монете в собственном span.
+func (h *Handler) decideSymbol(ctx context.Context, market ai.MarketData, chatID int64, profile risk.Profile) SymbolAction {
+	ctx, span := tracer.Start(ctx, "rebalance.symbol-decision",
+		trace.WithAttributes(
+			attribute.String("symbol", market.Symbol),
+			attribute.Float64("price", market.Price),
+		),
+	)
+	defer span.End()
+
+	action := SymbolAction{Symbol: market.Symbol}
+	resp, _, err := h.decide(ctx, span, decisionInput{
+		market:  market,
+		chatID:  chatID,
+		profile: profile,
+	})
+	if err != nil {
+		action.Error = err.Error()
+		return action
+	}
+	action.DecisionResponse = resp
+	span.SetStatus(codes.Ok, "Decision completed successfully")
+	return action
+}
@@ -0,0 +1,106 @@
+// Package regime определяет режим рынка по дневным закрытиям: тренд, боковик
+// или высокая волатильность. Стратегии могут вести себя в режимах
+// по-разному, а стратегия regime переключает модели по режиму.
+package regime
+
+import (
+	"math"
+
+	"github.com/skomaroh1845/crypto_telemetry/decision_service/format"
+	"github.com/skomaroh1845/crypto_telemetry/decision_service/indicators"
+)
+
+const (
+	Trending       = "trending"
+	Ranging        = "ranging"
+	HighVolatility = "high_volatility"
+)
+
+// Names — все режимы в порядке проверки.
+var Names = []string{HighVolatility, Trending, Ranging}
+
+// Config — окна и пороги классификатора.
+type Config struct {
+	// Window — окно, по которому считаются текущие волатильность и сила тренда
+	Window int `json:"window"`
+	// BaseWindow — длинное окно обычной для символа волатильности
+	BaseWindow int `json:"base_window"`
+	// TrendThreshold — сила тренда (0..1), с которой рынок считается трендовым
+	TrendThreshold float64 `json:"trend_threshold"`
+	// VolatilityRatio — во сколько раз текущая волатильность должна превысить
+	// обычную, чтобы режим стал high_volatility
+	VolatilityRatio float64 `json:"volatility_ratio"`
+	// MaxVolatility — дневная волатильность, которая высокая сама по себе
+	MaxVolatility float64 `json:"max_volatility"`
+}
+
+func DefaultConfig() Config {
+	return Config{
+		Window:          20,
+		BaseWindow:      90,
+		TrendThreshold:  0.3,
+		VolatilityRatio: 1.5,
+		MaxVolatility:   0.06,
+	}
+}
+
+// Regime — режим рынка и показатели, по которым он определён.
+type Regime struct {
+	Regime string `json:"regime"`
+	// Direction — up или down, только для trending
+	Direction string `json:"direction,omitempty"`
+	// TrendStrength — коэффициент эффективности Кауфмана: чистое изменение
+	// цены за окно, делённое на пройденный путь. 1 — движение без откатов,
+	// около 0 — цена ходит туда-обратно
+	TrendStrength float64 `json:"trend_strength"`
+	// Volatility — стандартное отклонение дневных доходностей за Window
+	Volatility float64 `json:"volatility"`
+	// BaseVolatility — то же за BaseWindow или всю доступную историю
+	BaseVolatility  float64 `json:"base_volatility"`
+	VolatilityRatio float64 `json:"volatility_ratio"`
+}
+
+// Classify определяет режим по закрытиям от старых к новым. Нужно хотя бы
+// Window+1 закрытие, иначе ok == false.
+func Classify(closes []float64, cfg Config) (*Regime, bool) {
+	volatility, ok := indicators.Volatility(closes, cfg.Window)
+	if !ok {
+		return nil, false
+	}
+	base, _ := indicators.Volatility(closes, min(cfg.BaseWindow, len(closes)-1))
+	if base <= 0 {
+		base = volatility
+	}
+
+	window := closes[len(closes)-cfg.Window-1:]
+	var path float64
+	for i := 1; i < len(window); i++ {
+		path += math.Abs(window[i] - window[i-1])
+	}
+	net := window[len(window)-1] - window[0]
+
+	r := &Regime{
+		Volatility:     format.Round(volatility, 4),
+		BaseVolatility: format.Round(base, 4),
+	}
+	if path > 0 {
+		r.TrendStrength = format.Round(math.Abs(net)/path, 4)
+	}
+	if base > 0 {
+		r.VolatilityRatio = format.Round(volatility/base, 4)
+	}
+
+	switch {
+	case r.VolatilityRatio >= cfg.VolatilityRatio || volatility >= cfg.MaxVolatility:
+		r.Regime = HighVolatility
+	case r.TrendStrength >= cfg.TrendThreshold:
+		r.Regime = Trending
+		r.Direction = "up"
+		if net < 0 {
+			r.Direction = "down"
+		}
+	default:
+		r.Regime = Ranging
+	}
+	return r, true
+}
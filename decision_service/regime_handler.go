@@ -0,0 +1,81 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/skomaroh1845/crypto_telemetry/decision_service/ai"
+	"github.com/skomaroh1845/crypto_telemetry/decision_service/regime"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// RegimeResponse — режим рынка символа на текущий момент.
+type RegimeResponse struct {
+	Symbol  string    `json:"symbol"`
+	Time    time.Time `json:"time"`
+	Candles int       `json:"candles"`
+	*regime.Regime
+}
+
+// regimeHandler определяет режим рынка по тем же свечам и цене, что попадают
+// в решение, поэтому ответ совпадает с режимом в MarketData.
+func (h *Handler) regimeHandler(w http.ResponseWriter, r *http.Request) {
+	ctx, span := tracer.Start(r.Context(), "regime-process",
+		trace.WithAttributes(attribute.String("handler", "regime")),
+	)
+	defer span.End()
+
+	symbol := r.URL.Query().Get("symbol")
+	span.SetAttributes(attribute.String("symbol", symbol))
+	if symbol == "" {
+		err := errors.New("symbol is required")
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "Invalid symbol")
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	market, err := getMarketData(ctx, symbol)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "Market data fetch failed")
+		http.Error(w, "Failed to fetch market data: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	candles, err := getRecentCandles(ctx, symbol, h.historyDays)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "History fetch failed")
+		http.Error(w, "Failed to fetch history: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	market.Candles = candles
+	market = ai.WithIndicators(market)
+	if market.Regime == nil {
+		err := errors.New("not enough history to detect market regime")
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "Not enough history")
+		http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+
+	span.SetAttributes(
+		attribute.String("market.regime", market.Regime.Regime),
+		attribute.Float64("market.trend_strength", market.Regime.TrendStrength),
+		attribute.Float64("market.volatility_ratio", market.Regime.VolatilityRatio),
+	)
+	span.SetStatus(codes.Ok, "Regime detected")
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(RegimeResponse{
+		Symbol:  symbol,
+		Time:    time.Now().UTC(),
+		Candles: len(candles),
+		Regime:  market.Regime,
+	})
+}
@@ -0,0 +1,343 @@
+// Package registry хранит именованные версии настроек стратегий — стратегию,
+// модель, шаблон промпта и параметры — и переключает боевую стратегию между
+// ними без перезапуска. Активации складываются в стек, откат возвращает
+// предыдущую версию, а с пустым стеком действует стратегия из окружения.
+package registry
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/skomaroh1845/crypto_telemetry/decision_service/ai"
+)
+
+// Config — настройки стратегии, из которых собирается клиент.
+type Config struct {
+	// Strategy — имя для ai.NewClient: daniilfrolov, groq, deepseek, ml, rules или regime
+	Strategy string `json:"strategy"`
+	Model    string `json:"model,omitempty"`
+	// Prompt — имя шаблона промпта, пусто — шаблон по умолчанию
+	Prompt string             `json:"prompt,omitempty"`
+	Params map[string]float64 `json:"params,omitempty"`
+}
+
+// hash — отпечаток настроек, одинаковые настройки не заводят новую версию.
+func (c Config) hash() string {
+	data, _ := json.Marshal(c) // ключи map сериализуются по порядку
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:4])
+}
+
+// Version — неизменяемая версия настроек стратегии name.
+type Version struct {
+	Name    string `json:"name"`
+	Version int    `json:"version"`
+	Config
+	Hash      string    `json:"hash"`
+	Note      string    `json:"note,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Label — имя версии для трейсов и ответов, например groq-fast@v3.
+func (v Version) Label() string {
+	return fmt.Sprintf("%s@v%d", v.Name, v.Version)
+}
+
+// Activation — запись стека активаций.
+type Activation struct {
+	Name        string    `json:"name"`
+	Version     int       `json:"version"`
+	ActivatedAt time.Time `json:"activated_at"`
+}
+
+// Active — собранная действующая версия.
+type Active struct {
+	Version Version
+	Client  ai.AIClient
+	// Prompt — шаблон версии, nil — шаблон по умолчанию
+	Prompt *ai.PromptTemplate
+}
+
+// Builder собирает клиента и шаблон промпта по настройкам. Он же проверяет
+// настройки при регистрации версии.
+type Builder func(cfg Config) (ai.AIClient, *ai.PromptTemplate, error)
+
+var (
+	ErrNotFound    = errors.New("strategy version not found")
+	ErrNoRollback  = errors.New("no active strategy version to roll back")
+	ErrInvalidName = errors.New("strategy name must be non-empty and contain only letters, digits, '-', '_' or '.'")
+)
+
+type state struct {
+	Versions []Version    `json:"versions"`
+	Stack    []Activation `json:"stack"`
+}
+
+// Registry — версии стратегий и стек активаций, сохраняются в JSON-файл.
+type Registry struct {
+	path  string
+	build Builder
+
+	mu     sync.RWMutex
+	state  state
+	active *Active
+}
+
+// Open загружает реестр из path и собирает активную версию. Если файла нет,
+// реестр пуст и действует стратегия из окружения.
+func Open(path string, build Builder) (*Registry, error) {
+	r := &Registry{path: path, build: build}
+	data, err := os.ReadFile(path)
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+		return r, nil
+	case err != nil:
+		return nil, fmt.Errorf("failed to read strategy registry: %w", err)
+	}
+	if err := json.Unmarshal(data, &r.state); err != nil {
+		return nil, fmt.Errorf("failed to parse strategy registry %s: %w", path, err)
+	}
+	if n := len(r.state.Stack); n > 0 {
+		top := r.state.Stack[n-1]
+		v, ok := r.find(top.Name, top.Version)
+		if !ok {
+			return nil, fmt.Errorf("active strategy %s@v%d: %w", top.Name, top.Version, ErrNotFound)
+		}
+		active, err := r.assemble(v)
+		if err != nil {
+			return nil, fmt.Errorf("failed to build active strategy %s: %w", v.Label(), err)
+		}
+		r.active = active
+	}
+	return r, nil
+}
+
+// Current возвращает действующую версию или nil, если действует стратегия
+// из окружения.
+func (r *Registry) Current() *Active {
+	if r == nil {
+		return nil
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.active
+}
+
+// Register добавляет версию настроек стратегии name. Если такие же настройки
+// уже зарегистрированы под этим именем, возвращается существующая версия и
+// created == false.
+func (r *Registry) Register(name string, cfg Config, note string) (v Version, created bool, err error) {
+	if !validName(name) {
+		return Version{}, false, ErrInvalidName
+	}
+	cfg.Strategy = strings.ToLower(strings.TrimSpace(cfg.Strategy))
+	if cfg.Strategy == "" {
+		return Version{}, false, errors.New("strategy is required")
+	}
+	if _, _, err := r.build(cfg); err != nil {
+		return Version{}, false, fmt.Errorf("invalid strategy config: %w", err)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	hash := cfg.hash()
+	latest := 0
+	for _, existing := range r.state.Versions {
+		if existing.Name != name {
+			continue
+		}
+		if existing.Hash == hash {
+			return existing, false, nil
+		}
+		latest = max(latest, existing.Version)
+	}
+	v = Version{
+		Name:      name,
+		Version:   latest + 1,
+		Config:    cfg,
+		Hash:      hash,
+		Note:      note,
+		CreatedAt: time.Now().UTC(),
+	}
+	r.state.Versions = append(r.state.Versions, v)
+	if err := r.save(); err != nil {
+		r.state.Versions = r.state.Versions[:len(r.state.Versions)-1]
+		return Version{}, false, err
+	}
+	return v, true, nil
+}
+
+// Activate делает версию боевой. version <= 0 означает последнюю версию name.
+func (r *Registry) Activate(name string, version int) (Version, error) {
+	r.mu.RLock()
+	v, ok := r.find(name, version)
+	r.mu.RUnlock()
+	if !ok {
+		return Version{}, ErrNotFound
+	}
+	// клиент собирается без блокировки: правила и модели читаются с диска
+	active, err := r.assemble(v)
+	if err != nil {
+		return Version{}, fmt.Errorf("failed to build strategy %s: %w", v.Label(), err)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.active != nil && r.active.Version.Name == v.Name && r.active.Version.Version == v.Version {
+		return v, nil
+	}
+	r.state.Stack = append(r.state.Stack, Activation{Name: v.Name, Version: v.Version, ActivatedAt: time.Now().UTC()})
+	if err := r.save(); err != nil {
+		r.state.Stack = r.state.Stack[:len(r.state.Stack)-1]
+		return Version{}, err
+	}
+	r.active = active
+	return v, nil
+}
+
+// Rollback снимает текущую активацию и возвращает предыдущую версию. Если
+// предыдущей нет, действует стратегия из окружения и ok == false.
+func (r *Registry) Rollback() (v Version, ok bool, err error) {
+	for {
+		r.mu.RLock()
+		stack := append([]Activation(nil), r.state.Stack...)
+		r.mu.RUnlock()
+		n := len(stack)
+		if n == 0 {
+			return Version{}, false, ErrNoRollback
+		}
+
+		// как и в Activate, клиент собирается без блокировки
+		var active *Active
+		v, ok = Version{}, false
+		if n > 1 {
+			prev := stack[n-2]
+			r.mu.RLock()
+			found, exists := r.find(prev.Name, prev.Version)
+			r.mu.RUnlock()
+			if !exists {
+				return Version{}, false, fmt.Errorf("previous strategy %s@v%d: %w", prev.Name, prev.Version, ErrNotFound)
+			}
+			if active, err = r.assemble(found); err != nil {
+				return Version{}, false, fmt.Errorf("failed to build strategy %s: %w", found.Label(), err)
+			}
+			v, ok = found, true
+		}
+
+		r.mu.Lock()
+		// пока клиент собирался, стек могли изменить: тогда всё заново
+		if !slices.Equal(r.state.Stack, stack) {
+			r.mu.Unlock()
+			continue
+		}
+		popped := r.state.Stack[n-1]
+		r.state.Stack = r.state.Stack[:n-1]
+		if err := r.save(); err != nil {
+			r.state.Stack = append(r.state.Stack, popped)
+			r.mu.Unlock()
+			return Version{}, false, err
+		}
+		r.active = active
+		r.mu.Unlock()
+		return v, ok, nil
+	}
+}
+
+// Status — версии, сгруппированные по имени, и стек активаций от новых к старым.
+type Status struct {
+	Active   *Version     `json:"active"`
+	Versions []Version    `json:"versions"`
+	History  []Activation `json:"history"`
+}
+
+func (r *Registry) Status() Status {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	s := Status{
+		Versions: append([]Version{}, r.state.Versions...),
+		History:  make([]Activation, 0, len(r.state.Stack)),
+	}
+	if r.active != nil {
+		v := r.active.Version
+		s.Active = &v
+	}
+	sort.SliceStable(s.Versions, func(i, j int) bool {
+		if s.Versions[i].Name != s.Versions[j].Name {
+			return s.Versions[i].Name < s.Versions[j].Name
+		}
+		return s.Versions[i].Version < s.Versions[j].Version
+	})
+	for i := len(r.state.Stack) - 1; i >= 0; i-- {
+		s.History = append(s.History, r.state.Stack[i])
+	}
+	return s
+}
+
+// find ищет версию, version <= 0 — последнюю; вызывается под mu.
+func (r *Registry) find(name string, version int) (Version, bool) {
+	var found Version
+	ok := false
+	for _, v := range r.state.Versions {
+		if v.Name != name {
+			continue
+		}
+		if v.Version == version || (version <= 0 && v.Version > found.Version) {
+			found, ok = v, true
+		}
+	}
+	return found, ok
+}
+
+func (r *Registry) assemble(v Version) (*Active, error) {
+	client, prompt, err := r.build(v.Config)
+	if err != nil {
+		return nil, err
+	}
+	return &Active{Version: v, Client: client, Prompt: prompt}, nil
+}
+
+// save пишет состояние во временный файл и атомарно переименовывает его,
+// вызывается под mu.
+func (r *Registry) save() error {
+	if r.path == "" {
+		return nil
+	}
+	data, err := json.MarshalIndent(r.state, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal strategy registry: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(r.path), 0o755); err != nil {
+		return fmt.Errorf("failed to create strategy registry dir: %w", err)
+	}
+	tmp := r.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return fmt.Errorf("failed to write strategy registry: %w", err)
+	}
+	if err := os.Rename(tmp, r.path); err != nil {
+		return fmt.Errorf("failed to save strategy registry: %w", err)
+	}
+	return nil
+}
+
+func validName(name string) bool {
+	if name == "" {
+		return false
+	}
+	for _, c := range name {
+		if !(c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '-' || c == '_' || c == '.') {
+			return false
+		}
+	}
+	return true
+}
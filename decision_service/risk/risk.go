@@ -0,0 +1,185 @@
+// Package risk превращает голое решение buy/sell/hold в торгуемую заявку:
+// размер позиции, stop-loss и take-profit с учётом волатильности и
+// профиля риска пользователя.
+package risk
+
+import (
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/skomaroh1845/crypto_telemetry/decision_service/ai"
+	"github.com/skomaroh1845/crypto_telemetry/decision_service/format"
+)
+
+type Profile string
+
+const (
+	Conservative Profile = "conservative"
+	Balanced     Profile = "balanced"
+	Aggressive   Profile = "aggressive"
+)
+
+// ParseProfile разбирает имя профиля, пустая строка — balanced.
+func ParseProfile(value string) (Profile, error) {
+	switch p := Profile(strings.ToLower(strings.TrimSpace(value))); p {
+	case "":
+		return Balanced, nil
+	case Conservative, Balanced, Aggressive:
+		return p, nil
+	default:
+		return "", fmt.Errorf("unknown risk profile: %s", value)
+	}
+}
+
+// Params — параметры профиля.
+type Params struct {
+	RiskPerTrade float64 // доля капитала, теряемая при срабатывании stop-loss
+	MaxPosition  float64 // максимальная доля капитала в одной позиции
+	StopATR      float64 // расстояние до stop-loss в ATR
+	RewardRisk   float64 // отношение take-profit к stop-loss
+	// MaxLevel — самый высокий уровень волатильности, при котором ещё разрешена покупка
+	MaxLevel Level
+}
+
+func (p Profile) Params() Params {
+	switch p {
+	case Conservative:
+		return Params{RiskPerTrade: 0.005, MaxPosition: 0.10, StopATR: 1.5, RewardRisk: 1.5, MaxLevel: LevelNormal}
+	case Aggressive:
+		return Params{RiskPerTrade: 0.02, MaxPosition: 0.50, StopATR: 3, RewardRisk: 3, MaxLevel: LevelHigh}
+	default:
+		return Params{RiskPerTrade: 0.01, MaxPosition: 0.25, StopATR: 2, RewardRisk: 2, MaxLevel: LevelHigh}
+	}
+}
+
+// Level — уровень волатильности по дневному стандартному отклонению
+// доходностей. Это не режим рынка: режимы определяет пакет regime.
+type Level string
+
+const (
+	LevelUnknown Level = "unknown"
+	LevelLow     Level = "low"
+	LevelNormal  Level = "normal"
+	LevelHigh    Level = "high"
+	LevelExtreme Level = "extreme"
+)
+
+// Границы уровней для дневной волатильности крипты.
+const (
+	lowVolatility     = 0.015
+	normalVolatility  = 0.04
+	extremeVolatility = 0.08
+)
+
+// fallbackStop — расстояние до stop-loss в долях цены, когда истории нет.
+const fallbackStop = 0.05
+
+func ClassifyVolatility(volatility float64) Level {
+	switch {
+	case volatility <= 0:
+		return LevelUnknown
+	case volatility < lowVolatility:
+		return LevelLow
+	case volatility < normalVolatility:
+		return LevelNormal
+	case volatility < extremeVolatility:
+		return LevelHigh
+	default:
+		return LevelExtreme
+	}
+}
+
+func (l Level) rank() int {
+	switch l {
+	case LevelLow:
+		return 1
+	case LevelNormal:
+		return 2
+	case LevelHigh:
+		return 3
+	case LevelExtreme:
+		return 4
+	default:
+		return 0
+	}
+}
+
+// Assessment — рекомендация по управлению риском для одного решения.
+type Assessment struct {
+	Profile    Profile `json:"profile"`
+	Level      Level   `json:"volatility_level"`
+	Volatility float64 `json:"volatility,omitempty"`
+	// PositionSize — доля капитала, которую стоит задействовать в сделке
+	PositionSize float64 `json:"position_size"`
+	StopLoss     float64 `json:"stop_loss,omitempty"`
+	TakeProfit   float64 `json:"take_profit,omitempty"`
+	// Refused — исходное решение отклонено, OriginalDecision хранит его
+	Refused          bool   `json:"refused,omitempty"`
+	OriginalDecision string `json:"original_decision,omitempty"`
+	Reason           string `json:"reason,omitempty"`
+}
+
+// Assess рассчитывает размер позиции и уровни выхода. Волатильность и ATR
+// берутся из market.Indicators (volatility_20, atr_14), так что рынок должен
+// быть пропущен через ai.WithIndicators. Возвращает итоговое решение: покупка
+// при слишком высокой для профиля волатильности заменяется на hold.
+func Assess(decision string, confidence float64, market ai.MarketData, profile Profile) (string, Assessment) {
+	params := profile.Params()
+	volatility := market.Indicators["volatility_20"]
+	a := Assessment{
+		Profile:    profile,
+		Level:      ClassifyVolatility(volatility),
+		Volatility: volatility,
+	}
+
+	if decision == "buy" && a.Level.rank() > params.MaxLevel.rank() {
+		a.Refused = true
+		a.OriginalDecision = decision
+		a.Reason = fmt.Sprintf("%s volatility is too risky for %s profile", a.Level, profile)
+		return "hold", a
+	}
+
+	price := market.Price
+	if price <= 0 || decision == "hold" {
+		return decision, a
+	}
+
+	// расстояние до стопа: ATR, если его нет — волатильность, иначе фиксированное
+	stop := params.StopATR * market.Indicators["atr_14"]
+	if stop <= 0 && volatility > 0 {
+		stop = params.StopATR * volatility * price
+	}
+	if stop <= 0 {
+		stop = fallbackStop * price
+	}
+	// стоп не может быть дальше половины цены
+	stop = math.Min(stop, price/2)
+
+	size := params.RiskPerTrade / (stop / price)
+	if confidence > 0 && confidence < 1 {
+		size *= confidence
+	}
+	a.PositionSize = format.Round(math.Min(size, params.MaxPosition), 4)
+
+	switch decision {
+	case "buy":
+		a.StopLoss = roundPrice(price-stop, price)
+		a.TakeProfit = roundPrice(price+stop*params.RewardRisk, price)
+	case "sell":
+		// для продажи уровни описывают короткую позицию
+		a.StopLoss = roundPrice(price+stop, price)
+		a.TakeProfit = roundPrice(math.Max(price-stop*params.RewardRisk, 0), price)
+	}
+	return decision, a
+}
+
+// priceDigits — значащих цифр цены, которые сохраняются в уровнях выхода.
+const priceDigits = 6
+
+// roundPrice округляет уровень с точностью, зависящей от величины цены:
+// центы для BTC, но не ноль для монет дешевле доллара.
+func roundPrice(value, price float64) float64 {
+	digits := priceDigits - 1 - int(math.Floor(math.Log10(price)))
+	return format.Round(value, max(digits, 2))
+}
@@ -0,0 +1,568 @@
+package rules
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/skomaroh1845/crypto_telemetry/decision_service/indicators"
+	"github.com/skomaroh1845/crypto_telemetry/decision_service/regime"
+)
+
+// valueType — тип выражения, проверяется при разборе.
+type valueType int
+
+const (
+	numberType valueType = iota
+	boolType
+)
+
+func (t valueType) String() string {
+	if t == boolType {
+		return "bool"
+	}
+	return "number"
+}
+
+// node — узел разобранного выражения.
+type node interface {
+	typ() valueType
+	eval(s *Snapshot) (float64, error) // bool кодируется как 0/1
+}
+
+type numberNode float64
+
+func (n numberNode) typ() valueType                  { return numberType }
+func (n numberNode) eval(*Snapshot) (float64, error) { return float64(n), nil }
+
+type boolNode bool
+
+func (n boolNode) typ() valueType { return boolType }
+func (n boolNode) eval(*Snapshot) (float64, error) {
+	return b2f(bool(n)), nil
+}
+
+// variables — поля снимка, доступные в правилах по имени.
+var variables = map[string]func(s *Snapshot) float64{
+	"price":      func(s *Snapshot) float64 { return s.Price },
+	"volume":     func(s *Snapshot) float64 { return s.Volume },
+	"high_24h":   func(s *Snapshot) float64 { return s.High24h },
+	"low_24h":    func(s *Snapshot) float64 { return s.Low24h },
+	"change_24h": func(s *Snapshot) float64 { return s.DailyChangePct },
+
+	"trend_strength":   func(s *Snapshot) float64 { return s.TrendStrength },
+	"volatility_ratio": func(s *Snapshot) float64 { return s.VolatilityRatio },
+}
+
+// flags — логические переменные режима рынка. Если режим неизвестен, все
+// ложны.
+var flags = map[string]func(s *Snapshot) bool{
+	"trending":        func(s *Snapshot) bool { return s.Regime == regime.Trending },
+	"ranging":         func(s *Snapshot) bool { return s.Regime == regime.Ranging },
+	"high_volatility": func(s *Snapshot) bool { return s.Regime == regime.HighVolatility },
+}
+
+type flagNode struct {
+	name string
+	get  func(s *Snapshot) bool
+}
+
+func (n flagNode) typ() valueType { return boolType }
+func (n flagNode) eval(s *Snapshot) (float64, error) {
+	return b2f(n.get(s)), nil
+}
+
+type varNode struct {
+	name string
+	get  func(s *Snapshot) float64
+}
+
+func (n varNode) typ() valueType { return numberType }
+func (n varNode) eval(s *Snapshot) (float64, error) {
+	return n.get(s), nil
+}
+
+// indicatorFuncs — индикаторы по закрытиям с периодом-константой.
+var indicatorFuncs = map[string]func(s *Snapshot, n int) (float64, bool){
+	"sma":        func(s *Snapshot, n int) (float64, bool) { return indicators.SMA(s.Close, n) },
+	"ema":        func(s *Snapshot, n int) (float64, bool) { return indicators.EMA(s.Close, n) },
+	"rsi":        func(s *Snapshot, n int) (float64, bool) { return indicators.RSI(s.Close, n) },
+	"volatility": func(s *Snapshot, n int) (float64, bool) { return indicators.Volatility(s.Close, n) },
+	"atr":        func(s *Snapshot, n int) (float64, bool) { return indicators.ATR(s.High, s.Low, s.Close, n) },
+	"change":     func(s *Snapshot, n int) (float64, bool) { return indicators.Change(s.Close, n) },
+}
+
+// maxPeriod ограничивает период индикатора, чтобы опечатка вроде sma(20000)
+// была видна при загрузке, а не как вечно несработавшее правило.
+const maxPeriod = 1000
+
+type indicatorNode struct {
+	name   string
+	period int
+	fn     func(s *Snapshot, n int) (float64, bool)
+}
+
+func (n indicatorNode) typ() valueType { return numberType }
+func (n indicatorNode) eval(s *Snapshot) (float64, error) {
+	v, ok := n.fn(s, n.period)
+	if !ok {
+		return 0, fmt.Errorf("%s(%d): not enough history (%d values)", n.name, n.period, len(s.Close))
+	}
+	return v, nil
+}
+
+// mathFuncs — функции от чисел и их арность.
+var mathFuncs = map[string]struct {
+	arity int
+	fn    func(args []float64) float64
+}{
+	"abs": {1, func(a []float64) float64 { return math.Abs(a[0]) }},
+	"min": {2, func(a []float64) float64 { return math.Min(a[0], a[1]) }},
+	"max": {2, func(a []float64) float64 { return math.Max(a[0], a[1]) }},
+}
+
+type mathNode struct {
+	fn   func(args []float64) float64
+	args []node
+}
+
+func (n mathNode) typ() valueType { return numberType }
+func (n mathNode) eval(s *Snapshot) (float64, error) {
+	values := make([]float64, len(n.args))
+	for i, arg := range n.args {
+		v, err := arg.eval(s)
+		if err != nil {
+			return 0, err
+		}
+		values[i] = v
+	}
+	return n.fn(values), nil
+}
+
+type unaryNode struct {
+	op string
+	x  node
+}
+
+func (n unaryNode) typ() valueType {
+	if n.op == "!" {
+		return boolType
+	}
+	return numberType
+}
+
+func (n unaryNode) eval(s *Snapshot) (float64, error) {
+	v, err := n.x.eval(s)
+	if err != nil {
+		return 0, err
+	}
+	if n.op == "!" {
+		return b2f(v == 0), nil
+	}
+	return -v, nil
+}
+
+type binaryNode struct {
+	op   string
+	l, r node
+}
+
+func (n binaryNode) typ() valueType {
+	switch n.op {
+	case "+", "-", "*", "/":
+		return numberType
+	default:
+		return boolType
+	}
+}
+
+func (n binaryNode) eval(s *Snapshot) (float64, error) {
+	l, err := n.l.eval(s)
+	if err != nil {
+		return 0, err
+	}
+	// && и || вычисляются лениво: правая часть может требовать истории,
+	// которой нет, хотя результат уже известен
+	switch {
+	case n.op == "&&" && l == 0:
+		return 0, nil
+	case n.op == "||" && l != 0:
+		return 1, nil
+	}
+	r, err := n.r.eval(s)
+	if err != nil {
+		return 0, err
+	}
+	switch n.op {
+	case "+":
+		return l + r, nil
+	case "-":
+		return l - r, nil
+	case "*":
+		return l * r, nil
+	case "/":
+		if r == 0 {
+			return 0, fmt.Errorf("division by zero")
+		}
+		return l / r, nil
+	case "<":
+		return b2f(l < r), nil
+	case "<=":
+		return b2f(l <= r), nil
+	case ">":
+		return b2f(l > r), nil
+	case ">=":
+		return b2f(l >= r), nil
+	case "==":
+		return b2f(l == r), nil
+	case "!=":
+		return b2f(l != r), nil
+	default: // && и ||
+		return b2f(r != 0), nil
+	}
+}
+
+func b2f(b bool) float64 {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+// token — лексема выражения, pos — позиция в строке с единицы.
+type token struct {
+	kind string // number, ident, op, eof
+	text string
+	pos  int
+}
+
+func tokenize(text string, offset int) ([]token, error) {
+	var tokens []token
+	runes := []rune(text)
+	for i := 0; i < len(runes); {
+		c := runes[i]
+		pos := offset + i + 1
+		switch {
+		case unicode.IsSpace(c):
+			i++
+		case unicode.IsDigit(c) || c == '.':
+			j := i
+			for j < len(runes) && (unicode.IsDigit(runes[j]) || runes[j] == '.') {
+				j++
+			}
+			tokens = append(tokens, token{kind: "number", text: string(runes[i:j]), pos: pos})
+			i = j
+		case unicode.IsLetter(c) || c == '_':
+			j := i
+			for j < len(runes) && (unicode.IsLetter(runes[j]) || unicode.IsDigit(runes[j]) || runes[j] == '_') {
+				j++
+			}
+			tokens = append(tokens, token{kind: "ident", text: string(runes[i:j]), pos: pos})
+			i = j
+		default:
+			op := string(c)
+			if i+1 < len(runes) {
+				switch two := string(runes[i : i+2]); two {
+				case "&&", "||", "<=", ">=", "==", "!=":
+					op = two
+				}
+			}
+			if !strings.Contains("+-*/<>!(),", op) && len(op) == 1 {
+				return nil, &posError{pos: pos, msg: fmt.Sprintf("unexpected character %q", c)}
+			}
+			tokens = append(tokens, token{kind: "op", text: op, pos: pos})
+			i += len(op)
+		}
+	}
+	return append(tokens, token{kind: "eof", pos: offset + len(runes) + 1}), nil
+}
+
+type posError struct {
+	pos int
+	msg string
+}
+
+func (e *posError) Error() string { return e.msg }
+
+// parser — рекурсивный спуск с проверкой типов:
+//
+//	or      = and { "||" and }
+//	and     = not { "&&" not }
+//	not     = "!" not | compare
+//	compare = sum [ ("<"|"<="|">"|">="|"=="|"!=") sum ]
+//	sum     = product { ("+"|"-") product }
+//	product = unary { ("*"|"/") unary }
+//	unary   = "-" unary | primary
+//	primary = number | true | false | name | name "(" args ")" | "(" or ")"
+type parser struct {
+	tokens []token
+	i      int
+	params map[string]float64
+}
+
+// parseExpr разбирает выражение; params подставляются как константы, поэтому
+// параметр годится и как период индикатора.
+func parseExpr(text string, offset int, params map[string]float64) (node, error) {
+	tokens, err := tokenize(text, offset)
+	if err != nil {
+		return nil, err
+	}
+	p := &parser{tokens: tokens, params: params}
+	n, err := p.or()
+	if err != nil {
+		return nil, err
+	}
+	if t := p.peek(); t.kind != "eof" {
+		return nil, &posError{pos: t.pos, msg: fmt.Sprintf("unexpected %q", t.text)}
+	}
+	return n, nil
+}
+
+func (p *parser) peek() token { return p.tokens[p.i] }
+
+func (p *parser) next() token {
+	t := p.tokens[p.i]
+	if t.kind != "eof" {
+		p.i++
+	}
+	return t
+}
+
+func (p *parser) accept(ops ...string) (token, bool) {
+	t := p.peek()
+	if t.kind != "op" {
+		return t, false
+	}
+	for _, op := range ops {
+		if t.text == op {
+			p.i++
+			return t, true
+		}
+	}
+	return t, false
+}
+
+func (p *parser) expect(op string) error {
+	if _, ok := p.accept(op); !ok {
+		t := p.peek()
+		return &posError{pos: t.pos, msg: fmt.Sprintf("expected %q, got %s", op, describe(t))}
+	}
+	return nil
+}
+
+func describe(t token) string {
+	if t.kind == "eof" {
+		return "end of expression"
+	}
+	return strconv.Quote(t.text)
+}
+
+func want(t token, n node, typ valueType, op string) error {
+	if n.typ() != typ {
+		return &posError{pos: t.pos, msg: fmt.Sprintf("%q needs %s operands, got %s", op, typ, n.typ())}
+	}
+	return nil
+}
+
+func (p *parser) logical(op string, operand func() (node, error)) (node, error) {
+	l, err := operand()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		t, ok := p.accept(op)
+		if !ok {
+			return l, nil
+		}
+		r, err := operand()
+		if err != nil {
+			return nil, err
+		}
+		if err := want(t, l, boolType, op); err != nil {
+			return nil, err
+		}
+		if err := want(t, r, boolType, op); err != nil {
+			return nil, err
+		}
+		l = binaryNode{op: op, l: l, r: r}
+	}
+}
+
+func (p *parser) or() (node, error)  { return p.logical("||", p.and) }
+func (p *parser) and() (node, error) { return p.logical("&&", p.not) }
+
+func (p *parser) not() (node, error) {
+	if t, ok := p.accept("!"); ok {
+		x, err := p.not()
+		if err != nil {
+			return nil, err
+		}
+		if err := want(t, x, boolType, "!"); err != nil {
+			return nil, err
+		}
+		return unaryNode{op: "!", x: x}, nil
+	}
+	return p.compare()
+}
+
+func (p *parser) compare() (node, error) {
+	l, err := p.sum()
+	if err != nil {
+		return nil, err
+	}
+	t, ok := p.accept("<", "<=", ">", ">=", "==", "!=")
+	if !ok {
+		return l, nil
+	}
+	r, err := p.sum()
+	if err != nil {
+		return nil, err
+	}
+	if err := want(t, l, numberType, t.text); err != nil {
+		return nil, err
+	}
+	if err := want(t, r, numberType, t.text); err != nil {
+		return nil, err
+	}
+	return binaryNode{op: t.text, l: l, r: r}, nil
+}
+
+func (p *parser) arithmetic(ops []string, operand func() (node, error)) (node, error) {
+	l, err := operand()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		t, ok := p.accept(ops...)
+		if !ok {
+			return l, nil
+		}
+		r, err := operand()
+		if err != nil {
+			return nil, err
+		}
+		if err := want(t, l, numberType, t.text); err != nil {
+			return nil, err
+		}
+		if err := want(t, r, numberType, t.text); err != nil {
+			return nil, err
+		}
+		l = binaryNode{op: t.text, l: l, r: r}
+	}
+}
+
+func (p *parser) sum() (node, error)     { return p.arithmetic([]string{"+", "-"}, p.product) }
+func (p *parser) product() (node, error) { return p.arithmetic([]string{"*", "/"}, p.unary) }
+
+func (p *parser) unary() (node, error) {
+	if t, ok := p.accept("-"); ok {
+		x, err := p.unary()
+		if err != nil {
+			return nil, err
+		}
+		if err := want(t, x, numberType, "-"); err != nil {
+			return nil, err
+		}
+		return unaryNode{op: "-", x: x}, nil
+	}
+	return p.primary()
+}
+
+func (p *parser) primary() (node, error) {
+	t := p.next()
+	switch t.kind {
+	case "number":
+		v, err := strconv.ParseFloat(t.text, 64)
+		if err != nil {
+			return nil, &posError{pos: t.pos, msg: fmt.Sprintf("invalid number %q", t.text)}
+		}
+		return numberNode(v), nil
+	case "ident":
+		switch t.text {
+		case "true":
+			return boolNode(true), nil
+		case "false":
+			return boolNode(false), nil
+		}
+		if _, ok := p.accept("("); ok {
+			return p.call(t)
+		}
+		if v, ok := p.params[t.text]; ok {
+			return numberNode(v), nil
+		}
+		if get, ok := flags[t.text]; ok {
+			return flagNode{name: t.text, get: get}, nil
+		}
+		get, ok := variables[t.text]
+		if !ok {
+			return nil, &posError{pos: t.pos, msg: fmt.Sprintf("unknown variable %q", t.text)}
+		}
+		return varNode{name: t.text, get: get}, nil
+	case "op":
+		if t.text == "(" {
+			n, err := p.or()
+			if err != nil {
+				return nil, err
+			}
+			if err := p.expect(")"); err != nil {
+				return nil, err
+			}
+			return n, nil
+		}
+	}
+	return nil, &posError{pos: t.pos, msg: fmt.Sprintf("unexpected %s", describe(t))}
+}
+
+// call разбирает аргументы функции name, открывающая скобка уже съедена.
+func (p *parser) call(name token) (node, error) {
+	var args []node
+	if _, ok := p.accept(")"); !ok {
+		for {
+			arg, err := p.or()
+			if err != nil {
+				return nil, err
+			}
+			args = append(args, arg)
+			if _, ok := p.accept(","); ok {
+				continue
+			}
+			if err := p.expect(")"); err != nil {
+				return nil, err
+			}
+			break
+		}
+	}
+
+	if fn, ok := indicatorFuncs[name.text]; ok {
+		period, ok := constPeriod(args)
+		if !ok {
+			return nil, &posError{pos: name.pos, msg: fmt.Sprintf("%s() takes one integer period from 1 to %d", name.text, maxPeriod)}
+		}
+		return indicatorNode{name: name.text, period: period, fn: fn}, nil
+	}
+	if fn, ok := mathFuncs[name.text]; ok {
+		if len(args) != fn.arity {
+			return nil, &posError{pos: name.pos, msg: fmt.Sprintf("%s() takes %d argument(s), got %d", name.text, fn.arity, len(args))}
+		}
+		for _, arg := range args {
+			if err := want(name, arg, numberType, name.text); err != nil {
+				return nil, err
+			}
+		}
+		return mathNode{fn: fn.fn, args: args}, nil
+	}
+	return nil, &posError{pos: name.pos, msg: fmt.Sprintf("unknown function %q", name.text)}
+}
+
+func constPeriod(args []node) (int, bool) {
+	if len(args) != 1 {
+		return 0, false
+	}
+	n, ok := args[0].(numberNode)
+	if !ok || float64(n) != math.Trunc(float64(n)) || n < 1 || n > maxPeriod {
+		return 0, false
+	}
+	return int(n), true
+}
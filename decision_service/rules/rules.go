@@ -0,0 +1,319 @@
+// Package rules — декларативные правила стратегии, которые читаются из файла
+// и меняются без пересборки сервиса.
+//
+// Каждая строка файла — правило "<decision> [confidence] when <expr>",
+// "default <decision> [confidence]" или параметр "param <name> = <number>";
+// # начинает комментарий. Правила проверяются сверху вниз, срабатывает первое
+// истинное:
+//
+//	param oversold = 30
+//	buy 0.7 when rsi(14) < oversold && price > sma(20)
+//	sell when rsi(14) > 70 || change(7) < -15
+//	default hold
+//
+// В выражениях доступны price, volume, high_24h, low_24h, change_24h,
+// показатели режима рынка trend_strength и volatility_ratio, флаги режима
+// trending, ranging и high_volatility, индикаторы sma, ema, rsi, volatility,
+// atr, change с периодом-константой, abs, min, max, арифметика, сравнения и
+// && || !. Параметры объявляются до использования и могут быть
+// переопределены файлом параметров, например результатом cmd/optimize.
+package rules
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Snapshot — рыночные данные для правил. Close, High и Low — ряды от старых
+// к новым с текущей ценой последней точкой.
+type Snapshot struct {
+	Price          float64
+	Volume         float64
+	High24h        float64
+	Low24h         float64
+	DailyChangePct float64
+	Close          []float64
+	High           []float64
+	Low            []float64
+	// Regime — режим рынка из пакета regime, пусто — неизвестен
+	Regime          string
+	TrendStrength   float64
+	VolatilityRatio float64
+}
+
+// Rule — одно правило файла.
+type Rule struct {
+	Line       int     `json:"line"`
+	Decision   string  `json:"decision"`
+	Confidence float64 `json:"confidence,omitempty"`
+	Expr       string  `json:"expr"`
+	cond       node
+}
+
+// RuleSet — разобранный и проверенный файл правил.
+type RuleSet struct {
+	Name    string `json:"name"`
+	Version string `json:"version"` // хэш содержимого
+	Rules   []Rule `json:"rules"`
+	Default *Rule  `json:"default,omitempty"`
+	// Params — действующие значения параметров с учётом переопределений
+	Params map[string]float64 `json:"params,omitempty"`
+}
+
+// ValidationError перечисляет все ошибки файла сразу, а не только первую.
+type ValidationError struct {
+	Name     string
+	Problems []string
+}
+
+func (e *ValidationError) Error() string {
+	return fmt.Sprintf("invalid rules %s:\n%s", e.Name, strings.Join(e.Problems, "\n"))
+}
+
+// Parse разбирает и проверяет правила: синтаксис, имена переменных и
+// функций, типы операндов и периоды индикаторов.
+func Parse(name string, text []byte) (*RuleSet, error) {
+	return ParseWithParams(name, text, nil)
+}
+
+// ParseWithParams разбирает правила, заменяя значения объявленных параметров
+// на overrides. Переопределение необъявленного параметра — ошибка: скорее
+// всего файл параметров собран для другой версии правил.
+func ParseWithParams(name string, text []byte, overrides map[string]float64) (*RuleSet, error) {
+	set := &RuleSet{Name: name, Version: version(text, overrides)}
+	verr := &ValidationError{Name: name}
+	fail := func(line, col int, format string, args ...any) {
+		verr.Problems = append(verr.Problems, fmt.Sprintf("%s:%d:%d: %s", name, line, col, fmt.Sprintf(format, args...)))
+	}
+
+	for i, raw := range strings.Split(string(text), "\n") {
+		line := i + 1
+		if before, _, found := strings.Cut(raw, "#"); found {
+			raw = before
+		}
+		if strings.TrimSpace(raw) == "" {
+			continue
+		}
+		indent := len(raw) - len(strings.TrimLeft(raw, " \t"))
+
+		head, expr, hasWhen := cutWord(raw, "when")
+		fields := strings.Fields(head)
+		if len(fields) == 0 {
+			fail(line, indent+1, "missing decision before \"when\"")
+			continue
+		}
+
+		if fields[0] == "param" {
+			param, value, err := parseParam(raw)
+			if err != nil {
+				fail(line, indent+1, "%v", err)
+				continue
+			}
+			if _, dup := set.Params[param]; dup {
+				fail(line, indent+1, "duplicate param %q", param)
+				continue
+			}
+			if v, ok := overrides[param]; ok {
+				value = v
+			}
+			if set.Params == nil {
+				set.Params = map[string]float64{}
+			}
+			set.Params[param] = value
+			continue
+		}
+
+		if fields[0] == "default" {
+			if hasWhen {
+				fail(line, indent+1, "default rule has no condition")
+				continue
+			}
+			rule, err := parseHead(fields[1:])
+			if err != nil {
+				fail(line, indent+1, "%v", err)
+				continue
+			}
+			if set.Default != nil {
+				fail(line, indent+1, "duplicate default, first one is on line %d", set.Default.Line)
+				continue
+			}
+			rule.Line = line
+			set.Default = &rule
+			continue
+		}
+
+		if !hasWhen {
+			fail(line, indent+1, `expected "<decision> [confidence] when <expr>"`)
+			continue
+		}
+		rule, err := parseHead(fields)
+		if err != nil {
+			fail(line, indent+1, "%v", err)
+			continue
+		}
+		offset := len(raw) - len(expr)
+		cond, err := parseExpr(expr, offset, set.Params)
+		if err != nil {
+			col := offset + 1
+			if pe, ok := err.(*posError); ok {
+				col = pe.pos
+			}
+			fail(line, col, "%v", err)
+			continue
+		}
+		if cond.typ() != boolType {
+			fail(line, offset+1, "condition must be a comparison or logical expression, got %s", cond.typ())
+			continue
+		}
+		rule.Line = line
+		rule.Expr = strings.TrimSpace(expr)
+		rule.cond = cond
+		set.Rules = append(set.Rules, rule)
+	}
+
+	for _, param := range sortedKeys(overrides) {
+		if _, ok := set.Params[param]; !ok {
+			verr.Problems = append(verr.Problems, fmt.Sprintf("%s: unknown param %q", name, param))
+		}
+	}
+	if len(verr.Problems) > 0 {
+		return nil, verr
+	}
+	if len(set.Rules) == 0 && set.Default == nil {
+		return nil, &ValidationError{Name: name, Problems: []string{name + ": no rules"}}
+	}
+	return set, nil
+}
+
+var paramName = regexp.MustCompile(`^[a-z_][a-z0-9_]*$`)
+
+// reserved — имена, которые нельзя занять параметром.
+var reserved = map[string]bool{
+	"true": true, "false": true, "when": true, "param": true, "default": true,
+	"buy": true, "sell": true, "hold": true,
+}
+
+// parseParam разбирает строку "param <name> = <number>".
+func parseParam(raw string) (string, float64, error) {
+	decl := strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(raw), "param"))
+	name, value, ok := strings.Cut(decl, "=")
+	if !ok {
+		return "", 0, fmt.Errorf(`expected "param <name> = <number>"`)
+	}
+	name = strings.TrimSpace(name)
+	if !paramName.MatchString(name) {
+		return "", 0, fmt.Errorf("invalid param name %q", name)
+	}
+	_, isVar := variables[name]
+	_, isFlag := flags[name]
+	_, isIndicator := indicatorFuncs[name]
+	_, isMath := mathFuncs[name]
+	if reserved[name] || isVar || isFlag || isIndicator || isMath {
+		return "", 0, fmt.Errorf("param name %q is reserved", name)
+	}
+	v, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+	if err != nil {
+		return "", 0, fmt.Errorf("param %s value must be a number, got %q", name, strings.TrimSpace(value))
+	}
+	return name, v, nil
+}
+
+// version — хэш текста правил и переопределённых параметров.
+func version(text []byte, overrides map[string]float64) string {
+	h := sha256.New()
+	h.Write(text)
+	for _, k := range sortedKeys(overrides) {
+		fmt.Fprintf(h, "\n%s=%v", k, overrides[k])
+	}
+	return hex.EncodeToString(h.Sum(nil)[:4])
+}
+
+func sortedKeys(m map[string]float64) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// cutWord делит строку по первому отдельному слову word.
+func cutWord(s, word string) (before, after string, found bool) {
+	for i := 0; i+len(word) <= len(s); i++ {
+		if s[i:i+len(word)] != word {
+			continue
+		}
+		startOK := i == 0 || s[i-1] == ' ' || s[i-1] == '\t'
+		end := i + len(word)
+		endOK := end == len(s) || s[end] == ' ' || s[end] == '\t'
+		if startOK && endOK {
+			return s[:i], s[end:], true
+		}
+	}
+	return s, "", false
+}
+
+func parseHead(fields []string) (Rule, error) {
+	if len(fields) == 0 || len(fields) > 2 {
+		return Rule{}, fmt.Errorf(`expected "<decision> [confidence]"`)
+	}
+	rule := Rule{Decision: strings.ToLower(fields[0])}
+	switch rule.Decision {
+	case "buy", "sell", "hold":
+	default:
+		return Rule{}, fmt.Errorf("unknown decision %q, expected buy, sell or hold", fields[0])
+	}
+	if len(fields) == 2 {
+		c, err := strconv.ParseFloat(fields[1], 64)
+		if err != nil || c < 0 || c > 1 {
+			return Rule{}, fmt.Errorf("confidence must be a number from 0 to 1, got %q", fields[1])
+		}
+		rule.Confidence = c
+	}
+	return rule, nil
+}
+
+// Evaluation — результат проверки одного правила.
+type Evaluation struct {
+	Line    int    `json:"line"`
+	Expr    string `json:"expr"`
+	Matched bool   `json:"matched"`
+	Error   string `json:"error,omitempty"`
+}
+
+// Result — решение набора правил и ход проверки.
+type Result struct {
+	Decision    string       `json:"decision"`
+	Confidence  float64      `json:"confidence,omitempty"`
+	Rule        *Rule        `json:"rule,omitempty"` // nil — ни одно правило и нет default
+	Evaluations []Evaluation `json:"evaluations"`
+}
+
+// Evaluate проверяет правила по порядку до первого истинного. Правило, для
+// которого не хватает данных, считается несработавшим.
+func (rs *RuleSet) Evaluate(s *Snapshot) Result {
+	res := Result{Decision: "hold", Evaluations: make([]Evaluation, 0, len(rs.Rules))}
+	for i := range rs.Rules {
+		rule := &rs.Rules[i]
+		ev := Evaluation{Line: rule.Line, Expr: rule.Expr}
+		v, err := rule.cond.eval(s)
+		if err != nil {
+			ev.Error = err.Error()
+		}
+		ev.Matched = err == nil && v != 0
+		res.Evaluations = append(res.Evaluations, ev)
+		if ev.Matched {
+			res.Decision, res.Confidence, res.Rule = rule.Decision, rule.Confidence, rule
+			return res
+		}
+	}
+	if rs.Default != nil {
+		res.Decision, res.Confidence, res.Rule = rs.Default.Decision, rs.Default.Confidence, rs.Default
+	}
+	return res
+}
@@ -0,0 +1,44 @@
+package rules
+
+import (
+	"errors"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestParseExample(t *testing.T) {
+	text, err := os.ReadFile("example.rules")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Parse("example", text); err != nil {
+		t.Fatalf("example.rules: %v", err)
+	}
+}
+
+func TestParseInvalid(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want string
+	}{
+		{name: "missing decision", text: "when rsi(14) < 30", want: `missing decision before "when"`},
+		{name: "indented missing decision", text: "buy 0.7 when rsi(14) < 30\n  when price > 1", want: `:2:3: missing decision before "when"`},
+		{name: "default with condition", text: "default hold when rsi(14) < 30", want: "default rule has no condition"},
+		{name: "duplicate param", text: "param x = 1\nparam x = 2", want: `duplicate param "x"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Parse("test", []byte(tt.text))
+			var verr *ValidationError
+			if !errors.As(err, &verr) {
+				t.Fatalf("Parse(%q) error = %v, want ValidationError", tt.text, err)
+			}
+			if !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Parse(%q) error = %v, want it to mention %q", tt.text, err, tt.want)
+			}
+		})
+	}
+}
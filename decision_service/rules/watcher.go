@@ -0,0 +1,187 @@
+package rules
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// ParamsFile — значения параметров правил, например лучший набор из
+// cmd/optimize. Остальные поля файла Watcher не читает.
+type ParamsFile struct {
+	// Rules — версия правил без переопределений, для которой подобраны параметры
+	Rules  string             `json:"rules,omitempty"`
+	Params map[string]float64 `json:"params"`
+}
+
+// LoadParams читает файл параметров.
+func LoadParams(path string) (*ParamsFile, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read rule params: %w", err)
+	}
+	var pf ParamsFile
+	if err := json.Unmarshal(data, &pf); err != nil {
+		return nil, fmt.Errorf("failed to parse rule params %s: %w", path, err)
+	}
+	return &pf, nil
+}
+
+// DefaultReloadInterval — как часто Watcher проверяет файл на изменения.
+const DefaultReloadInterval = 2 * time.Second
+
+// Watcher держит правила из файла и перечитывает их, когда меняется файл
+// правил или файл параметров. Проверка делается при обращении к правилам,
+// не чаще interval, поэтому фоновая горутина не нужна. Если новая версия файла не проходит проверку,
+// остаются прежние правила, а ошибка видна в Status.
+type Watcher struct {
+	path       string
+	paramsPath string
+	params     map[string]float64
+	interval   time.Duration
+
+	mu       sync.Mutex
+	set      *RuleSet
+	stamp    string
+	checked  time.Time
+	loadedAt time.Time
+	err      error
+}
+
+// Status — какие правила сейчас действуют и чем закончилась последняя
+// попытка перезагрузки.
+type Status struct {
+	Path        string    `json:"path"`
+	ParamsPath  string    `json:"params_path,omitempty"`
+	Version     string    `json:"version"`
+	LoadedAt    time.Time `json:"loaded_at"`
+	ReloadError string    `json:"reload_error,omitempty"`
+}
+
+// Open загружает правила и, если paramsPath не пуст, параметры к ним. Ошибки
+// первой загрузки возвращаются сразу, чтобы сервис не стартовал с неверным
+// файлом.
+func Open(path, paramsPath string, interval time.Duration) (*Watcher, error) {
+	return OpenWithParams(path, paramsPath, nil, interval)
+}
+
+// OpenWithParams — как Open, но params переопределяют значения из файла
+// параметров при каждой загрузке.
+func OpenWithParams(path, paramsPath string, params map[string]float64, interval time.Duration) (*Watcher, error) {
+	if interval <= 0 {
+		interval = DefaultReloadInterval
+	}
+	w := &Watcher{path: path, paramsPath: paramsPath, params: params, interval: interval}
+	stamp, err := w.stat()
+	if err != nil {
+		return nil, err
+	}
+	if err := w.load(stamp); err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+// Rules возвращает действующие правила, при необходимости перечитав файл.
+func (w *Watcher) Rules() *RuleSet {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	now := time.Now()
+	if now.Sub(w.checked) < w.interval {
+		return w.set
+	}
+	w.checked = now
+
+	stamp, err := w.stat()
+	if err != nil {
+		w.fail(err)
+		return w.set
+	}
+	if stamp == w.stamp {
+		return w.set
+	}
+	if err := w.load(stamp); err != nil {
+		w.fail(err)
+	}
+	return w.set
+}
+
+// stat возвращает отпечаток файлов по времени изменения и размеру.
+func (w *Watcher) stat() (string, error) {
+	stamp := ""
+	for _, path := range []string{w.path, w.paramsPath} {
+		if path == "" {
+			continue
+		}
+		info, err := os.Stat(path)
+		if err != nil {
+			return "", fmt.Errorf("failed to read rules: %w", err)
+		}
+		stamp += fmt.Sprintf("%d/%d;", info.ModTime().UnixNano(), info.Size())
+	}
+	return stamp, nil
+}
+
+func (w *Watcher) Status() Status {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	s := Status{Path: w.path, ParamsPath: w.paramsPath, Version: w.set.Version, LoadedAt: w.loadedAt}
+	if w.err != nil {
+		s.ReloadError = w.err.Error()
+	}
+	return s
+}
+
+func (w *Watcher) load(stamp string) error {
+	// отпечаток запоминается до разбора, чтобы неверная версия не
+	// разбиралась заново при каждой проверке
+	w.stamp = stamp
+
+	text, err := os.ReadFile(w.path)
+	if err != nil {
+		return fmt.Errorf("failed to read rules: %w", err)
+	}
+	name := filepath.Base(w.path)
+	var overrides map[string]float64
+	if w.paramsPath != "" {
+		pf, err := LoadParams(w.paramsPath)
+		if err != nil {
+			return err
+		}
+		if base := version(text, nil); pf.Rules != "" && pf.Rules != base {
+			log.Printf("rule params %s were tuned for rules %s, current rules are %s", w.paramsPath, pf.Rules, base)
+		}
+		overrides = pf.Params
+	}
+	if len(w.params) > 0 {
+		merged := make(map[string]float64, len(overrides)+len(w.params))
+		for k, v := range overrides {
+			merged[k] = v
+		}
+		for k, v := range w.params {
+			merged[k] = v
+		}
+		overrides = merged
+	}
+	set, err := ParseWithParams(name, text, overrides)
+	if err != nil {
+		return err
+	}
+	if w.set != nil && w.set.Version != set.Version {
+		log.Printf("reloaded rules %s: version %s -> %s", w.path, w.set.Version, set.Version)
+	}
+	w.set, w.err, w.loadedAt = set, nil, time.Now().UTC()
+	return nil
+}
+
+func (w *Watcher) fail(err error) {
+	if w.err == nil || w.err.Error() != err.Error() {
+		log.Printf("keeping rules %s after failed reload: %v", w.set.Version, err)
+	}
+	w.err = err
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/skomaroh1845/crypto_telemetry/decision_service/ai"
+	"github.com/skomaroh1845/crypto_telemetry/decision_service/rules"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// RulesDryRunRequest — снимок рынка в формате /v1/decide и, необязательно,
+// текст правил, которые нужно проверить вместо загруженных из RULES_FILE.
+type RulesDryRunRequest struct {
+	ai.MarketData
+	Rules string `json:"rules,omitempty"`
+}
+
+type RulesDryRunResponse struct {
+	rules.Result
+	Version string `json:"version"`
+	// Source — "file" для правил из RULES_FILE, "request" для правил из запроса
+	Source string        `json:"source"`
+	Status *rules.Status `json:"status,omitempty"`
+}
+
+// rulesDryRunHandler проверяет правила на переданном снимке и показывает,
+// какое правило сработало и почему не сработали предыдущие. Ничего не пишет
+// в аудит и не торгует.
+func (h *Handler) rulesDryRunHandler(w http.ResponseWriter, r *http.Request) {
+	_, span := tracer.Start(r.Context(), "rules-dry-run",
+		trace.WithAttributes(attribute.String("handler", "rules-dry-run")),
+	)
+	defer span.End()
+
+	var req RulesDryRunRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxDecideBody)).Decode(&req); err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "Invalid request body")
+		http.Error(w, "Invalid request body: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := validateSnapshot(&req.MarketData); err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "Invalid market snapshot")
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	resp := RulesDryRunResponse{Source: "request"}
+	var set *rules.RuleSet
+	switch {
+	case req.Rules != "":
+		var err error
+		if set, err = rules.Parse("request", []byte(req.Rules)); err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, "Invalid rules")
+			var verr *rules.ValidationError
+			if errors.As(err, &verr) {
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(http.StatusBadRequest)
+				_ = json.NewEncoder(w).Encode(map[string][]string{"errors": verr.Problems})
+				return
+			}
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	case h.rules != nil:
+		set = h.rules.Rules()
+		status := h.rules.Status()
+		resp.Source, resp.Status = "file", &status
+	default:
+		span.SetStatus(codes.Error, "Rules are not configured")
+		http.Error(w, "Rules are not configured: set RULES_FILE or pass rules in the request", http.StatusNotFound)
+		return
+	}
+
+	resp.Result = ai.EvaluateRules(set, req.MarketData)
+	resp.Version = set.Version
+	span.SetAttributes(
+		attribute.String("symbol", req.Symbol),
+		attribute.String("rules.source", resp.Source),
+		attribute.String("rules.version", set.Version),
+		attribute.String("decision", resp.Decision),
+	)
+	span.SetStatus(codes.Ok, "Rules evaluated")
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(resp)
+}
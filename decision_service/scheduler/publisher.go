@@ -0,0 +1,60 @@
+package scheduler
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+)
+
+// NotifierPublisher отправляет события в notifier_service, который рассылает
+// их подписчикам в Telegram.
+type NotifierPublisher struct {
+	url    string
+	client *http.Client
+}
+
+func NewNotifierPublisher(baseURL string, timeout time.Duration) *NotifierPublisher {
+	return &NotifierPublisher{
+		url: strings.TrimSuffix(baseURL, "/") + "/api/v1/notification",
+		client: &http.Client{
+			Timeout:   timeout,
+			Transport: otelhttp.NewTransport(http.DefaultTransport),
+		},
+	}
+}
+
+func (p *NotifierPublisher) Publish(ctx context.Context, event Event) error {
+	return p.Send(ctx, event)
+}
+
+// Send отправляет в notifier произвольное событие с полем type, например
+// оповещение для админского чата.
+func (p *NotifierPublisher) Send(ctx context.Context, event any) error {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to marshal event: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to reach notifier: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("notifier returned %d", resp.StatusCode)
+	}
+	return nil
+}
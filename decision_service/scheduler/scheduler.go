@@ -0,0 +1,300 @@
+// Package scheduler принимает решения по расписанию без запроса пользователя
+// и публикует события, когда сигнал по символу меняется.
+package scheduler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/skomaroh1845/crypto_telemetry/decision_service/ai"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Job — набор символов, которые одна стратегия оценивает по одному расписанию.
+// Пустая стратегия означает боевую стратегию сервиса.
+type Job struct {
+	Symbols  []string
+	Strategy string
+	Schedule Schedule
+	Spec     string
+}
+
+// ParseJobs разбирает задания через ";" в формате
+// "SYMBOLS[:strategy]=schedule", например
+// "BTC,ETH=*/15 * * * *;SOL:groq=@every 1h". Расписание считается в UTC.
+func ParseJobs(spec string) ([]Job, error) {
+	var jobs []Job
+	for _, part := range strings.Split(spec, ";") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		target, schedule, ok := strings.Cut(part, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid job %q: expected SYMBOLS[:strategy]=schedule", part)
+		}
+		symbolsText, strategy, _ := strings.Cut(target, ":")
+
+		job := Job{Strategy: strings.ToLower(strings.TrimSpace(strategy)), Spec: strings.TrimSpace(schedule)}
+		for _, symbol := range strings.Split(symbolsText, ",") {
+			if symbol = strings.ToUpper(strings.TrimSpace(symbol)); symbol != "" {
+				job.Symbols = append(job.Symbols, symbol)
+			}
+		}
+		if len(job.Symbols) == 0 {
+			return nil, fmt.Errorf("invalid job %q: no symbols", part)
+		}
+		s, err := ParseSchedule(job.Spec)
+		if err != nil {
+			return nil, err
+		}
+		job.Schedule = s
+		jobs = append(jobs, job)
+	}
+	return jobs, nil
+}
+
+// Decider принимает решение по символу заданной стратегией.
+type Decider func(ctx context.Context, symbol, strategy string) (ai.DecisionResponse, float64, error)
+
+// Signal — последнее известное решение по символу и стратегии.
+type Signal struct {
+	Symbol   string    `json:"symbol"`
+	Strategy string    `json:"strategy"`
+	Decision string    `json:"decision"`
+	Price    float64   `json:"price"`
+	Time     time.Time `json:"time"`
+}
+
+// Event — смена сигнала, которая рассылается подписчикам.
+type Event struct {
+	Type       string    `json:"type"`
+	Symbol     string    `json:"symbol"`
+	Strategy   string    `json:"strategy"`
+	Previous   string    `json:"previous"`
+	Decision   string    `json:"decision"`
+	Confidence float64   `json:"confidence,omitempty"`
+	Reason     string    `json:"reason,omitempty"`
+	Horizon    string    `json:"horizon,omitempty"`
+	Price      float64   `json:"price"`
+	Time       time.Time `json:"time"`
+}
+
+// EventSignalChange — тип события смены сигнала.
+const EventSignalChange = "signal_change"
+
+// Publisher доставляет событие подписчикам.
+type Publisher interface {
+	Publish(ctx context.Context, event Event) error
+}
+
+// ChangeHook вызывается для каждой доставленной смены сигнала, например для метрик.
+type ChangeHook func(ctx context.Context, event Event)
+
+// Scheduler запускает задания по расписанию и помнит последний сигнал по
+// каждой паре символ/стратегия. Состояние сохраняется в JSON, чтобы после
+// перезапуска не рассылать повторно уже известные сигналы.
+type Scheduler struct {
+	jobs      []Job
+	decide    Decider
+	publisher Publisher
+	onChange  ChangeHook
+
+	mu      sync.Mutex
+	path    string
+	signals map[string]Signal
+}
+
+func New(jobs []Job, decide Decider, publisher Publisher, path string, onChange ChangeHook) (*Scheduler, error) {
+	s := &Scheduler{
+		jobs:      jobs,
+		decide:    decide,
+		publisher: publisher,
+		onChange:  onChange,
+		path:      path,
+		signals:   map[string]Signal{},
+	}
+	if path == "" {
+		return s, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read signal state: %w", err)
+	}
+	if err := json.Unmarshal(data, &s.signals); err != nil {
+		return nil, fmt.Errorf("failed to parse signal state: %w", err)
+	}
+	return s, nil
+}
+
+// Run запускает каждое задание в своей горутине и ждёт отмены контекста.
+// Следующий запуск задания не начнётся, пока не закончился предыдущий.
+func (s *Scheduler) Run(ctx context.Context) {
+	var wg sync.WaitGroup
+	for _, job := range s.jobs {
+		wg.Add(1)
+		go func(job Job) {
+			defer wg.Done()
+			s.runJob(ctx, job)
+		}(job)
+	}
+	wg.Wait()
+}
+
+func (s *Scheduler) runJob(ctx context.Context, job Job) {
+	for {
+		now := time.Now().UTC()
+		next := job.Schedule.Next(now)
+		if next.IsZero() {
+			log.Printf("schedule %q never fires, job for %s stopped", job.Spec, strings.Join(job.Symbols, ","))
+			return
+		}
+
+		timer := time.NewTimer(next.Sub(now))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+		s.RunJob(ctx, job)
+	}
+}
+
+// RunJob оценивает все символы задания и публикует смены сигналов.
+func (s *Scheduler) RunJob(ctx context.Context, job Job) {
+	ctx, span := otel.Tracer("signal-scheduler").Start(ctx, "scheduler.run",
+		trace.WithNewRoot(),
+	)
+	defer span.End()
+
+	span.SetAttributes(
+		attribute.String("schedule", job.Spec),
+		attribute.String("strategy", job.Strategy),
+		attribute.StringSlice("symbols", job.Symbols),
+	)
+
+	changes, failed := 0, 0
+	for _, symbol := range job.Symbols {
+		changed, err := s.evaluate(ctx, symbol, job.Strategy)
+		if err != nil {
+			failed++
+			span.RecordError(err)
+			log.Printf("scheduled decision for %s failed: %v", symbol, err)
+			continue
+		}
+		if changed {
+			changes++
+		}
+	}
+
+	span.SetAttributes(
+		attribute.Int("signal.changes", changes),
+		attribute.Int("signal.failed", failed),
+	)
+	if failed > 0 {
+		span.SetStatus(codes.Error, "Some scheduled decisions failed")
+		return
+	}
+	span.SetStatus(codes.Ok, "Scheduled decisions completed")
+}
+
+func (s *Scheduler) evaluate(ctx context.Context, symbol, strategy string) (bool, error) {
+	decision, price, err := s.decide(ctx, symbol, strategy)
+	if err != nil {
+		return false, err
+	}
+
+	current := Signal{
+		Symbol:   symbol,
+		Strategy: strategy,
+		Decision: decision.Decision,
+		Price:    price,
+		Time:     time.Now().UTC(),
+	}
+	key := symbol + "/" + strategy
+
+	s.mu.Lock()
+	previous, known := s.signals[key]
+	s.mu.Unlock()
+
+	// первый сигнал только запоминается: менять ещё нечего
+	if !known || previous.Decision == current.Decision {
+		s.commit(key, current)
+		return false, nil
+	}
+
+	if strategy == "" {
+		strategy = decision.Strategy
+	}
+	event := Event{
+		Type:       EventSignalChange,
+		Symbol:     symbol,
+		Strategy:   strategy,
+		Previous:   previous.Decision,
+		Decision:   current.Decision,
+		Confidence: decision.Confidence,
+		Reason:     decision.Reason,
+		Horizon:    decision.Horizon,
+		Price:      price,
+		Time:       current.Time,
+	}
+	// новый сигнал запоминается только после доставки: если уведомитель
+	// недоступен, смена будет найдена и отправлена при следующем запуске
+	if s.publisher != nil {
+		if err := s.publisher.Publish(ctx, event); err != nil {
+			return false, fmt.Errorf("failed to publish signal change: %w", err)
+		}
+	}
+	s.commit(key, current)
+	if s.onChange != nil {
+		s.onChange(ctx, event)
+	}
+	return true, nil
+}
+
+func (s *Scheduler) commit(key string, signal Signal) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.signals[key] = signal
+	if err := s.save(); err != nil {
+		log.Printf("failed to save signal state: %v", err)
+	}
+}
+
+// save пишет состояние во временный файл и атомарно переименовывает его.
+func (s *Scheduler) save() error {
+	if s.path == "" {
+		return nil
+	}
+	data, err := json.MarshalIndent(s.signals, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal signal state: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(s.path), 0o755); err != nil {
+		return fmt.Errorf("failed to create signal state dir: %w", err)
+	}
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return fmt.Errorf("failed to write signal state: %w", err)
+	}
+	if err := os.Rename(tmp, s.path); err != nil {
+		return fmt.Errorf("failed to save signal state: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,238 @@
+package sentiment
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"html"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+// maxFeedSize — больше этого ответ ленты не читается.
+const maxFeedSize = 5 << 20
+
+// Feed — лента RSS 2.0, Atom или JSON Feed по адресу http(s):// или из
+// локального файла (путь или file://), что удобно для проверки на фикстурах.
+type Feed struct {
+	location string
+	client   *http.Client
+}
+
+func NewFeed(location string, timeout time.Duration) *Feed {
+	return &Feed{location: location, client: &http.Client{Timeout: timeout}}
+}
+
+// ParseFeeds разбирает список лент через запятую.
+func ParseFeeds(spec string, timeout time.Duration) []Source {
+	var sources []Source
+	for _, part := range strings.Split(spec, ",") {
+		if part = strings.TrimSpace(part); part != "" {
+			sources = append(sources, NewFeed(part, timeout))
+		}
+	}
+	return sources
+}
+
+func (f *Feed) Name() string { return f.location }
+
+func (f *Feed) Fetch(ctx context.Context) ([]Item, error) {
+	body, err := f.read(ctx)
+	if err != nil {
+		return nil, err
+	}
+	items, err := ParseFeed(body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse feed %s: %w", f.location, err)
+	}
+	for i := range items {
+		items[i].Source = f.location
+	}
+	return items, nil
+}
+
+func (f *Feed) read(ctx context.Context) ([]byte, error) {
+	if !strings.HasPrefix(f.location, "http://") && !strings.HasPrefix(f.location, "https://") {
+		body, err := os.ReadFile(strings.TrimPrefix(f.location, "file://"))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read feed: %w", err)
+		}
+		return body, nil
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, f.location, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := f.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch feed %s: %w", f.location, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("feed %s returned status %d", f.location, resp.StatusCode)
+	}
+	return io.ReadAll(io.LimitReader(resp.Body, maxFeedSize))
+}
+
+// ParseFeed разбирает ленту, формат определяется по содержимому.
+func ParseFeed(body []byte) ([]Item, error) {
+	body = bytes.TrimSpace(body)
+	if len(body) == 0 {
+		return nil, fmt.Errorf("empty feed")
+	}
+	if body[0] == '{' {
+		return parseJSONFeed(body)
+	}
+	return parseXMLFeed(body)
+}
+
+type jsonFeed struct {
+	Items []struct {
+		ID            string `json:"id"`
+		URL           string `json:"url"`
+		Title         string `json:"title"`
+		Summary       string `json:"summary"`
+		ContentText   string `json:"content_text"`
+		ContentHTML   string `json:"content_html"`
+		DatePublished string `json:"date_published"`
+	} `json:"items"`
+}
+
+func parseJSONFeed(body []byte) ([]Item, error) {
+	var feed jsonFeed
+	if err := json.Unmarshal(body, &feed); err != nil {
+		return nil, err
+	}
+	items := make([]Item, 0, len(feed.Items))
+	for _, e := range feed.Items {
+		summary := e.Summary
+		if summary == "" {
+			summary = e.ContentText
+		}
+		if summary == "" {
+			summary = e.ContentHTML
+		}
+		items = append(items, newItem(e.ID, e.URL, e.Title, summary, e.DatePublished))
+	}
+	return items, nil
+}
+
+// xmlFeed покрывает и RSS 2.0 (channel/item), и Atom (entry).
+type xmlFeed struct {
+	XMLName xml.Name
+	Items   []struct {
+		GUID        string `xml:"guid"`
+		Link        string `xml:"link"`
+		Title       string `xml:"title"`
+		Description string `xml:"description"`
+		PubDate     string `xml:"pubDate"`
+	} `xml:"channel>item"`
+	Entries []struct {
+		ID    string `xml:"id"`
+		Links []struct {
+			Href string `xml:"href,attr"`
+			Rel  string `xml:"rel,attr"`
+		} `xml:"link"`
+		Title     string `xml:"title"`
+		Summary   string `xml:"summary"`
+		Content   string `xml:"content"`
+		Published string `xml:"published"`
+		Updated   string `xml:"updated"`
+	} `xml:"entry"`
+}
+
+func parseXMLFeed(body []byte) ([]Item, error) {
+	var feed xmlFeed
+	if err := xml.Unmarshal(body, &feed); err != nil {
+		return nil, err
+	}
+	switch feed.XMLName.Local {
+	case "rss", "feed":
+	default:
+		return nil, fmt.Errorf("unsupported feed root <%s>, expected rss, Atom feed or JSON Feed", feed.XMLName.Local)
+	}
+
+	items := make([]Item, 0, len(feed.Items)+len(feed.Entries))
+	for _, e := range feed.Items {
+		items = append(items, newItem(e.GUID, e.Link, e.Title, e.Description, e.PubDate))
+	}
+	for _, e := range feed.Entries {
+		var link string
+		for _, l := range e.Links {
+			if l.Rel == "" || l.Rel == "alternate" {
+				link = l.Href
+				break
+			}
+		}
+		summary := e.Summary
+		if summary == "" {
+			summary = e.Content
+		}
+		published := e.Published
+		if published == "" {
+			published = e.Updated
+		}
+		items = append(items, newItem(e.ID, link, e.Title, summary, published))
+	}
+	return items, nil
+}
+
+func newItem(id, link, title, summary, published string) Item {
+	item := Item{
+		ID:      strings.TrimSpace(id),
+		Link:    strings.TrimSpace(link),
+		Title:   strings.TrimSpace(stripTags(title)),
+		Summary: strings.TrimSpace(stripTags(summary)),
+	}
+	if item.ID == "" {
+		item.ID = item.Link
+	}
+	if item.ID == "" {
+		item.ID = item.Title
+	}
+	item.Published, _ = parseTime(published)
+	return item
+}
+
+var timeLayouts = []string{
+	time.RFC3339,
+	time.RFC1123Z,
+	time.RFC1123,
+	"Mon, 2 Jan 2006 15:04:05 -0700",
+	"Mon, 2 Jan 2006 15:04:05 MST",
+	"2006-01-02 15:04:05",
+}
+
+func parseTime(s string) (time.Time, bool) {
+	s = strings.TrimSpace(s)
+	for _, layout := range timeLayouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t.UTC(), true
+		}
+	}
+	return time.Time{}, false
+}
+
+// stripTags убирает HTML-разметку из описаний и раскрывает сущности.
+func stripTags(s string) string {
+	var b strings.Builder
+	inTag := false
+	for _, r := range s {
+		switch {
+		case r == '<':
+			inTag = true
+		case r == '>' && inTag:
+			inTag = false
+			b.WriteByte(' ')
+		case !inTag:
+			b.WriteRune(r)
+		}
+	}
+	return strings.Join(strings.Fields(html.UnescapeString(b.String())), " ")
+}
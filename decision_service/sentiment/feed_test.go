@@ -0,0 +1,169 @@
+package sentiment
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestFeedFixtures(t *testing.T) {
+	tests := []struct {
+		file  string
+		items []Item
+	}{
+		{
+			file: "testdata/news.rss",
+			items: []Item{
+				{
+					ID:        "rss-1",
+					Title:     "Bitcoin surges to record high as ETF inflows jump",
+					Summary:   "BTC gained 6% after strong inflows.",
+					Link:      "https://example.com/news/1",
+					Published: time.Date(2025, 1, 6, 9, 0, 0, 0, time.UTC),
+				},
+				{
+					ID:        "rss-2",
+					Title:     "Exchange hacked, ETH withdrawals halted",
+					Summary:   "Ether slumps as traders fear liquidations.",
+					Link:      "https://example.com/news/2",
+					Published: time.Date(2025, 1, 6, 8, 0, 0, 0, time.UTC),
+				},
+				{
+					ID:        "rss-3",
+					Title:     "Regulator says spot Solana ETF not approved yet",
+					Link:      "https://example.com/news/3",
+					Published: time.Date(2025, 1, 6, 7, 30, 0, 0, time.UTC),
+				},
+			},
+		},
+		{
+			file: "testdata/news.atom",
+			items: []Item{
+				{
+					ID:        "urn:market-wire:1",
+					Title:     "Bitcoin miners face warning over network delays",
+					Summary:   "BTC fees rise while blocks are delayed.",
+					Link:      "https://example.org/wire/1",
+					Published: time.Date(2025, 1, 6, 6, 0, 0, 0, time.UTC),
+				},
+			},
+		},
+		{
+			file: "testdata/news.json",
+			items: []Item{
+				{
+					ID:        "json-1",
+					Title:     "Ethereum upgrade launches, staking adoption rises",
+					Summary:   "The ETH network upgrade went live without issues.",
+					Link:      "https://example.net/digest/1",
+					Published: time.Date(2025, 1, 6, 10, 0, 0, 0, time.UTC),
+				},
+				{
+					ID:        "json-2",
+					Title:     "Weekly recap of DeFi protocols",
+					Link:      "https://example.net/digest/2",
+					Published: time.Date(2025, 1, 5, 12, 0, 0, 0, time.UTC),
+				},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.file, func(t *testing.T) {
+			items, err := NewFeed(tt.file, time.Second).Fetch(context.Background())
+			if err != nil {
+				t.Fatalf("Fetch(%s) error: %v", tt.file, err)
+			}
+			if len(items) != len(tt.items) {
+				t.Fatalf("got %d items, want %d: %+v", len(items), len(tt.items), items)
+			}
+			for i, want := range tt.items {
+				want.Source = tt.file
+				if got := items[i]; got != want {
+					t.Errorf("item %d =\n%+v\nwant\n%+v", i, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestParseFeedRejectsUnknownRoot(t *testing.T) {
+	if _, err := ParseFeed([]byte(`<html><body>not a feed</body></html>`)); err == nil {
+		t.Error("ParseFeed accepted an HTML page")
+	}
+	if _, err := ParseFeed([]byte("  ")); err == nil {
+		t.Error("ParseFeed accepted an empty body")
+	}
+}
+
+func TestScoreText(t *testing.T) {
+	tests := []struct {
+		text string
+		want float64
+	}{
+		{text: "Weekly recap of DeFi protocols", want: 0},
+		{text: "", want: 0},
+		{text: "ETF approved", want: 0.5},
+		{text: "ETF not approved yet", want: -0.5},
+		{text: "No crash this week", want: 0.5},
+		{text: "Never a hack", want: -0.5}, // отрицание действует только на следующее слово
+		{text: "Bitcoin SURGES", want: 0.5},
+		{text: "Rally then crash", want: 0},
+		{text: "Exchange hacked, withdrawals halted", want: -0.5},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.text, func(t *testing.T) {
+			if got := ScoreText(tt.text); got != tt.want {
+				t.Errorf("ScoreText(%q) = %v, want %v", tt.text, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestScoreTextFixtureTone(t *testing.T) {
+	items, err := NewFeed("testdata/news.rss", time.Second).Fetch(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, positive := range []bool{true, false, false} {
+		score := ScoreText(items[i].Text())
+		if score <= -1 || score >= 1 {
+			t.Errorf("%s: score %v is outside (-1, 1)", items[i].ID, score)
+		}
+		if (score > 0) != positive {
+			t.Errorf("%s: score %v, want positive=%v", items[i].ID, score, positive)
+		}
+	}
+}
+
+func TestMentions(t *testing.T) {
+	tests := []struct {
+		symbol string
+		text   string
+		want   bool
+	}{
+		{symbol: "BTC", text: "BTC gained 6% after strong inflows.", want: true},
+		{symbol: "btc", text: "BTC gained 6%", want: true},
+		{symbol: "BTC", text: "Bitcoin surges to record high", want: true},
+		{symbol: "BTC", text: "BITCOIN miners", want: true},
+		{symbol: "ETH", text: "Ether slumps as traders fear liquidations", want: true},
+		{symbol: "ETH", text: "Tether prints more stablecoins", want: false},
+		{symbol: "BNB", text: "Binance Coin burn completed", want: true},
+		{symbol: "LINK", text: "LINK jumps after the upgrade", want: true},
+		{symbol: "LINK", text: "Chainlink oracles expand", want: true},
+		{symbol: "LINK", text: "Read the full story via the link below", want: false},
+		{symbol: "LINK", text: "Link to the report", want: false},
+		{symbol: "SOL", text: "A new solution for wallets", want: false},
+		{symbol: "SOL", text: "Regulator says spot Solana ETF not approved yet", want: true},
+		{symbol: "XRP", text: "Weekly recap of DeFi protocols", want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.symbol+"/"+tt.text, func(t *testing.T) {
+			if got := Mentions(tt.symbol, tt.text); got != tt.want {
+				t.Errorf("Mentions(%q, %q) = %v, want %v", tt.symbol, tt.text, got, tt.want)
+			}
+		})
+	}
+}
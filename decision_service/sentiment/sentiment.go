@@ -0,0 +1,131 @@
+// Package sentiment собирает новости из лент, оценивает тон заголовков по
+// каждому символу и сводит оценки в скользящий показатель настроения рынка,
+// который попадает в контекст решения.
+package sentiment
+
+import (
+	"context"
+	"math"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// Item — одна новость из ленты.
+type Item struct {
+	ID        string    `json:"id"`
+	Source    string    `json:"source"`
+	Title     string    `json:"title"`
+	Summary   string    `json:"summary,omitempty"`
+	Link      string    `json:"link,omitempty"`
+	Published time.Time `json:"published"`
+}
+
+func (i Item) Text() string {
+	if i.Summary == "" {
+		return i.Title
+	}
+	return i.Title + ". " + i.Summary
+}
+
+// Source — источник новостей, например лента RSS или API новостного сервиса.
+type Source interface {
+	Name() string
+	Fetch(ctx context.Context) ([]Item, error)
+}
+
+// Scorer оценивает тон новости для символа от -1 (негатив) до 1 (позитив).
+// Новость заранее отобрана по упоминанию символа.
+type Scorer interface {
+	Score(ctx context.Context, symbol string, item Item) (float64, error)
+}
+
+// aliases — как монеты называют в новостях, тикер ищется отдельно.
+var aliases = map[string][]string{
+	"BTC":  {"bitcoin", "xbt"},
+	"ETH":  {"ethereum", "ether"},
+	"SOL":  {"solana"},
+	"XRP":  {"ripple"},
+	"BNB":  {"binance coin"},
+	"ADA":  {"cardano"},
+	"DOGE": {"dogecoin"},
+	"TON":  {"toncoin"},
+	"DOT":  {"polkadot"},
+	"AVAX": {"avalanche"},
+	"LTC":  {"litecoin"},
+	"LINK": {"chainlink"},
+}
+
+// Mentions сообщает, упоминается ли символ в тексте: тикер ищется как слово в
+// верхнем регистре (чтобы не путать LINK и link), названия — без учёта регистра.
+func Mentions(symbol, text string) bool {
+	symbol = strings.ToUpper(symbol)
+	for _, word := range words(text) {
+		if word == symbol {
+			return true
+		}
+	}
+	lower := " " + strings.Join(words(strings.ToLower(text)), " ") + " "
+	for _, alias := range aliases[symbol] {
+		if strings.Contains(lower, " "+alias+" ") {
+			return true
+		}
+	}
+	return false
+}
+
+func words(text string) []string {
+	return strings.FieldsFunc(text, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// lexicon — веса слов, характерных для новостей крипторынка.
+var lexicon = map[string]float64{
+	"surge": 1, "surges": 1, "soar": 1, "soars": 1, "rally": 1, "rallies": 1,
+	"jump": 0.8, "jumps": 0.8, "gain": 0.6, "gains": 0.6, "rise": 0.6, "rises": 0.6,
+	"record": 0.8, "high": 0.4, "bullish": 1, "breakout": 0.8, "rebound": 0.6,
+	"recovery": 0.6, "recovers": 0.6, "approval": 1, "approved": 1, "approves": 1,
+	"adoption": 0.8, "inflows": 0.8, "upgrade": 0.6, "partnership": 0.6,
+	"launch": 0.4, "launches": 0.4, "buy": 0.4, "accumulate": 0.6, "optimism": 0.8,
+
+	"crash": -1, "crashes": -1, "plunge": -1, "plunges": -1, "tumble": -1, "tumbles": -1,
+	"drop": -0.8, "drops": -0.8, "fall": -0.6, "falls": -0.6, "decline": -0.6,
+	"slump": -0.8, "low": -0.4, "bearish": -1, "selloff": -1, "liquidation": -0.8,
+	"liquidations": -0.8, "hack": -1, "hacked": -1, "exploit": -1, "scam": -1,
+	"fraud": -1, "lawsuit": -0.8, "sues": -0.8, "ban": -1, "bans": -1,
+	"crackdown": -1, "outflows": -0.8, "rejected": -0.8, "rejects": -0.8,
+	"delay": -0.4, "delays": -0.4, "fear": -0.8, "warning": -0.6, "bankruptcy": -1,
+}
+
+// negations переворачивают вес следующего слова: "not approved".
+var negations = map[string]bool{"not": true, "no": true, "never": true, "without": true}
+
+// Lexicon оценивает тон по словарю: сумма весов найденных слов, делённая на
+// сумму их модулей плюс один, так что одно слово не даёт крайней оценки.
+// Текст без оценочных слов нейтрален.
+type Lexicon struct{}
+
+func (Lexicon) Score(_ context.Context, _ string, item Item) (float64, error) {
+	return ScoreText(item.Text()), nil
+}
+
+func ScoreText(text string) float64 {
+	var sum, total float64
+	negated := false
+	for _, word := range words(strings.ToLower(text)) {
+		if negations[word] {
+			negated = true
+			continue
+		}
+		if w, ok := lexicon[word]; ok {
+			if negated {
+				w = -w
+			}
+			sum += w
+			total += math.Abs(w)
+		}
+		negated = false
+	}
+	return sum / (total + 1)
+}
@@ -0,0 +1,321 @@
+package sentiment
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+
+	"github.com/skomaroh1845/crypto_telemetry/decision_service/format"
+)
+
+// maxHeadlines — сколько последних заголовков попадает в сводку.
+const maxHeadlines = 3
+
+// neutralBand — оценки ближе к нулю считаются нейтральными.
+const neutralBand = 0.05
+
+// maxSymbols — сколько символов оценивается одновременно; символ, который
+// дольше всех не запрашивали, уступает место новому.
+const maxSymbols = 50
+
+// Summary — настроение новостей по символу за скользящее окно.
+type Summary struct {
+	// Score — от -1 до 1, свежие новости весят больше старых
+	Score     float64    `json:"score"`
+	Items     int        `json:"items"`
+	Positive  int        `json:"positive"`
+	Negative  int        `json:"negative"`
+	Window    string     `json:"window"`
+	UpdatedAt time.Time  `json:"updated_at"`
+	Headlines []Headline `json:"headlines,omitempty"`
+}
+
+type Headline struct {
+	Title     string    `json:"title"`
+	Source    string    `json:"source"`
+	Link      string    `json:"link,omitempty"`
+	Published time.Time `json:"published"`
+	Score     float64   `json:"score"`
+}
+
+// Tracker опрашивает источники, хранит новости за окно и оценки по символам.
+// Новости оцениваются в фоне, в Run, для символов, которые запрашивались за
+// последнее окно, так что сводка никогда не ждёт оценщика.
+type Tracker struct {
+	sources []Source
+	scorer  Scorer
+	window  time.Duration
+	// wake будит Run, когда запрошен новый символ
+	wake chan struct{}
+
+	mu       sync.Mutex
+	items    map[string]Item
+	scores   map[string]map[string]float64 // символ -> ключ новости -> оценка
+	inflight map[string]bool               // символ и ключ новости, которые сейчас оцениваются
+	symbols  map[string]time.Time          // символ -> когда его последний раз запрашивали
+	updated  time.Time
+}
+
+func NewTracker(sources []Source, scorer Scorer, window time.Duration) *Tracker {
+	if scorer == nil {
+		scorer = Lexicon{}
+	}
+	return &Tracker{
+		sources:  sources,
+		scorer:   scorer,
+		window:   window,
+		wake:     make(chan struct{}, 1),
+		items:    map[string]Item{},
+		scores:   map[string]map[string]float64{},
+		inflight: map[string]bool{},
+		symbols:  map[string]time.Time{},
+	}
+}
+
+// Run обновляет новости с заданным интервалом до отмены ctx, а между
+// обновлениями оценивает уже загруженные новости для новых символов.
+func (t *Tracker) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if n, err := t.Refresh(ctx, time.Now()); err != nil {
+			log.Printf("sentiment refresh failed after %d new items: %v", n, err)
+		}
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-t.wake:
+				t.scorePending(ctx)
+				continue
+			case <-ticker.C:
+			}
+			break
+		}
+	}
+}
+
+// Refresh загружает все источники, отбрасывает новости старше окна и
+// оценивает неоценённые для запрошенных символов. Ошибка одного источника не
+// мешает остальным, возвращается число новых новостей.
+func (t *Tracker) Refresh(ctx context.Context, now time.Time) (int, error) {
+	ctx, span := otel.Tracer("sentiment-tracker").Start(ctx, "sentiment.refresh")
+	defer span.End()
+
+	var fresh []Item
+	var errs []error
+	for _, src := range t.sources {
+		items, err := src.Fetch(ctx)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		for _, item := range items {
+			// без даты или с датой из будущего новость считается только что вышедшей
+			if item.Published.IsZero() || item.Published.After(now) {
+				item.Published = now
+			}
+			if now.Sub(item.Published) <= t.window {
+				fresh = append(fresh, item)
+			}
+		}
+	}
+
+	t.mu.Lock()
+	t.prune(now)
+	var added []Item
+	for _, item := range fresh {
+		key := itemKey(item)
+		if _, ok := t.items[key]; ok {
+			continue
+		}
+		t.items[key] = item
+		added = append(added, item)
+	}
+	t.updated = now
+	t.mu.Unlock()
+
+	t.scorePending(ctx)
+
+	span.SetAttributes(
+		attribute.Int("sentiment.sources", len(t.sources)),
+		attribute.Int("sentiment.new_items", len(added)),
+	)
+	if err := errors.Join(errs...); err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "Feed fetch failed")
+		return len(added), err
+	}
+	return len(added), nil
+}
+
+// Summary сводит уже посчитанные оценки новостей о символе и не вызывает
+// оценщик. Символ запоминается, и его новости оцениваются в фоне, так что
+// первый запрос по символу обычно возвращает ok == false. ok == false и
+// тогда, когда за окно до now о символе ничего не писали.
+func (t *Tracker) Summary(_ context.Context, symbol string, now time.Time) (*Summary, bool) {
+	symbol = strings.ToUpper(symbol)
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.remember(symbol, now) {
+		select {
+		case t.wake <- struct{}{}:
+		default:
+		}
+	}
+	s := &Summary{Window: format.Duration(t.window), UpdatedAt: t.updated}
+	var sum, weights float64
+	for key, score := range t.scores[symbol] {
+		item, ok := t.items[key]
+		if !ok {
+			continue
+		}
+		// вес падает линейно от свежей новости к границе окна
+		weight := 1 - float64(now.Sub(item.Published))/float64(t.window)
+		if weight <= 0 || weight > 1 {
+			continue
+		}
+		sum += weight * score
+		weights += weight
+		s.Items++
+		switch {
+		case score > neutralBand:
+			s.Positive++
+		case score < -neutralBand:
+			s.Negative++
+		}
+		s.Headlines = append(s.Headlines, Headline{
+			Title:     item.Title,
+			Source:    item.Source,
+			Link:      item.Link,
+			Published: item.Published,
+			Score:     format.Round(score, 2),
+		})
+	}
+	if s.Items == 0 {
+		return nil, false
+	}
+	s.Score = format.Round(sum/weights, 2)
+	sort.Slice(s.Headlines, func(i, j int) bool {
+		return s.Headlines[i].Published.After(s.Headlines[j].Published)
+	})
+	if len(s.Headlines) > maxHeadlines {
+		s.Headlines = s.Headlines[:maxHeadlines]
+	}
+	return s, true
+}
+
+// remember отмечает запрос символа и сообщает, новый ли он. Если символов
+// больше maxSymbols, забывается тот, что дольше всех не запрашивали.
+// Вызывается под mu.
+func (t *Tracker) remember(symbol string, now time.Time) bool {
+	_, known := t.symbols[symbol]
+	t.symbols[symbol] = now
+	if known {
+		return false
+	}
+	if len(t.symbols) > maxSymbols {
+		oldest := symbol
+		for s, at := range t.symbols {
+			if at.Before(t.symbols[oldest]) {
+				oldest = s
+			}
+		}
+		t.forget(oldest)
+	}
+	return true
+}
+
+// forget забывает символ вместе с его оценками, вызывается под mu.
+func (t *Tracker) forget(symbol string) {
+	delete(t.symbols, symbol)
+	delete(t.scores, symbol)
+}
+
+// scorePending оценивает неоценённые новости для всех запрошенных символов.
+func (t *Tracker) scorePending(ctx context.Context) {
+	t.mu.Lock()
+	symbols := make([]string, 0, len(t.symbols))
+	for s := range t.symbols {
+		symbols = append(symbols, s)
+	}
+	t.mu.Unlock()
+
+	for _, symbol := range symbols {
+		if ctx.Err() != nil {
+			return
+		}
+		t.score(ctx, symbol)
+	}
+}
+
+// score оценивает ещё не оценённые новости, упоминающие символ. Оценщик
+// вызывается без блокировки; новость, которую уже оценивает другой вызов,
+// пропускается, а новость с ошибкой оценки будет оценена позже.
+func (t *Tracker) score(ctx context.Context, symbol string) {
+	t.mu.Lock()
+	known := t.scores[symbol]
+	var todo []Item
+	for key, item := range t.items {
+		if _, ok := known[key]; ok || t.inflight[symbol+"\x00"+key] || !Mentions(symbol, item.Text()) {
+			continue
+		}
+		t.inflight[symbol+"\x00"+key] = true
+		todo = append(todo, item)
+	}
+	t.mu.Unlock()
+
+	for _, item := range todo {
+		key := itemKey(item)
+		score, err := t.scorer.Score(ctx, symbol, item)
+
+		t.mu.Lock()
+		delete(t.inflight, symbol+"\x00"+key)
+		_, tracked := t.symbols[symbol]
+		_, current := t.items[key]
+		if err == nil && tracked && current {
+			if t.scores[symbol] == nil {
+				t.scores[symbol] = map[string]float64{}
+			}
+			t.scores[symbol][key] = math.Max(-1, math.Min(1, score))
+		}
+		t.mu.Unlock()
+		if err != nil {
+			log.Printf("failed to score news %q for %s: %v", item.Title, symbol, err)
+		}
+	}
+}
+
+// prune удаляет новости старше окна вместе с их оценками и символы, которые
+// не запрашивали дольше окна, вызывается под mu.
+func (t *Tracker) prune(now time.Time) {
+	for key, item := range t.items {
+		if now.Sub(item.Published) > t.window {
+			delete(t.items, key)
+			for _, scores := range t.scores {
+				delete(scores, key)
+			}
+		}
+	}
+	for symbol, at := range t.symbols {
+		if now.Sub(at) > t.window {
+			t.forget(symbol)
+		}
+	}
+}
+
+func itemKey(item Item) string {
+	return fmt.Sprintf("%s\x00%s", item.Source, item.ID)
+}
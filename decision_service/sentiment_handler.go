@@ -0,0 +1,57 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/skomaroh1845/crypto_telemetry/decision_service/sentiment"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// SentimentResponse — настроение новостей о символе, как оно попадает в
+// MarketData. Sentiment пустой, если за окно о символе ничего не писали.
+type SentimentResponse struct {
+	Symbol    string             `json:"symbol"`
+	Sentiment *sentiment.Summary `json:"sentiment"`
+}
+
+func (h *Handler) sentimentHandler(w http.ResponseWriter, r *http.Request) {
+	ctx, span := tracer.Start(r.Context(), "sentiment-process",
+		trace.WithAttributes(attribute.String("handler", "sentiment")),
+	)
+	defer span.End()
+
+	if h.sentiment == nil {
+		span.SetStatus(codes.Error, "Sentiment is not configured")
+		http.Error(w, "News sentiment is not configured", http.StatusNotFound)
+		return
+	}
+	symbol := strings.ToUpper(r.URL.Query().Get("symbol"))
+	span.SetAttributes(attribute.String("symbol", symbol))
+	if symbol == "" {
+		err := errors.New("symbol is required")
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "Invalid symbol")
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	resp := SentimentResponse{Symbol: symbol}
+	if summary, ok := h.sentiment.Summary(ctx, symbol, time.Now()); ok {
+		resp.Sentiment = summary
+		span.SetAttributes(
+			attribute.Float64("market.sentiment", summary.Score),
+			attribute.Int("market.sentiment_items", summary.Items),
+		)
+	}
+	span.SetStatus(codes.Ok, "Sentiment summarized")
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(resp)
+}
@@ -0,0 +1,394 @@
+// Package shadow прогоняет стратегии-кандидаты на боевом трафике без влияния
+// на ответ пользователю и сравнивает их решения с боевым.
+package shadow
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/skomaroh1845/crypto_telemetry/decision_service/ai"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Record — ответ теневой стратегии на один боевой запрос.
+type Record struct {
+	Time         time.Time `json:"time"`
+	DecisionID   string    `json:"decision_id,omitempty"` // ID боевого решения в журнале аудита
+	Symbol       string    `json:"symbol"`
+	Shadow       string    `json:"shadow"`
+	LiveStrategy string    `json:"live_strategy"`
+	LiveDecision string    `json:"live_decision"`
+	Decision     string    `json:"decision,omitempty"`
+	Agree        bool      `json:"agree"`
+	LatencyMs    float64   `json:"latency_ms"`
+	Error        string    `json:"error,omitempty"`
+	// Dropped — вызов не выполнялся, потому что очередь тени переполнена
+	Dropped bool `json:"dropped,omitempty"`
+}
+
+// Agreement — доля совпадений с боевой стратегией для одной тени.
+type Agreement struct {
+	Shadow       string  `json:"shadow"`
+	Count        int     `json:"count"`
+	Agreed       int     `json:"agreed"`
+	Errors       int     `json:"errors"`
+	Dropped      int     `json:"dropped"`
+	Rate         float64 `json:"agreement_rate"`
+	AvgLatencyMs float64 `json:"avg_latency_ms"`
+}
+
+// ResultHook вызывается после каждого ответа тени, например для метрик.
+type ResultHook func(ctx context.Context, rec Record)
+
+type shadowClient struct {
+	name     string
+	client   ai.AIClient
+	timeout  time.Duration
+	inflight chan struct{}
+}
+
+// Runner запускает теневые стратегии асинхронно. У каждой тени ограничено
+// число одновременных вызовов: при перегрузке вызов пропускается, а не
+// ставится в очередь, чтобы тени не копили горутины.
+type Runner struct {
+	shadows  []*shadowClient
+	onResult ResultHook
+
+	mu      sync.RWMutex
+	path    string
+	file    *os.File
+	records []Record
+	wg      sync.WaitGroup
+	// retention — сколько хранятся записи, 0 — без ограничения
+	retention time.Duration
+}
+
+// NewRunner создаёт тени по спецификации цепочки ("groq:5s,deepseek") через
+// ai.NewClient. maxInFlight — лимит одновременных вызовов на тень, записи
+// старше retention удаляются из памяти и журнала.
+func NewRunner(specs []ai.ProviderSpec, maxInFlight int, path string, retention time.Duration, onResult ResultHook) (*Runner, error) {
+	if maxInFlight <= 0 {
+		maxInFlight = 1
+	}
+
+	r := &Runner{onResult: onResult, path: path, retention: retention}
+	for _, spec := range specs {
+		client, err := ai.NewClient(spec.Name)
+		if err != nil {
+			return nil, fmt.Errorf("shadow %s: %w", spec.Name, err)
+		}
+		r.shadows = append(r.shadows, &shadowClient{
+			name:     spec.Name,
+			client:   client,
+			timeout:  spec.Timeout,
+			inflight: make(chan struct{}, maxInFlight),
+		})
+	}
+
+	if path == "" {
+		return r, nil
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create shadow dir: %w", err)
+	}
+	if err := r.load(path); err != nil {
+		return nil, err
+	}
+	if _, err := r.compact(time.Now()); err != nil {
+		return nil, err
+	}
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open shadow log: %w", err)
+	}
+	r.file = file
+	return r, nil
+}
+
+func (r *Runner) load(path string) error {
+	f, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read shadow log: %w", err)
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var rec Record
+		if err := json.Unmarshal(scanner.Bytes(), &rec); err != nil {
+			continue
+		}
+		r.records = append(r.records, rec)
+	}
+	return scanner.Err()
+}
+
+// Run запускает все тени на тех же рыночных данных и сразу возвращается.
+// Контекст запроса используется только для связи трейсов: отмена запроса
+// не прерывает теневые вызовы.
+func (r *Runner) Run(ctx context.Context, decisionID string, data ai.MarketData, live ai.DecisionResponse) {
+	if r == nil {
+		return
+	}
+
+	link := trace.LinkFromContext(ctx)
+	base := context.WithoutCancel(ctx)
+	for _, s := range r.shadows {
+		rec := Record{
+			Time:         time.Now().UTC(),
+			DecisionID:   decisionID,
+			Symbol:       data.Symbol,
+			Shadow:       s.name,
+			LiveStrategy: live.Strategy,
+			LiveDecision: live.Decision,
+		}
+
+		select {
+		case s.inflight <- struct{}{}:
+		default:
+			rec.Dropped = true
+			rec.Error = "shadow is overloaded"
+			r.record(base, rec)
+			continue
+		}
+
+		r.wg.Add(1)
+		go func(s *shadowClient, rec Record) {
+			defer r.wg.Done()
+			defer func() { <-s.inflight }()
+			r.call(base, link, s, data, rec)
+		}(s, rec)
+	}
+}
+
+func (r *Runner) call(ctx context.Context, link trace.Link, s *shadowClient, data ai.MarketData, rec Record) {
+	// отдельный корневой span со ссылкой на боевой запрос, чтобы тени не
+	// растягивали трейс ответа пользователю
+	ctx, span := otel.Tracer("shadow-runner").Start(ctx, "shadow.decision",
+		trace.WithNewRoot(),
+		trace.WithLinks(link),
+		trace.WithAttributes(
+			attribute.String("shadow.name", s.name),
+			attribute.String("symbol", rec.Symbol),
+			attribute.String("live.decision", rec.LiveDecision),
+		),
+	)
+	defer span.End()
+
+	if s.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, s.timeout)
+		defer cancel()
+	}
+
+	started := time.Now()
+	resp, err := s.client.GetDecision(ctx, data)
+	rec.LatencyMs = float64(time.Since(started).Microseconds()) / 1000
+
+	if err != nil {
+		rec.Error = err.Error()
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "Shadow decision failed")
+	} else {
+		rec.Decision = resp.Decision
+		rec.Agree = resp.Decision == rec.LiveDecision
+		span.SetAttributes(
+			attribute.String("shadow.decision", resp.Decision),
+			attribute.Bool("shadow.agree", rec.Agree),
+		)
+		span.SetStatus(codes.Ok, "Shadow decision completed")
+	}
+	r.record(ctx, rec)
+}
+
+func (r *Runner) record(ctx context.Context, rec Record) {
+	r.mu.Lock()
+	if r.file != nil {
+		if line, err := json.Marshal(rec); err == nil {
+			if _, err := r.file.Write(append(line, '\n')); err != nil {
+				log.Printf("failed to write shadow record: %v", err)
+			}
+		}
+	}
+	r.records = append(r.records, rec)
+	r.mu.Unlock()
+
+	if r.onResult != nil {
+		r.onResult(ctx, rec)
+	}
+}
+
+// Agreements считает долю совпадений по каждой тени с момента since.
+// Ошибки и пропуски не входят в знаменатель доли.
+func (r *Runner) Agreements(since time.Time) []Agreement {
+	if r == nil {
+		return nil
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	groups := map[string]*Agreement{}
+	latency := map[string]float64{}
+	for _, rec := range r.records {
+		if !since.IsZero() && rec.Time.Before(since) {
+			continue
+		}
+		a := groups[rec.Shadow]
+		if a == nil {
+			a = &Agreement{Shadow: rec.Shadow}
+			groups[rec.Shadow] = a
+		}
+		switch {
+		case rec.Dropped:
+			a.Dropped++
+		case rec.Error != "":
+			a.Errors++
+		default:
+			a.Count++
+			latency[rec.Shadow] += rec.LatencyMs
+			if rec.Agree {
+				a.Agreed++
+			}
+		}
+	}
+
+	result := make([]Agreement, 0, len(groups))
+	for name, a := range groups {
+		if a.Count > 0 {
+			a.Rate = float64(a.Agreed) / float64(a.Count)
+			a.AvgLatencyMs = latency[name] / float64(a.Count)
+		}
+		result = append(result, *a)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Shadow < result[j].Shadow })
+	return result
+}
+
+// RunRetention периодически удаляет записи старше периода хранения до
+// отмены ctx.
+func (r *Runner) RunRetention(ctx context.Context, interval time.Duration) {
+	if r == nil || r.retention <= 0 {
+		return
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		if n, err := r.Compact(time.Now()); err != nil {
+			log.Printf("shadow log compaction failed: %v", err)
+		} else if n > 0 {
+			log.Printf("removed %d shadow records older than %s", n, r.retention)
+		}
+	}
+}
+
+// Compact удаляет из памяти и файла записи старше периода хранения и
+// возвращает их число.
+func (r *Runner) Compact(now time.Time) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.file != nil {
+		if err := r.file.Close(); err != nil {
+			return 0, fmt.Errorf("failed to close shadow log: %w", err)
+		}
+		r.file = nil
+	}
+	removed, err := r.compact(now)
+	if r.path != "" {
+		file, openErr := os.OpenFile(r.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+		if openErr != nil {
+			return removed, errors.Join(err, fmt.Errorf("failed to open shadow log: %w", openErr))
+		}
+		r.file = file
+	}
+	return removed, err
+}
+
+// compact переписывает журнал без устаревших записей. Тени отвечают не по
+// порядку, поэтому записи фильтруются целиком, а не отрезаются с начала.
+// Вызывается под mu при закрытом файле.
+func (r *Runner) compact(now time.Time) (int, error) {
+	if r.retention <= 0 {
+		return 0, nil
+	}
+	cutoff := now.Add(-r.retention)
+	kept := r.records[:0]
+	for _, rec := range r.records {
+		if !rec.Time.Before(cutoff) {
+			kept = append(kept, rec)
+		}
+	}
+	n := len(r.records) - len(kept)
+	if n == 0 {
+		return 0, nil
+	}
+	r.records = append([]Record(nil), kept...)
+	if r.path == "" {
+		return n, nil
+	}
+
+	var b strings.Builder
+	for _, rec := range r.records {
+		line, err := json.Marshal(rec)
+		if err != nil {
+			continue
+		}
+		b.Write(line)
+		b.WriteByte('\n')
+	}
+	tmp := r.path + ".tmp"
+	if err := os.WriteFile(tmp, []byte(b.String()), 0o644); err != nil {
+		return n, fmt.Errorf("failed to write shadow log: %w", err)
+	}
+	if err := os.Rename(tmp, r.path); err != nil {
+		return n, fmt.Errorf("failed to save shadow log: %w", err)
+	}
+	return n, nil
+}
+
+// Names возвращает имена теней через запятую, для логов.
+func (r *Runner) Names() string {
+	names := make([]string, 0, len(r.shadows))
+	for _, s := range r.shadows {
+		names = append(names, s.name)
+	}
+	return strings.Join(names, ",")
+}
+
+// Close дожидается текущих теневых вызовов и закрывает журнал.
+func (r *Runner) Close() error {
+	if r == nil {
+		return nil
+	}
+	r.wg.Wait()
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.file == nil {
+		return nil
+	}
+	return r.file.Close()
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/skomaroh1845/crypto_telemetry/decision_service/ai"
+	"github.com/skomaroh1845/crypto_telemetry/decision_service/audit"
+	"github.com/skomaroh1845/crypto_telemetry/decision_service/scheduler"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// scheduledDecider возвращает функцию решения для планировщика. Клиенты
+// стратегий из заданий создаются заранее, пустая стратегия — боевая.
+func (h *Handler) scheduledDecider(jobs []scheduler.Job) (scheduler.Decider, error) {
+	clients := map[string]ai.AIClient{}
+	for _, job := range jobs {
+		if job.Strategy == "" || clients[job.Strategy] != nil {
+			continue
+		}
+		client, err := ai.NewClient(job.Strategy)
+		if err != nil {
+			return nil, err
+		}
+		clients[job.Strategy] = client
+	}
+
+	return func(ctx context.Context, symbol, strategy string) (ai.DecisionResponse, float64, error) {
+		ctx, span := tracer.Start(ctx, "scheduler.decision",
+			trace.WithAttributes(
+				attribute.String("symbol", symbol),
+				attribute.String("scheduler.strategy", strategy),
+			),
+		)
+		defer span.End()
+
+		market, err := getMarketData(ctx, symbol)
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, "Market data fetch failed")
+			return ai.DecisionResponse{}, 0, err
+		}
+		if candles, err := getRecentCandles(ctx, symbol, h.historyDays); err == nil {
+			market.Candles = candles
+		}
+		market = ai.WithIndicators(market)
+		market = h.withTimeframes(ctx, symbol, market)
+		market = h.withSentiment(ctx, symbol, market)
+
+		resp, _, err := h.decide(ctx, span, decisionInput{
+			market:  market,
+			profile: h.riskProfile,
+			source:  audit.SourceSchedule,
+			client:  clients[strategy],
+		})
+		if err != nil {
+			return ai.DecisionResponse{}, 0, fmt.Errorf("decision failed: %w", err)
+		}
+		span.SetStatus(codes.Ok, "Scheduled decision completed")
+		return resp.DecisionResponse, market.Price, nil
+	}, nil
+}
@@ -0,0 +1,154 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+
+	"github.com/skomaroh1845/crypto_telemetry/decision_service/ai"
+	"github.com/skomaroh1845/crypto_telemetry/decision_service/registry"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// maxStrategyBody ограничивает тело запроса к реестру стратегий.
+const maxStrategyBody = 64 << 10
+
+// StrategyRequest — действие с реестром: register заводит версию настроек
+// (и при Activate сразу делает её боевой), activate включает версию Version
+// стратегии Name (0 — последнюю), rollback возвращает предыдущую версию.
+type StrategyRequest struct {
+	Action  string `json:"action"`
+	Name    string `json:"name,omitempty"`
+	Version int    `json:"version,omitempty"`
+	registry.Config
+	Note     string `json:"note,omitempty"`
+	Activate bool   `json:"activate,omitempty"`
+}
+
+// StrategiesResponse — состояние реестра; Default — стратегия из окружения,
+// которая действует, пока ни одна версия не активна.
+type StrategiesResponse struct {
+	registry.Status
+	Default string `json:"default"`
+	// Registered — версия, заведённая запросом register
+	Registered *registry.Version `json:"registered,omitempty"`
+}
+
+func (h *Handler) strategiesHandler(w http.ResponseWriter, r *http.Request) {
+	_, span := tracer.Start(r.Context(), "strategies-list",
+		trace.WithAttributes(attribute.String("handler", "strategies")),
+	)
+	defer span.End()
+
+	resp := StrategiesResponse{Status: h.registry.Status(), Default: h.strategy}
+	if resp.Active != nil {
+		span.SetAttributes(attribute.String("strategy.version", resp.Active.Label()))
+	}
+	span.SetStatus(codes.Ok, "Strategies listed")
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(resp)
+}
+
+func (h *Handler) strategiesUpdateHandler(w http.ResponseWriter, r *http.Request) {
+	_, span := tracer.Start(r.Context(), "strategies-update",
+		trace.WithAttributes(attribute.String("handler", "strategies")),
+	)
+	defer span.End()
+
+	var req StrategyRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxStrategyBody)).Decode(&req); err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "Invalid request body")
+		http.Error(w, "Invalid request body: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	span.SetAttributes(
+		attribute.String("strategies.action", req.Action),
+		attribute.String("strategies.name", req.Name),
+	)
+
+	var registered *registry.Version
+	status := http.StatusOK
+	var err error
+	switch req.Action {
+	case "register":
+		var v registry.Version
+		var created bool
+		v, created, err = h.registry.Register(req.Name, req.Config, req.Note)
+		if err != nil {
+			break
+		}
+		registered = &v
+		if created {
+			status = http.StatusCreated
+			log.Printf("registered strategy %s (%s)", v.Label(), v.Hash)
+		}
+		if req.Activate {
+			_, err = h.registry.Activate(v.Name, v.Version)
+		}
+	case "activate":
+		var v registry.Version
+		if v, err = h.registry.Activate(req.Name, req.Version); err == nil {
+			log.Printf("activated strategy %s", v.Label())
+		}
+	case "rollback":
+		var v registry.Version
+		var ok bool
+		if v, ok, err = h.registry.Rollback(); err == nil {
+			if ok {
+				log.Printf("rolled back to strategy %s", v.Label())
+			} else {
+				log.Printf("rolled back to default strategy %s", h.strategy)
+			}
+		}
+	default:
+		err = fmt.Errorf("unknown action %q, expected register, activate or rollback", req.Action)
+	}
+	if err != nil {
+		code := http.StatusBadRequest
+		switch {
+		case errors.Is(err, registry.ErrNotFound):
+			code = http.StatusNotFound
+		case errors.Is(err, registry.ErrNoRollback):
+			code = http.StatusConflict
+		}
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "Strategy update failed")
+		http.Error(w, err.Error(), code)
+		return
+	}
+
+	resp := StrategiesResponse{Status: h.registry.Status(), Default: h.strategy, Registered: registered}
+	if resp.Active != nil {
+		span.SetAttributes(attribute.String("strategy.version", resp.Active.Label()))
+	}
+	span.SetStatus(codes.Ok, "Strategies updated")
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(resp)
+}
+
+// newStrategyBuilder собирает стратегии реестра: шаблоны ищутся среди
+// загруженных prompts, модель и параметры передаются в ai.NewClientWithOptions.
+func newStrategyBuilder(prompts map[string]*ai.PromptTemplate) registry.Builder {
+	return func(cfg registry.Config) (ai.AIClient, *ai.PromptTemplate, error) {
+		var prompt *ai.PromptTemplate
+		if cfg.Prompt != "" {
+			if prompt = prompts[cfg.Prompt]; prompt == nil {
+				return nil, nil, fmt.Errorf("prompt template %q not found", cfg.Prompt)
+			}
+		}
+		client, err := ai.NewClientWithOptions(cfg.Strategy, ai.ClientOptions{Model: cfg.Model, Params: cfg.Params})
+		if err != nil {
+			return nil, nil, err
+		}
+		return client, prompt, nil
+	}
+}
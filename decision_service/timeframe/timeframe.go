@@ -0,0 +1,153 @@
+// Package timeframe сводит свечи нескольких таймфреймов к коротким
+// признакам и вердиктам и проверяет, согласны ли таймфреймы с решением
+// стратегии.
+package timeframe
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/skomaroh1845/crypto_telemetry/decision_service/format"
+	"github.com/skomaroh1845/crypto_telemetry/decision_service/indicators"
+)
+
+type Timeframe string
+
+const (
+	M15 Timeframe = "15m"
+	H1  Timeframe = "1h"
+	H4  Timeframe = "4h"
+	D1  Timeframe = "1d"
+)
+
+// All — поддерживаемые таймфреймы от младшего к старшему.
+var All = []Timeframe{M15, H1, H4, D1}
+
+func (t Timeframe) Duration() time.Duration {
+	switch t {
+	case M15:
+		return 15 * time.Minute
+	case H1:
+		return time.Hour
+	case H4:
+		return 4 * time.Hour
+	default:
+		return 24 * time.Hour
+	}
+}
+
+// Parse разбирает список таймфреймов через запятую, например "15m,1h,4h,1d",
+// и сортирует его от младшего к старшему.
+func Parse(spec string) ([]Timeframe, error) {
+	var out []Timeframe
+	for _, part := range strings.Split(spec, ",") {
+		tf := Timeframe(strings.ToLower(strings.TrimSpace(part)))
+		if tf == "" {
+			continue
+		}
+		if !slices.Contains(All, tf) {
+			return nil, fmt.Errorf("unknown timeframe %q, expected 15m, 1h, 4h or 1d", part)
+		}
+		if !slices.Contains(out, tf) {
+			out = append(out, tf)
+		}
+	}
+	slices.SortFunc(out, func(a, b Timeframe) int { return int(a.Duration() - b.Duration()) })
+	return out, nil
+}
+
+// Lookback — сколько закрытых свечей загружать для признаков таймфрейма.
+const Lookback = 60
+
+// MinCandles — меньше закрытий не хватает на EMA(26).
+const MinCandles = 27
+
+// Frame — признаки и вердикт одного таймфрейма.
+type Frame struct {
+	Timeframe Timeframe `json:"timeframe"`
+	// Time — время открытия последней закрытой свечи
+	Time    time.Time `json:"time"`
+	Candles int       `json:"candles"`
+	// Trend — up или down по EMA(12) относительно EMA(26)
+	Trend     string  `json:"trend"`
+	RSI       float64 `json:"rsi"`
+	ChangePct float64 `json:"change_pct"` // за последние 10 свечей
+	Verdict   string  `json:"verdict"`
+}
+
+// Analyze считает признаки по закрытиям от старых к новым, последним должна
+// идти текущая цена. При нехватке истории ok == false.
+func Analyze(tf Timeframe, last time.Time, closes []float64) (Frame, bool) {
+	if len(closes) < MinCandles {
+		return Frame{}, false
+	}
+	fast, _ := indicators.EMA(closes, 12)
+	slow, _ := indicators.EMA(closes, 26)
+	rsi, _ := indicators.RSI(closes, 14)
+	change, _ := indicators.Change(closes, 10)
+	price := closes[len(closes)-1]
+
+	f := Frame{
+		Timeframe: tf,
+		Time:      last,
+		Candles:   len(closes) - 1,
+		Trend:     "up",
+		RSI:       format.Round(rsi, 2),
+		ChangePct: format.Round(change, 2),
+		Verdict:   "hold",
+	}
+	if fast < slow {
+		f.Trend = "down"
+	}
+	// по тренду, но не против перекупленности или перепроданности
+	switch {
+	case f.Trend == "up" && price > fast && rsi < 70:
+		f.Verdict = "buy"
+	case f.Trend == "down" && price < fast && rsi > 30:
+		f.Verdict = "sell"
+	}
+	return f, true
+}
+
+// Agreement — проверка решения стратегии по таймфреймам.
+type Agreement struct {
+	Required int `json:"required"`
+	Agreed   int `json:"agreed"`
+	Total    int `json:"total"`
+	// Blocked — решение заменено на hold, OriginalDecision хранит исходное,
+	// Reason — почему
+	Blocked          bool   `json:"blocked,omitempty"`
+	OriginalDecision string `json:"original_decision,omitempty"`
+	Reason           string `json:"reason,omitempty"`
+}
+
+// Confirm пропускает buy или sell, только если с ним согласны хотя бы
+// required таймфреймов. hold не проверяется. Если признаки удалось посчитать
+// меньше чем по required таймфреймам (в том числе ни по одному), подтвердить
+// решение нечем, и оно тоже заменяется на hold.
+func Confirm(decision string, frames []Frame, required int) (string, *Agreement) {
+	if decision != "buy" && decision != "sell" {
+		return decision, nil
+	}
+	a := &Agreement{Required: required, Total: len(frames)}
+	if len(frames) < required {
+		a.Blocked = true
+		a.OriginalDecision = decision
+		a.Reason = fmt.Sprintf("only %d of %d required timeframes have data", len(frames), required)
+		return "hold", a
+	}
+	for _, f := range frames {
+		if f.Verdict == decision {
+			a.Agreed++
+		}
+	}
+	if a.Agreed < a.Required {
+		a.Blocked = true
+		a.OriginalDecision = decision
+		a.Reason = fmt.Sprintf("only %d of %d timeframes agree, %d required", a.Agreed, a.Total, a.Required)
+		return "hold", a
+	}
+	return decision, a
+}
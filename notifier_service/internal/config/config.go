@@ -13,8 +13,16 @@ type Config struct {
 	OTLPCollectorURL   string
 	Environment        string
 	DecisionServiceURL string
-	HTTPTimeout        int
-	PollInterval       time.Duration // Polling interval for Telegram
+	// DecisionLanguage is the language of decision explanations, empty for the service default
+	DecisionLanguage string
+	// AdminChatID receives operational alerts such as decision drift, 0 disables them
+	AdminChatID  int64
+	HTTPTimeout  int
+	PollInterval time.Duration // Polling interval for Telegram
+	// RiskProfilesFile stores the risk profiles chosen with /risk, empty keeps them in memory
+	RiskProfilesFile string
+	// SubscriptionsFile stores the /subscribe signal subscriptions, empty keeps them in memory
+	SubscriptionsFile string
 }
 
 func Load() *Config {
@@ -26,8 +34,12 @@ func Load() *Config {
 		OTLPCollectorURL:   getEnv("OTEL_COLLECTOR_URL", "otel-collector:4317"),
 		Environment:        getEnv("ENVIRONMENT", "development"),
 		DecisionServiceURL: getEnv("DECISION_SERVICE_URL", "http://decision_service:8081"),
+		DecisionLanguage:   getEnv("DECISION_LANGUAGE", ""),
+		AdminChatID:        getEnvAsInt64("ADMIN_CHAT_ID", 0),
 		HTTPTimeout:        getEnvAsInt("HTTP_TIMEOUT", 10),
 		PollInterval:       getEnvAsDuration("POLL_INTERVAL", 2*time.Second),
+		RiskProfilesFile:   getEnv("RISK_PROFILES_FILE", "data/risk_profiles.json"),
+		SubscriptionsFile:  getEnv("SUBSCRIPTIONS_FILE", "data/subscriptions.json"),
 	}
 }
 
@@ -47,6 +59,15 @@ func getEnvAsInt(key string, defaultValue int) int {
 	return defaultValue
 }
 
+func getEnvAsInt64(key string, defaultValue int64) int64 {
+	if value := os.Getenv(key); value != "" {
+		if intValue, err := strconv.ParseInt(value, 10, 64); err == nil {
+			return intValue
+		}
+	}
+	return defaultValue
+}
+
 func getEnvAsBool(key string, defaultValue bool) bool {
 	if value := os.Getenv(key); value != "" {
 		if boolValue, err := strconv.ParseBool(value); err == nil {
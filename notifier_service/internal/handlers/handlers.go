@@ -1,9 +1,16 @@
 package handlers
 
 import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+
 	"github.com/gin-gonic/gin"
 	"go.opentelemetry.io/otel/trace"
 
+	"github.com/skomaroh1845/crypto_telemetry/notifier-service/internal/models"
 	"github.com/skomaroh1845/crypto_telemetry/notifier-service/internal/telegram"
 	"github.com/skomaroh1845/crypto_telemetry/notifier-service/internal/telemetry"
 )
@@ -45,3 +52,97 @@ func (h *TelegramHandler) HandleWebhook(c *gin.Context) {
 		"message": "Telegram webhook endpoint is working",
 	})
 }
+
+// NotificationHandler handles events published by other services
+type NotificationHandler struct {
+	*BaseHandler
+	orchestrator *telegram.WorkflowOrchestrator
+}
+
+// NewNotificationHandler creates a new notification handler
+func NewNotificationHandler(orchestrator *telegram.WorkflowOrchestrator, metrics *telemetry.Metrics) *NotificationHandler {
+	return &NotificationHandler{
+		BaseHandler:  NewBaseHandler(metrics),
+		orchestrator: orchestrator,
+	}
+}
+
+// HandleNotification delivers an event by its type: signal changes go to the
+// chats subscribed to the symbol, decision drift alerts go to the admin chat
+func (h *NotificationHandler) HandleNotification(c *gin.Context) {
+	body, err := c.GetRawData()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status": "error",
+			"error":  err.Error(),
+		})
+		return
+	}
+	var envelope struct {
+		Type string `json:"type"`
+	}
+	if err := json.Unmarshal(body, &envelope); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status": "error",
+			"error":  err.Error(),
+		})
+		return
+	}
+	c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+	switch envelope.Type {
+	case models.SignalChangeEvent:
+		h.handleSignal(c)
+	case models.DecisionDriftEvent:
+		h.handleDrift(c)
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status": "error",
+			"error":  "unsupported notification type: " + envelope.Type,
+		})
+	}
+}
+
+func (h *NotificationHandler) handleSignal(c *gin.Context) {
+	var event models.SignalEvent
+	if err := c.ShouldBindJSON(&event); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status": "error",
+			"error":  err.Error(),
+		})
+		return
+	}
+
+	delivered := h.orchestrator.BroadcastSignal(c.Request.Context(), event)
+	c.JSON(http.StatusOK, gin.H{
+		"status":    "delivered",
+		"delivered": delivered,
+	})
+}
+
+func (h *NotificationHandler) handleDrift(c *gin.Context) {
+	var event models.DriftEvent
+	if err := c.ShouldBindJSON(&event); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status": "error",
+			"error":  err.Error(),
+		})
+		return
+	}
+
+	if err := h.orchestrator.NotifyDrift(c.Request.Context(), event); err != nil {
+		status := http.StatusBadGateway
+		if errors.Is(err, telegram.ErrNoAdminChat) {
+			status = http.StatusUnprocessableEntity
+		}
+		c.JSON(status, gin.H{
+			"status": "error",
+			"error":  err.Error(),
+		})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"status":    "delivered",
+		"delivered": 1,
+	})
+}
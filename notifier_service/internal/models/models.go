@@ -1,5 +1,7 @@
 package models
 
+import "time"
+
 // MarketDataResponse response from Market Data Service
 type MarketDataResponse struct {
 	Crypto    string  `json:"crypto"`
@@ -15,6 +17,7 @@ type MarketDataResponse struct {
 type DecisionRequest struct {
 	Crypto    string `json:"crypto"`
 	Symbol    string
+	ChatID    int64   `json:"chat_id,omitempty"`
 	Price     float64 `json:"price"`
 	Currency  string  `json:"currency"`
 	Timestamp int64   `json:"timestamp"`
@@ -22,14 +25,194 @@ type DecisionRequest struct {
 
 // DecisionResponse response from Decision Service
 type DecisionResponse struct {
-	Decision   string  `json:"decision"`
-	Reason     string  `json:"reason"`
-	Confidence float64 `json:"confidence"`
-	Timestamp  int64   `json:"timestamp"`
-	Success    bool    `json:"success"`
-	Error      string  `json:"error,omitempty"`
+	Decision   string          `json:"decision"`
+	Reason     string          `json:"reason"`
+	Confidence float64         `json:"confidence"`
+	Horizon    string          `json:"horizon,omitempty"`
+	Timestamp  int64           `json:"timestamp"`
+	Success    bool            `json:"success"`
+	Error      string          `json:"error,omitempty"`
+	PaperTrade *PaperFill      `json:"paper_trade,omitempty"`
+	Risk       *RiskAssessment `json:"risk,omitempty"`
+	// Timeframes are per-timeframe verdicts, lowest timeframe first
+	Timeframes []TimeframeVerdict  `json:"timeframes,omitempty"`
+	Agreement  *TimeframeAgreement `json:"timeframe_agreement,omitempty"`
+}
+
+// TimeframeVerdict is the trend and signal of a single timeframe
+type TimeframeVerdict struct {
+	Timeframe string  `json:"timeframe"`
+	Trend     string  `json:"trend"`
+	RSI       float64 `json:"rsi"`
+	ChangePct float64 `json:"change_pct"`
+	Verdict   string  `json:"verdict"`
+}
+
+// TimeframeAgreement tells how many timeframes backed the decision
+type TimeframeAgreement struct {
+	Required         int    `json:"required"`
+	Agreed           int    `json:"agreed"`
+	Total            int    `json:"total"`
+	Blocked          bool   `json:"blocked,omitempty"`
+	OriginalDecision string `json:"original_decision,omitempty"`
+	Reason           string `json:"reason,omitempty"`
+}
+
+// RiskAssessment holds position sizing and exit levels suggested for a decision
+type RiskAssessment struct {
+	Profile          string  `json:"profile"`
+	VolatilityLevel  string  `json:"volatility_level"`
+	Volatility       float64 `json:"volatility,omitempty"`
+	PositionSize     float64 `json:"position_size"`
+	StopLoss         float64 `json:"stop_loss,omitempty"`
+	TakeProfit       float64 `json:"take_profit,omitempty"`
+	Refused          bool    `json:"refused,omitempty"`
+	OriginalDecision string  `json:"original_decision,omitempty"`
+	Reason           string  `json:"reason,omitempty"`
+}
+
+// PaperFill represents a virtual trade executed on the user's paper portfolio
+type PaperFill struct {
+	Symbol   string  `json:"symbol"`
+	Side     string  `json:"side"`
+	Quantity float64 `json:"quantity"`
+	Price    float64 `json:"price"`
+	Fee      float64 `json:"fee"`
+	PnL      float64 `json:"pnl,omitempty"`
 }
 
+// PaperPosition represents an open position valued at the current quote
+type PaperPosition struct {
+	Symbol        string  `json:"symbol"`
+	Quantity      float64 `json:"quantity"`
+	AvgPrice      float64 `json:"avg_price"`
+	Price         float64 `json:"price"`
+	MarketValue   float64 `json:"market_value"`
+	UnrealizedPnL float64 `json:"unrealized_pnl"`
+}
+
+// PortfolioResponse response from Decision Service /portfolio
+type PortfolioResponse struct {
+	ChatID      int64           `json:"chat_id"`
+	Cash        float64         `json:"cash"`
+	Equity      float64         `json:"equity"`
+	Positions   []PaperPosition `json:"positions"`
+	RecentFills []PaperFill     `json:"recent_fills"`
+}
+
+// PnLResponse response from Decision Service /pnl
+type PnLResponse struct {
+	ChatID        int64   `json:"chat_id"`
+	InitialCash   float64 `json:"initial_cash"`
+	Equity        float64 `json:"equity"`
+	RealizedPnL   float64 `json:"realized_pnl"`
+	UnrealizedPnL float64 `json:"unrealized_pnl"`
+	TotalPnL      float64 `json:"total_pnl"`
+	ReturnPct     float64 `json:"return_pct"`
+	Fees          float64 `json:"fees"`
+	Trades        int     `json:"trades"`
+}
+
+// RebalanceHolding is the quantity of a coin held in the user's basket
+type RebalanceHolding struct {
+	Symbol   string  `json:"symbol"`
+	Quantity float64 `json:"quantity"`
+}
+
+// RebalanceRequest request to Decision Service /v1/rebalance.
+// Without holdings and cash the user's paper portfolio is used
+type RebalanceRequest struct {
+	ChatID   int64              `json:"chat_id,omitempty"`
+	Risk     string             `json:"risk,omitempty"`
+	Symbols  []string           `json:"symbols,omitempty"`
+	Holdings []RebalanceHolding `json:"holdings,omitempty"`
+	Cash     *float64           `json:"cash,omitempty"`
+}
+
+// RebalanceAction is the decision for one coin of the basket
+type RebalanceAction struct {
+	Symbol     string          `json:"symbol"`
+	Decision   string          `json:"decision"`
+	Confidence float64         `json:"confidence,omitempty"`
+	Risk       *RiskAssessment `json:"risk,omitempty"`
+	Error      string          `json:"error,omitempty"`
+}
+
+// RebalanceAllocation compares current and target weight of a coin
+type RebalanceAllocation struct {
+	Symbol        string  `json:"symbol"`
+	Decision      string  `json:"decision"`
+	Price         float64 `json:"price"`
+	Quantity      float64 `json:"quantity"`
+	Value         float64 `json:"value"`
+	CurrentWeight float64 `json:"current_weight"`
+	TargetWeight  float64 `json:"target_weight"`
+}
+
+// RebalanceTrade is a trade suggested to reach the target allocation
+type RebalanceTrade struct {
+	Symbol   string  `json:"symbol"`
+	Side     string  `json:"side"`
+	Quantity float64 `json:"quantity"`
+	Price    float64 `json:"price"`
+	Value    float64 `json:"value"`
+}
+
+// RebalanceResponse response from Decision Service /v1/rebalance
+type RebalanceResponse struct {
+	Source           string                `json:"source"`
+	Actions          []RebalanceAction     `json:"actions"`
+	Equity           float64               `json:"equity"`
+	Cash             float64               `json:"cash"`
+	CashWeight       float64               `json:"cash_weight"`
+	TargetCashWeight float64               `json:"target_cash_weight"`
+	Allocations      []RebalanceAllocation `json:"allocations"`
+	Trades           []RebalanceTrade      `json:"trades"`
+}
+
+// SignalEvent is a signal change published by Decision Service's scheduler
+type SignalEvent struct {
+	Type       string    `json:"type" binding:"required"`
+	Symbol     string    `json:"symbol" binding:"required"`
+	Strategy   string    `json:"strategy"`
+	Previous   string    `json:"previous"`
+	Decision   string    `json:"decision" binding:"required"`
+	Confidence float64   `json:"confidence,omitempty"`
+	Reason     string    `json:"reason,omitempty"`
+	Horizon    string    `json:"horizon,omitempty"`
+	Price      float64   `json:"price"`
+	Time       time.Time `json:"time"`
+}
+
+// SignalChangeEvent is the notification type of SignalEvent
+const SignalChangeEvent = "signal_change"
+
+// DecisionDistribution is the share of buy, sell and hold decisions in a window
+type DecisionDistribution struct {
+	Count int     `json:"count"`
+	Buy   float64 `json:"buy"`
+	Sell  float64 `json:"sell"`
+	Hold  float64 `json:"hold"`
+}
+
+// DriftEvent is published by Decision Service when the recent decisions of a
+// strategy diverge from its baseline window
+type DriftEvent struct {
+	Type           string               `json:"type" binding:"required"`
+	Strategy       string               `json:"strategy"`
+	Symbol         string               `json:"symbol" binding:"required"`
+	Current        DecisionDistribution `json:"current"`
+	Baseline       DecisionDistribution `json:"baseline"`
+	Divergence     float64              `json:"divergence"`
+	Threshold      float64              `json:"threshold"`
+	Window         string               `json:"window"`
+	BaselineWindow string               `json:"baseline_window"`
+	Time           time.Time            `json:"time"`
+}
+
+// DecisionDriftEvent is the notification type of DriftEvent
+const DecisionDriftEvent = "decision_drift"
+
 // TelegramMessage represents a message sent to Telegram API
 type TelegramMessage struct {
 	ChatID    int64  `json:"chat_id"`
@@ -37,7 +37,7 @@ func New(cfg *config.Config) *Server {
 }
 
 // SetupRoutes configures all the routes for the server
-func (s *Server) SetupRoutes(telegramHandler *handlers.TelegramHandler, metrics *telemetry.Metrics) {
+func (s *Server) SetupRoutes(telegramHandler *handlers.TelegramHandler, notificationHandler *handlers.NotificationHandler, metrics *telemetry.Metrics) {
 	// Add OpenTelemetry middleware to all routes
 	s.router.Use(otelgin.Middleware("notifier-service"))
 
@@ -48,7 +48,7 @@ func (s *Server) SetupRoutes(telegramHandler *handlers.TelegramHandler, metrics
 	// API v1 routes
 	v1 := s.router.Group("/api/v1")
 	{
-		v1.POST("/notification", s.notificationHandler)
+		v1.POST("/notification", notificationHandler.HandleNotification)
 	}
 
 	// Telegram webhook route
@@ -96,12 +96,3 @@ func (s *Server) readyHandler(c *gin.Context) {
 		"timestamp": time.Now().UTC(),
 	})
 }
-
-// notificationHandler handles incoming notifications from other services
-func (s *Server) notificationHandler(c *gin.Context) {
-	// TODO: Implement notification handling logic
-	c.JSON(http.StatusOK, gin.H{
-		"status":  "received",
-		"message": "Notification endpoint is working",
-	})
-}
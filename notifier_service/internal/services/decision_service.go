@@ -5,6 +5,7 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"net/url"
 	"strings"
 
 	"go.opentelemetry.io/otel"
@@ -17,27 +18,33 @@ import (
 
 // DecisionService handles HTTP communication with Decision Service
 type DecisionService struct {
-	baseURL string
-	client  *http.Client
-	tracer  trace.Tracer
+	baseURL  string
+	language string
+	client   *http.Client
+	tracer   trace.Tracer
 }
 
-// NewDecisionService creates a new Decision service client
-func NewDecisionService(baseURL string, timeout int) *DecisionService {
+// NewDecisionService creates a new Decision service client, language selects
+// the language of decision explanations (empty for the service default)
+func NewDecisionService(baseURL string, timeout int, language string) *DecisionService {
 	return &DecisionService{
-		baseURL: strings.TrimSuffix(baseURL, "/decision_service:8081"),
-		client:  http.NewClient(timeout),
-		tracer:  otel.Tracer("decision-service"),
+		baseURL:  strings.TrimSuffix(baseURL, "/"),
+		language: language,
+		client:   http.NewClient(timeout),
+		tracer:   otel.Tracer("decision-service"),
 	}
 }
 
-// GetDecision fetches trading decision via HTTP using symbol query parameter
-func (s *DecisionService) GetDecision(ctx context.Context, symbol string) (*models.DecisionResponse, error) {
+// GetDecision fetches trading decision via HTTP using symbol query parameter.
+// The chat ID lets Decision Service execute the decision on the user's paper portfolio,
+// the risk profile (empty for the service default) drives position sizing.
+func (s *DecisionService) GetDecision(ctx context.Context, symbol string, chatID int64, riskProfile string) (*models.DecisionResponse, error) {
 	ctx, span := s.tracer.Start(ctx, "DecisionService.GetDecision")
 	defer span.End()
 
 	decisionReq := models.DecisionRequest{
 		Symbol: symbol,
+		ChatID: chatID,
 	}
 
 	jsonData, err := json.Marshal(decisionReq)
@@ -48,7 +55,13 @@ func (s *DecisionService) GetDecision(ctx context.Context, symbol string) (*mode
 
 	// Construct URL with symbol query parameter
 	//decisionURL := fmt.Sprintf("%s/decision", s.baseURL)
-	decisionURL := fmt.Sprintf("http://decision_service:8081/decision?symbol=%s", symbol)
+	decisionURL := fmt.Sprintf("%s/decision?symbol=%s&chat_id=%d", s.baseURL, symbol, chatID)
+	if riskProfile != "" {
+		decisionURL += "&risk=" + riskProfile
+	}
+	if s.language != "" {
+		decisionURL += "&lang=" + url.QueryEscape(s.language)
+	}
 
 	headers := map[string]string{
 		"Content-Type": "application/json",
@@ -69,6 +82,84 @@ func (s *DecisionService) GetDecision(ctx context.Context, symbol string) (*mode
 	return &response, nil
 }
 
+// GetPortfolio fetches the user's paper portfolio valued at current quotes
+func (s *DecisionService) GetPortfolio(ctx context.Context, chatID int64) (*models.PortfolioResponse, error) {
+	ctx, span := s.tracer.Start(ctx, "DecisionService.GetPortfolio")
+	defer span.End()
+
+	portfolioURL := fmt.Sprintf("%s/portfolio?chat_id=%d", s.baseURL, chatID)
+	headers := map[string]string{
+		"Content-Type": "application/json",
+	}
+
+	var response models.PortfolioResponse
+	if err := s.client.Get(ctx, portfolioURL, headers, &response); err != nil {
+		span.RecordError(err)
+		return nil, fmt.Errorf("failed to get portfolio: %w", err)
+	}
+
+	span.SetAttributes(
+		attribute.Int64("telegram.chat_id", chatID),
+		attribute.Float64("portfolio.equity", response.Equity),
+	)
+
+	return &response, nil
+}
+
+// GetPnL fetches profit and loss summary of the user's paper portfolio
+func (s *DecisionService) GetPnL(ctx context.Context, chatID int64) (*models.PnLResponse, error) {
+	ctx, span := s.tracer.Start(ctx, "DecisionService.GetPnL")
+	defer span.End()
+
+	pnlURL := fmt.Sprintf("%s/pnl?chat_id=%d", s.baseURL, chatID)
+	headers := map[string]string{
+		"Content-Type": "application/json",
+	}
+
+	var response models.PnLResponse
+	if err := s.client.Get(ctx, pnlURL, headers, &response); err != nil {
+		span.RecordError(err)
+		return nil, fmt.Errorf("failed to get pnl: %w", err)
+	}
+
+	span.SetAttributes(
+		attribute.Int64("telegram.chat_id", chatID),
+		attribute.Float64("pnl.total", response.TotalPnL),
+	)
+
+	return &response, nil
+}
+
+// Rebalance asks Decision Service for per-coin decisions and a target allocation of the basket
+func (s *DecisionService) Rebalance(ctx context.Context, req models.RebalanceRequest) (*models.RebalanceResponse, error) {
+	ctx, span := s.tracer.Start(ctx, "DecisionService.Rebalance")
+	defer span.End()
+
+	jsonData, err := json.Marshal(req)
+	if err != nil {
+		span.RecordError(err)
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	rebalanceURL := fmt.Sprintf("%s/v1/rebalance", s.baseURL)
+	headers := map[string]string{
+		"Content-Type": "application/json",
+	}
+
+	var response models.RebalanceResponse
+	if err := s.client.Post(ctx, rebalanceURL, headers, bytes.NewBuffer(jsonData), &response); err != nil {
+		span.RecordError(err)
+		return nil, fmt.Errorf("failed to rebalance: %w", err)
+	}
+
+	span.SetAttributes(
+		attribute.Int64("telegram.chat_id", req.ChatID),
+		attribute.Int("rebalance.trades", len(response.Trades)),
+	)
+
+	return &response, nil
+}
+
 // HealthCheck checks if the decision service is healthy
 func (s *DecisionService) HealthCheck(ctx context.Context) error {
 	ctx, span := s.tracer.Start(ctx, "DecisionService.HealthCheck")
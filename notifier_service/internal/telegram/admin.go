@@ -0,0 +1,69 @@
+package telegram
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/metric"
+
+	"github.com/skomaroh1845/crypto_telemetry/notifier-service/internal/models"
+)
+
+// ErrNoAdminChat is returned when an admin alert arrives but no admin chat is configured
+var ErrNoAdminChat = errors.New("admin chat is not configured")
+
+// SetAdminChat sets the chat that receives operational alerts, 0 disables them
+func (o *WorkflowOrchestrator) SetAdminChat(chatID int64) {
+	o.adminChatID = chatID
+}
+
+// NotifyDrift sends a decision drift alert to the admin chat
+func (o *WorkflowOrchestrator) NotifyDrift(ctx context.Context, event models.DriftEvent) error {
+	ctx, span := o.tracer.Start(ctx, "WorkflowOrchestrator.NotifyDrift")
+	defer span.End()
+
+	span.SetAttributes(
+		attribute.String("crypto.symbol", event.Symbol),
+		attribute.String("drift.strategy", event.Strategy),
+		attribute.Float64("drift.divergence", event.Divergence),
+	)
+	if o.adminChatID == 0 {
+		span.SetStatus(codes.Error, "Admin chat is not configured")
+		return ErrNoAdminChat
+	}
+
+	msg := tgbotapi.NewMessage(o.adminChatID, o.formatDriftMessage(event))
+	if err := o.telegramBot.SendMessage(ctx, o.adminChatID, &msg); err != nil {
+		span.RecordError(err)
+		o.metrics.ErrorsCounter.Add(ctx, 1, metric.WithAttributes(attribute.String("error.type", "telegram_admin")))
+		return fmt.Errorf("failed to send drift alert: %w", err)
+	}
+	o.metrics.MessagesSentCounter.Add(ctx, 1)
+	return nil
+}
+
+// formatDriftMessage compares the recent and baseline decision shares
+func (o *WorkflowOrchestrator) formatDriftMessage(event models.DriftEvent) string {
+	var b strings.Builder
+	strategy := event.Strategy
+	if strategy == "" {
+		strategy = "default strategy"
+	}
+	fmt.Fprintf(&b, "🚨 Decision drift: %s on %s\n\n", strategy, event.Symbol)
+	fmt.Fprintf(&b, "Last %s (%d decisions): %s\n", event.Window, event.Current.Count, formatShares(event.Current))
+	fmt.Fprintf(&b, "Previous %s (%d decisions): %s\n", event.BaselineWindow, event.Baseline.Count, formatShares(event.Baseline))
+	fmt.Fprintf(&b, "📐 Divergence: %.3f (threshold %.3f)\n", event.Divergence, event.Threshold)
+	if !event.Time.IsZero() {
+		fmt.Fprintf(&b, "🕐 %s UTC\n", event.Time.UTC().Format("2006-01-02 15:04"))
+	}
+	return b.String()
+}
+
+func formatShares(d models.DecisionDistribution) string {
+	return fmt.Sprintf("buy %.0f%% · sell %.0f%% · hold %.0f%%", d.Buy*100, d.Sell*100, d.Hold*100)
+}
@@ -2,6 +2,7 @@ package telegram
 
 import (
 	"context"
+	"fmt"
 	"log"
 	"log/slog"
 	"strings"
@@ -77,6 +78,10 @@ func (p *Poller) handleStart(ctx context.Context, update *tgbotapi.Update) error
 		tgbotapi.NewKeyboardButtonRow(
 			tgbotapi.NewKeyboardButton("рекомендации"),
 			tgbotapi.NewKeyboardButton("помощь"),
+		),
+		tgbotapi.NewKeyboardButtonRow(
+			tgbotapi.NewKeyboardButton("портфель"),
+			tgbotapi.NewKeyboardButton("pnl"),
 		))
 
 	msg.ReplyMarkup = keyboard
@@ -95,7 +100,7 @@ func (p *Poller) handleHelp(ctx context.Context, update *tgbotapi.Update) error
 
 	slog.Info("handling help command from user")
 	msg := tgbotapi.NewMessage(update.Message.Chat.ID,
-		"\\help - помошь\n\\start - старт бота\n\\advice - рекомендации к покупке",
+		"\\help - помошь\n\\start - старт бота\n\\advice - рекомендации к покупке\n\\portfolio - виртуальный портфель\n\\pnl - доходность виртуального портфеля\n\\risk - профиль риска (conservative, balanced, aggressive)\n\\rebalance - ребалансировка корзины (BTC ETH или BTC=0.5 ETH=2 cash=1000)\n\\subscribe - уведомления о смене сигнала (BTC ETH)\n\\unsubscribe - отписаться от уведомлений",
 	)
 
 	if err := p.bot.SendMessage(ctx, update.Message.Chat.ID, &msg); err != nil {
@@ -107,6 +112,130 @@ func (p *Poller) handleHelp(ctx context.Context, update *tgbotapi.Update) error
 	return nil
 }
 
+func (p *Poller) handlePortfolio(ctx context.Context, update tgbotapi.Update) error {
+	ctx, span := p.tracer.Start(ctx, "TelegramPoller.handlePortfolio")
+	defer span.End()
+
+	slog.Info("handling portfolio command from user")
+	return p.orchestrator.ProcessPortfolioRequest(ctx, update.Message.Chat.ID)
+}
+
+func (p *Poller) handlePnL(ctx context.Context, update tgbotapi.Update) error {
+	ctx, span := p.tracer.Start(ctx, "TelegramPoller.handlePnL")
+	defer span.End()
+
+	slog.Info("handling pnl command from user")
+	return p.orchestrator.ProcessPnLRequest(ctx, update.Message.Chat.ID)
+}
+
+func (p *Poller) handleRebalance(ctx context.Context, update tgbotapi.Update) error {
+	ctx, span := p.tracer.Start(ctx, "TelegramPoller.handleRebalance")
+	defer span.End()
+
+	slog.Info("handling rebalance command from user")
+	return p.orchestrator.ProcessRebalanceRequest(ctx, update.Message.Chat.ID, update.Message.CommandArguments())
+}
+
+func (p *Poller) handleSubscribe(ctx context.Context, update tgbotapi.Update) error {
+	ctx, span := p.tracer.Start(ctx, "TelegramPoller.handleSubscribe")
+	defer span.End()
+
+	slog.Info("handling subscribe command from user")
+	chatID := update.Message.Chat.ID
+	symbols := strings.Fields(strings.ToUpper(update.Message.CommandArguments()))
+
+	var text string
+	if len(symbols) == 0 {
+		current := p.orchestrator.Subscriptions(chatID)
+		if len(current) == 0 {
+			text = "Нет подписок\nПодписаться: /subscribe BTC ETH"
+		} else {
+			text = "Подписки: " + strings.Join(current, ", ")
+		}
+	} else {
+		for _, symbol := range symbols {
+			p.orchestrator.Subscribe(chatID, symbol)
+		}
+		span.SetAttributes(attribute.StringSlice("subscribe.symbols", symbols))
+		text = "Уведомления о смене сигнала включены: " + strings.Join(symbols, ", ")
+	}
+
+	msg := tgbotapi.NewMessage(chatID, text)
+	if err := p.bot.SendMessage(ctx, chatID, &msg); err != nil {
+		span.RecordError(err)
+		log.Printf("Failed to send subscribe message: %v", err)
+		return err
+	}
+
+	return nil
+}
+
+func (p *Poller) handleUnsubscribe(ctx context.Context, update tgbotapi.Update) error {
+	ctx, span := p.tracer.Start(ctx, "TelegramPoller.handleUnsubscribe")
+	defer span.End()
+
+	slog.Info("handling unsubscribe command from user")
+	chatID := update.Message.Chat.ID
+	symbols := strings.Fields(strings.ToUpper(update.Message.CommandArguments()))
+	if len(symbols) == 0 {
+		symbols = p.orchestrator.Subscriptions(chatID)
+	}
+
+	var removed []string
+	for _, symbol := range symbols {
+		if p.orchestrator.Unsubscribe(chatID, symbol) {
+			removed = append(removed, symbol)
+		}
+	}
+
+	text := "Нет таких подписок"
+	if len(removed) > 0 {
+		text = "Уведомления выключены: " + strings.Join(removed, ", ")
+	}
+
+	msg := tgbotapi.NewMessage(chatID, text)
+	if err := p.bot.SendMessage(ctx, chatID, &msg); err != nil {
+		span.RecordError(err)
+		log.Printf("Failed to send unsubscribe message: %v", err)
+		return err
+	}
+
+	return nil
+}
+
+func (p *Poller) handleRisk(ctx context.Context, update tgbotapi.Update) error {
+	ctx, span := p.tracer.Start(ctx, "TelegramPoller.handleRisk")
+	defer span.End()
+
+	slog.Info("handling risk command from user")
+	chatID := update.Message.Chat.ID
+	profile := strings.TrimSpace(update.Message.CommandArguments())
+
+	var text string
+	switch {
+	case profile == "":
+		current := p.orchestrator.RiskProfile(chatID)
+		if current == "" {
+			current = "по умолчанию"
+		}
+		text = fmt.Sprintf("Текущий профиль риска: %s\nИзменить: /risk %s", current, strings.Join(RiskProfiles, "|"))
+	case p.orchestrator.SetRiskProfile(chatID, profile) != nil:
+		text = fmt.Sprintf("Неизвестный профиль %q, доступны: %s", profile, strings.Join(RiskProfiles, ", "))
+	default:
+		span.SetAttributes(attribute.String("risk.profile", profile))
+		text = "Профиль риска установлен: " + strings.ToLower(profile)
+	}
+
+	msg := tgbotapi.NewMessage(chatID, text)
+	if err := p.bot.SendMessage(ctx, chatID, &msg); err != nil {
+		span.RecordError(err)
+		log.Printf("Failed to send risk message: %v", err)
+		return err
+	}
+
+	return nil
+}
+
 func (p *Poller) handleAdvice(ctx context.Context, update tgbotapi.Update) error {
 	p.processUserRequest(ctx, update.Message.Chat.ID, update.Message.Text, update.Message.Chat.UserName)
 	return nil
@@ -146,6 +275,63 @@ func (p *Poller) processUpdate(ctx context.Context, update tgbotapi.Update) {
 			span.RecordError(err)
 		}
 
+		p.metrics.RequestsCounter.Add(ctx, 1)
+		return
+	} else if update.Message.Command() == "portfolio" || update.Message.Text == "портфель" {
+		go func() {
+			err := p.handlePortfolio(ctx, update)
+			if err != nil {
+				span.RecordError(err)
+			}
+
+			p.metrics.RequestsCounter.Add(ctx, 1)
+		}()
+
+		return
+	} else if update.Message.Command() == "pnl" || update.Message.Text == "pnl" {
+		go func() {
+			err := p.handlePnL(ctx, update)
+			if err != nil {
+				span.RecordError(err)
+			}
+
+			p.metrics.RequestsCounter.Add(ctx, 1)
+		}()
+
+		return
+	} else if update.Message.Command() == "rebalance" {
+		go func() {
+			err := p.handleRebalance(ctx, update)
+			if err != nil {
+				span.RecordError(err)
+			}
+
+			p.metrics.RequestsCounter.Add(ctx, 1)
+		}()
+
+		return
+	} else if update.Message.Command() == "subscribe" {
+		err := p.handleSubscribe(ctx, update)
+		if err != nil {
+			span.RecordError(err)
+		}
+
+		p.metrics.RequestsCounter.Add(ctx, 1)
+		return
+	} else if update.Message.Command() == "unsubscribe" {
+		err := p.handleUnsubscribe(ctx, update)
+		if err != nil {
+			span.RecordError(err)
+		}
+
+		p.metrics.RequestsCounter.Add(ctx, 1)
+		return
+	} else if update.Message.Command() == "risk" {
+		err := p.handleRisk(ctx, update)
+		if err != nil {
+			span.RecordError(err)
+		}
+
 		p.metrics.RequestsCounter.Add(ctx, 1)
 		return
 	} else if update.Message.Command() == "advice" || update.Message.Text == "рекомендации" {
@@ -0,0 +1,96 @@
+package telegram
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+)
+
+// LoadRiskProfiles restores the chats' risk profiles from path and keeps
+// saving them there on every change, an empty path keeps them in memory only
+func (o *WorkflowOrchestrator) LoadRiskProfiles(path string) error {
+	o.riskMu.Lock()
+	defer o.riskMu.Unlock()
+
+	o.riskPath = path
+	if err := loadState(path, &o.riskProfiles); err != nil {
+		return err
+	}
+	if o.riskProfiles == nil {
+		o.riskProfiles = map[int64]string{}
+	}
+	return nil
+}
+
+// saveRiskProfiles must be called with riskMu held
+func (o *WorkflowOrchestrator) saveRiskProfiles() {
+	if err := saveState(o.riskPath, o.riskProfiles); err != nil {
+		log.Printf("Failed to save risk profiles: %v", err)
+	}
+}
+
+// LoadSubscriptions restores signal subscriptions from path and keeps saving
+// them there on every change, an empty path keeps them in memory only
+func (o *WorkflowOrchestrator) LoadSubscriptions(path string) error {
+	o.subsMu.Lock()
+	defer o.subsMu.Unlock()
+
+	o.subsPath = path
+	if err := loadState(path, &o.subscriptions); err != nil {
+		return err
+	}
+	if o.subscriptions == nil {
+		o.subscriptions = map[string]map[int64]bool{}
+	}
+	return nil
+}
+
+// saveSubscriptions must be called with subsMu held
+func (o *WorkflowOrchestrator) saveSubscriptions() {
+	if err := saveState(o.subsPath, o.subscriptions); err != nil {
+		log.Printf("Failed to save subscriptions: %v", err)
+	}
+}
+
+// loadState reads JSON state from path, a missing file leaves v untouched
+func loadState(path string, v any) error {
+	if path == "" {
+		return nil
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read state %s: %w", path, err)
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("failed to parse state %s: %w", path, err)
+	}
+	return nil
+}
+
+// saveState writes JSON state to a temporary file and renames it over path
+func saveState(path string, v any) error {
+	if path == "" {
+		return nil
+	}
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal state: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("failed to create state dir: %w", err)
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return fmt.Errorf("failed to write state %s: %w", path, err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return fmt.Errorf("failed to save state %s: %w", path, err)
+	}
+	return nil
+}
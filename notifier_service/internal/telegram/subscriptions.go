@@ -0,0 +1,125 @@
+package telegram
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+
+	"github.com/skomaroh1845/crypto_telemetry/notifier-service/internal/models"
+)
+
+// Subscribe subscribes the chat to signal changes of the symbol
+func (o *WorkflowOrchestrator) Subscribe(chatID int64, symbol string) {
+	symbol = strings.ToUpper(strings.TrimSpace(symbol))
+
+	o.subsMu.Lock()
+	defer o.subsMu.Unlock()
+	if o.subscriptions[symbol] == nil {
+		o.subscriptions[symbol] = map[int64]bool{}
+	}
+	o.subscriptions[symbol][chatID] = true
+	o.saveSubscriptions()
+}
+
+// Unsubscribe removes the chat's subscription and reports whether it existed
+func (o *WorkflowOrchestrator) Unsubscribe(chatID int64, symbol string) bool {
+	symbol = strings.ToUpper(strings.TrimSpace(symbol))
+
+	o.subsMu.Lock()
+	defer o.subsMu.Unlock()
+	if !o.subscriptions[symbol][chatID] {
+		return false
+	}
+	delete(o.subscriptions[symbol], chatID)
+	if len(o.subscriptions[symbol]) == 0 {
+		delete(o.subscriptions, symbol)
+	}
+	o.saveSubscriptions()
+	return true
+}
+
+// Subscriptions lists the symbols the chat is subscribed to
+func (o *WorkflowOrchestrator) Subscriptions(chatID int64) []string {
+	o.subsMu.RLock()
+	defer o.subsMu.RUnlock()
+
+	var symbols []string
+	for symbol, chats := range o.subscriptions {
+		if chats[chatID] {
+			symbols = append(symbols, symbol)
+		}
+	}
+	sort.Strings(symbols)
+	return symbols
+}
+
+func (o *WorkflowOrchestrator) subscribers(symbol string) []int64 {
+	symbol = strings.ToUpper(strings.TrimSpace(symbol))
+
+	o.subsMu.RLock()
+	defer o.subsMu.RUnlock()
+
+	chats := make([]int64, 0, len(o.subscriptions[symbol]))
+	for chatID := range o.subscriptions[symbol] {
+		chats = append(chats, chatID)
+	}
+	return chats
+}
+
+// BroadcastSignal sends a signal change to every chat subscribed to its symbol
+// and returns how many chats received it
+func (o *WorkflowOrchestrator) BroadcastSignal(ctx context.Context, event models.SignalEvent) int {
+	ctx, span := o.tracer.Start(ctx, "WorkflowOrchestrator.BroadcastSignal")
+	defer span.End()
+
+	chats := o.subscribers(event.Symbol)
+	span.SetAttributes(
+		attribute.String("crypto.symbol", event.Symbol),
+		attribute.String("signal.previous", event.Previous),
+		attribute.String("signal.decision", event.Decision),
+		attribute.Int("signal.subscribers", len(chats)),
+	)
+
+	text := o.formatSignalMessage(event)
+	delivered := 0
+	for _, chatID := range chats {
+		msg := tgbotapi.NewMessage(chatID, text)
+		if err := o.telegramBot.SendMessage(ctx, chatID, &msg); err != nil {
+			span.RecordError(err)
+			o.metrics.ErrorsCounter.Add(ctx, 1, metric.WithAttributes(attribute.String("error.type", "telegram_signal")))
+			log.Printf("Failed to send signal to chat %d: %v", chatID, err)
+			continue
+		}
+		delivered++
+	}
+
+	o.metrics.MessagesSentCounter.Add(ctx, int64(delivered))
+	return delivered
+}
+
+// formatSignalMessage formats a signal change into a user-friendly message
+func (o *WorkflowOrchestrator) formatSignalMessage(event models.SignalEvent) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "🔔 %s signal changed\n\n%s → %s\n", event.Symbol,
+		decisionEmoji(event.Previous), decisionEmoji(event.Decision))
+	if event.Price > 0 {
+		fmt.Fprintf(&b, "💵 Price: $%s\n", formatPrice(event.Price))
+	}
+	if event.Confidence > 0 {
+		fmt.Fprintf(&b, "🎯 Confidence: %.0f%%\n", event.Confidence*100)
+	}
+	if event.Reason != "" {
+		fmt.Fprintf(&b, "💬 %s\n", event.Reason)
+	}
+	if event.Strategy != "" {
+		fmt.Fprintf(&b, "\nStrategy: %s", event.Strategy)
+	}
+	b.WriteString("\nUnsubscribe: /unsubscribe " + event.Symbol)
+	return b.String()
+}
@@ -4,7 +4,10 @@ import (
 	"context"
 	"fmt"
 	"log"
+	"math"
+	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"go.opentelemetry.io/otel"
@@ -24,8 +27,26 @@ type WorkflowOrchestrator struct {
 	telegramBot     *Bot
 	metrics         *telemetry.Metrics
 	tracer          trace.Tracer
+
+	// riskProfiles keeps the risk profile chosen by each chat with /risk,
+	// saved to riskPath when it is set
+	riskMu       sync.RWMutex
+	riskPath     string
+	riskProfiles map[int64]string
+
+	// subscriptions keeps the chats subscribed to signal changes of each symbol,
+	// saved to subsPath when it is set
+	subsMu        sync.RWMutex
+	subsPath      string
+	subscriptions map[string]map[int64]bool
+
+	// adminChatID receives operational alerts, 0 if none is configured
+	adminChatID int64
 }
 
+// RiskProfiles lists the profiles Decision Service understands
+var RiskProfiles = []string{"conservative", "balanced", "aggressive"}
+
 // NewWorkflowOrchestrator creates a new workflow orchestrator
 func NewWorkflowOrchestrator(
 	decisionService *services.DecisionService,
@@ -37,6 +58,8 @@ func NewWorkflowOrchestrator(
 		telegramBot:     telegramBot,
 		metrics:         metrics,
 		tracer:          otel.Tracer("workflow-orchestrator"),
+		riskProfiles:    map[int64]string{},
+		subscriptions:   map[string]map[int64]bool{},
 	}
 }
 
@@ -69,7 +92,7 @@ func (o *WorkflowOrchestrator) ProcessUserRequest(ctx context.Context, chatID in
 	span.SetAttributes(attribute.String("crypto.symbol", symbol))
 
 	// 2. Get decision from Decision Service via HTTP
-	decision, err := o.decisionService.GetDecision(ctx, symbol)
+	decision, err := o.decisionService.GetDecision(ctx, symbol, chatID, o.RiskProfile(chatID))
 	if err != nil {
 		span.RecordError(err)
 		o.metrics.ErrorsCounter.Add(ctx, 1, metric.WithAttributes(attribute.String("error.type", "decision_service")))
@@ -106,6 +129,28 @@ func (o *WorkflowOrchestrator) ProcessUserRequest(ctx context.Context, chatID in
 	return nil
 }
 
+// SetRiskProfile remembers the chat's risk profile, an unknown name is rejected
+func (o *WorkflowOrchestrator) SetRiskProfile(chatID int64, profile string) error {
+	profile = strings.ToLower(strings.TrimSpace(profile))
+	for _, known := range RiskProfiles {
+		if profile == known {
+			o.riskMu.Lock()
+			o.riskProfiles[chatID] = profile
+			o.saveRiskProfiles()
+			o.riskMu.Unlock()
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown risk profile: %s", profile)
+}
+
+// RiskProfile returns the chat's risk profile or an empty string for the default
+func (o *WorkflowOrchestrator) RiskProfile(chatID int64) string {
+	o.riskMu.RLock()
+	defer o.riskMu.RUnlock()
+	return o.riskProfiles[chatID]
+}
+
 // extractCryptoSymbol extracts cryptocurrency symbol from user message
 func (o *WorkflowOrchestrator) extractCryptoSymbol(message string) string {
 	message = strings.ToLower(message)
@@ -137,22 +182,288 @@ func (o *WorkflowOrchestrator) extractCryptoSymbol(message string) string {
 	return "BTC"
 }
 
+// verdictMark is a compact arrow for a timeframe verdict
+func verdictMark(verdict string) string {
+	switch verdict {
+	case "buy":
+		return "▲"
+	case "sell":
+		return "▼"
+	default:
+		return "•"
+	}
+}
+
 // formatDecisionMessage formats the decision into a user-friendly message
 func (o *WorkflowOrchestrator) formatDecisionMessage(decision *models.DecisionResponse) string {
-	var emoji string
-	switch strings.ToLower(decision.Decision) {
+	text := fmt.Sprintf(
+		"%s\n\n",
+		decisionEmoji(decision.Decision),
+	)
+
+	if decision.Confidence > 0 {
+		text += fmt.Sprintf("🎯 Confidence: %.0f%%", decision.Confidence*100)
+		if decision.Horizon != "" {
+			text += fmt.Sprintf(" (horizon %s)", decision.Horizon)
+		}
+		text += "\n"
+	}
+	if decision.Reason != "" {
+		text += fmt.Sprintf("💬 %s\n", decision.Reason)
+	}
+
+	if r := decision.Risk; r != nil {
+		if r.Refused {
+			text += fmt.Sprintf("⚠️ %s refused: %s\n", strings.ToUpper(r.OriginalDecision), r.Reason)
+		}
+		if r.PositionSize > 0 {
+			text += fmt.Sprintf("📐 Position size: %.1f%% of capital (%s)\n", r.PositionSize*100, r.Profile)
+		}
+		if r.StopLoss > 0 {
+			text += fmt.Sprintf("🛑 Stop-loss: $%s\n", formatPrice(r.StopLoss))
+		}
+		if r.TakeProfit > 0 {
+			text += fmt.Sprintf("🏁 Take-profit: $%s\n", formatPrice(r.TakeProfit))
+		}
+	}
+
+	if len(decision.Timeframes) > 0 {
+		text += "🕒 Timeframes:"
+		for _, tf := range decision.Timeframes {
+			text += fmt.Sprintf(" %s %s", tf.Timeframe, verdictMark(tf.Verdict))
+		}
+		text += "\n"
+	}
+	if a := decision.Agreement; a != nil && a.Blocked {
+		if a.Total < a.Required {
+			text += fmt.Sprintf("⚠️ %s needs %d timeframes, only %d have data\n", strings.ToUpper(a.OriginalDecision), a.Required, a.Total)
+		} else {
+			text += fmt.Sprintf("⚠️ %s needs %d of %d timeframes, only %d agree\n", strings.ToUpper(a.OriginalDecision), a.Required, a.Total, a.Agreed)
+		}
+	}
+
+	if trade := decision.PaperTrade; trade != nil {
+		text += fmt.Sprintf("📒 Paper trade: %s %.6f %s @ $%s\n", strings.ToUpper(trade.Side), trade.Quantity, trade.Symbol, formatPrice(trade.Price))
+	}
+
+	return text
+}
+
+// ProcessPortfolioRequest sends the user's paper portfolio
+func (o *WorkflowOrchestrator) ProcessPortfolioRequest(ctx context.Context, chatID int64) error {
+	ctx, span := o.tracer.Start(ctx, "WorkflowOrchestrator.ProcessPortfolioRequest")
+	defer span.End()
+
+	span.SetAttributes(attribute.Int64("telegram.chat_id", chatID))
+
+	portfolio, err := o.decisionService.GetPortfolio(ctx, chatID)
+	if err != nil {
+		span.RecordError(err)
+		o.metrics.ErrorsCounter.Add(ctx, 1, metric.WithAttributes(attribute.String("error.type", "decision_service")))
+		return o.sendFailure(ctx, chatID, "❌ Failed to load portfolio. Please try again later.", err)
+	}
+
+	msg := tgbotapi.NewMessage(chatID, o.formatPortfolioMessage(portfolio))
+	if err := o.telegramBot.SendMessage(ctx, chatID, &msg); err != nil {
+		span.RecordError(err)
+		o.metrics.ErrorsCounter.Add(ctx, 1, metric.WithAttributes(attribute.String("error.type", "telegram_final")))
+		return fmt.Errorf("failed to send portfolio message: %w", err)
+	}
+
+	o.metrics.MessagesSentCounter.Add(ctx, 1)
+	return nil
+}
+
+// ProcessPnLRequest sends profit and loss of the user's paper portfolio
+func (o *WorkflowOrchestrator) ProcessPnLRequest(ctx context.Context, chatID int64) error {
+	ctx, span := o.tracer.Start(ctx, "WorkflowOrchestrator.ProcessPnLRequest")
+	defer span.End()
+
+	span.SetAttributes(attribute.Int64("telegram.chat_id", chatID))
+
+	pnl, err := o.decisionService.GetPnL(ctx, chatID)
+	if err != nil {
+		span.RecordError(err)
+		o.metrics.ErrorsCounter.Add(ctx, 1, metric.WithAttributes(attribute.String("error.type", "decision_service")))
+		return o.sendFailure(ctx, chatID, "❌ Failed to load PnL. Please try again later.", err)
+	}
+
+	msg := tgbotapi.NewMessage(chatID, o.formatPnLMessage(pnl))
+	if err := o.telegramBot.SendMessage(ctx, chatID, &msg); err != nil {
+		span.RecordError(err)
+		o.metrics.ErrorsCounter.Add(ctx, 1, metric.WithAttributes(attribute.String("error.type", "telegram_final")))
+		return fmt.Errorf("failed to send pnl message: %w", err)
+	}
+
+	o.metrics.MessagesSentCounter.Add(ctx, 1)
+	return nil
+}
+
+// RebalanceUsage explains the /rebalance arguments
+const RebalanceUsage = "Usage: /rebalance BTC ETH SOL — rebalance the paper portfolio with these coins\n" +
+	"or /rebalance BTC=0.5 ETH=2 cash=1000 — rebalance your own basket"
+
+// ParseRebalanceArgs parses /rebalance arguments: SYMBOL adds a coin to consider,
+// SYMBOL=QTY declares a holding and cash=AMOUNT the free cash of the basket
+func ParseRebalanceArgs(args string) (models.RebalanceRequest, error) {
+	var req models.RebalanceRequest
+	for _, field := range strings.Fields(args) {
+		name, value, found := strings.Cut(field, "=")
+		if !found {
+			req.Symbols = append(req.Symbols, strings.ToUpper(field))
+			continue
+		}
+		amount, err := strconv.ParseFloat(value, 64)
+		if err != nil || amount < 0 {
+			return req, fmt.Errorf("invalid amount in %q", field)
+		}
+		if strings.EqualFold(name, "cash") {
+			req.Cash = &amount
+			continue
+		}
+		req.Holdings = append(req.Holdings, models.RebalanceHolding{Symbol: strings.ToUpper(name), Quantity: amount})
+	}
+	return req, nil
+}
+
+// ProcessRebalanceRequest sends per-coin decisions and rebalancing trades for the user's basket
+func (o *WorkflowOrchestrator) ProcessRebalanceRequest(ctx context.Context, chatID int64, args string) error {
+	ctx, span := o.tracer.Start(ctx, "WorkflowOrchestrator.ProcessRebalanceRequest")
+	defer span.End()
+
+	span.SetAttributes(
+		attribute.Int64("telegram.chat_id", chatID),
+		attribute.String("rebalance.args", args),
+	)
+
+	req, err := ParseRebalanceArgs(args)
+	if err != nil {
+		span.RecordError(err)
+		return o.sendFailure(ctx, chatID, "❌ "+err.Error()+"\n\n"+RebalanceUsage, err)
+	}
+	req.ChatID = chatID
+	req.Risk = o.RiskProfile(chatID)
+
+	msg := tgbotapi.NewMessage(chatID, "🔍 Analyzing your basket...")
+	if err := o.telegramBot.SendMessage(ctx, chatID, &msg); err != nil {
+		span.RecordError(err)
+		o.metrics.ErrorsCounter.Add(ctx, 1, metric.WithAttributes(attribute.String("error.type", "telegram_initial")))
+		return fmt.Errorf("failed to send initial message: %w", err)
+	}
+
+	plan, err := o.decisionService.Rebalance(ctx, req)
+	if err != nil {
+		span.RecordError(err)
+		o.metrics.ErrorsCounter.Add(ctx, 1, metric.WithAttributes(attribute.String("error.type", "decision_service")))
+		return o.sendFailure(ctx, chatID, "❌ Failed to rebalance. Please try again later.\n\n"+RebalanceUsage, err)
+	}
+
+	msg = tgbotapi.NewMessage(chatID, o.formatRebalanceMessage(plan))
+	if err := o.telegramBot.SendMessage(ctx, chatID, &msg); err != nil {
+		span.RecordError(err)
+		o.metrics.ErrorsCounter.Add(ctx, 1, metric.WithAttributes(attribute.String("error.type", "telegram_final")))
+		return fmt.Errorf("failed to send rebalance message: %w", err)
+	}
+
+	o.metrics.MessagesSentCounter.Add(ctx, 2)
+	return nil
+}
+
+// sendFailure notifies the user about a failed request and returns the original error
+func (o *WorkflowOrchestrator) sendFailure(ctx context.Context, chatID int64, text string, cause error) error {
+	msg := tgbotapi.NewMessage(chatID, text)
+	if sendErr := o.telegramBot.SendMessage(ctx, chatID, &msg); sendErr != nil {
+		log.Printf("Failed to send error message: %v", sendErr)
+	}
+	return cause
+}
+
+// formatPortfolioMessage formats the paper portfolio into a user-friendly message
+func (o *WorkflowOrchestrator) formatPortfolioMessage(portfolio *models.PortfolioResponse) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "💼 Paper portfolio\n\nEquity: $%.2f\nCash: $%.2f\n", portfolio.Equity, portfolio.Cash)
+
+	if len(portfolio.Positions) == 0 {
+		b.WriteString("\nNo open positions")
+		return b.String()
+	}
+
+	b.WriteString("\n")
+	for _, pos := range portfolio.Positions {
+		fmt.Fprintf(&b, "%s: %.6f @ $%s → $%s (%+.2f)\n",
+			pos.Symbol, pos.Quantity, formatPrice(pos.AvgPrice), formatPrice(pos.Price), pos.UnrealizedPnL)
+	}
+	return b.String()
+}
+
+// formatPnLMessage formats the paper portfolio PnL into a user-friendly message
+func (o *WorkflowOrchestrator) formatPnLMessage(pnl *models.PnLResponse) string {
+	emoji := "🟢"
+	if pnl.TotalPnL < 0 {
+		emoji = "🔴"
+	}
+
+	return fmt.Sprintf(
+		"%s PnL: %+.2f (%+.2f%%)\n\nRealized: %+.2f\nUnrealized: %+.2f\nFees: %.2f\nTrades: %d\nEquity: $%.2f of $%.2f",
+		emoji, pnl.TotalPnL, pnl.ReturnPct,
+		pnl.RealizedPnL, pnl.UnrealizedPnL, pnl.Fees, pnl.Trades,
+		pnl.Equity, pnl.InitialCash,
+	)
+}
+
+// formatRebalanceMessage formats decisions, target weights and trades of the basket
+func (o *WorkflowOrchestrator) formatRebalanceMessage(plan *models.RebalanceResponse) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "⚖️ Rebalance (%s)\n\nEquity: $%.2f\n\n", plan.Source, plan.Equity)
+
+	for _, action := range plan.Actions {
+		if action.Error != "" {
+			fmt.Fprintf(&b, "⚪ %s: no decision, kept as is\n", action.Symbol)
+			continue
+		}
+		fmt.Fprintf(&b, "%s %s", decisionEmoji(action.Decision), action.Symbol)
+		if action.Confidence > 0 {
+			fmt.Fprintf(&b, " (%.0f%%)", action.Confidence*100)
+		}
+		b.WriteString("\n")
+	}
+
+	b.WriteString("\nTarget allocation:\n")
+	for _, a := range plan.Allocations {
+		fmt.Fprintf(&b, "%s: %.1f%% → %.1f%%\n", a.Symbol, a.CurrentWeight*100, a.TargetWeight*100)
+	}
+	fmt.Fprintf(&b, "Cash: %.1f%% → %.1f%%\n", plan.CashWeight*100, plan.TargetCashWeight*100)
+
+	if len(plan.Trades) == 0 {
+		b.WriteString("\nNo trades needed")
+		return b.String()
+	}
+	b.WriteString("\nTrades:\n")
+	for _, t := range plan.Trades {
+		fmt.Fprintf(&b, "%s %.6f %s @ $%s ($%.2f)\n", strings.ToUpper(t.Side), t.Quantity, t.Symbol, formatPrice(t.Price), t.Value)
+	}
+	return b.String()
+}
+
+// formatPrice prints an asset price with cents above a dollar and with
+// six significant digits below it, so cheap coins do not show as $0.00
+func formatPrice(price float64) string {
+	if math.Abs(price) >= 1 || price == 0 {
+		return strconv.FormatFloat(price, 'f', 2, 64)
+	}
+	return strconv.FormatFloat(price, 'f', 5-int(math.Floor(math.Log10(math.Abs(price)))), 64)
+}
+
+// decisionEmoji marks a decision with a colored circle
+func decisionEmoji(decision string) string {
+	switch strings.ToLower(decision) {
 	case "buy", "покупать":
-		emoji = "🟢 BUY"
+		return "🟢 BUY"
 	case "sell", "продавать":
-		emoji = "🔴 SELL"
+		return "🔴 SELL"
 	case "hold", "держать":
-		emoji = "🟡 HOLD"
+		return "🟡 HOLD"
 	default:
-		emoji = "⚪ " + strings.ToUpper(decision.Decision)
+		return "⚪ " + strings.ToUpper(decision)
 	}
-
-	return fmt.Sprintf(
-		"%s\n\n",
-		emoji,
-	)
 }
@@ -57,7 +57,7 @@ func main() {
 	}
 
 	// Initialize clients for other services
-	decisionClient := services.NewDecisionService(cfg.DecisionServiceURL, cfg.HTTPTimeout)
+	decisionClient := services.NewDecisionService(cfg.DecisionServiceURL, cfg.HTTPTimeout, cfg.DecisionLanguage)
 	//telegramService := services.NewTelegramService(cfg.TelegramToken, cfg.HTTPTimeout)
 
 	telegramBot, err := telegram.NewBot(cfg)
@@ -71,6 +71,13 @@ func main() {
 		telegramBot,
 		metrics,
 	)
+	workflowOrchestrator.SetAdminChat(cfg.AdminChatID)
+	if err := workflowOrchestrator.LoadRiskProfiles(cfg.RiskProfilesFile); err != nil {
+		slog.Error("Failed to load risk profiles", "error", err)
+	}
+	if err := workflowOrchestrator.LoadSubscriptions(cfg.SubscriptionsFile); err != nil {
+		slog.Error("Failed to load subscriptions", "error", err)
+	}
 
 	// Initialize Telegram poller
 
@@ -86,10 +93,11 @@ func main() {
 
 	// Initialize handlers
 	telegramHandler := handlers.NewTelegramHandler(bot, workflowOrchestrator, metrics)
+	notificationHandler := handlers.NewNotificationHandler(workflowOrchestrator, metrics)
 
 	// Create and setup HTTP server
 	srv := server.New(cfg)
-	srv.SetupRoutes(telegramHandler, metrics)
+	srv.SetupRoutes(telegramHandler, notificationHandler, metrics)
 
 	// Start server in a goroutine
 	go func() {